@@ -1,7 +1,32 @@
 // Package adif implements a high performance ADIF library for Go.
-// It provides types and methods for reading and writing Amateur Data Interchange Format (ADI) files.
+// It provides types and methods for reading and writing Amateur Data Interchange Format (ADI and ADX) files.
 //
 // Use Scanner for streaming large files record-by-record.
 // Use Document for loading a complete file into memory.
 // Use Writer for streaming output.
+// Use ADXScanner and ADXWriter to read and write the XML based ADX format.
+//
+// # Reading and writing records
+//
+// Scanner, ADXScanner and NDJSONScanner read records in the same ways.
+// Scan advances one record at a time. All returns an iterator over the records, including the header,
+// for use with a for-range loop; call IsHeader within the loop to tell the header from QSO records.
+// If scanning fails, All yields a nil Record with the error and stops:
+//
+//	for r, err := range s.All() {
+//	    if err != nil { ... }
+//	}
+//
+// QSOs skips the header and stops at the first error, which Err returns after the loop.
+// Decode skips the header and unmarshals the next QSO record into a struct, returning io.EOF when no records remain:
+//
+//	for {
+//	    var q QSO
+//	    if err := s.Decode(&q); err == io.EOF {
+//	        break
+//	    } else if err != nil { ... }
+//	}
+//
+// ScanContext and the WriteContext methods of Writer, ADXWriter and NDJSONWriter check their context between records,
+// so that a canceled request stops an import or export loop without leaving a record half read or half written.
 package adif
@@ -0,0 +1,323 @@
+package adif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// ADX element and attribute names as defined by the ADIF specification.
+const (
+	adxElementRoot    = "ADX"
+	adxElementHeader  = "HEADER"
+	adxElementRecords = "RECORDS"
+	adxElementRecord  = "RECORD"
+	adxElementApp     = "APP"
+	adxElementUserdef = "USERDEF"
+	adxAttrProgramID  = "PROGRAMID"
+	adxAttrFieldName  = "FIELDNAME"
+	adxAttrFieldID    = "FIELDID"
+	adxAttrEnum       = "ENUM"
+	adxAttrRange      = "RANGE"
+	adxAttrType       = "TYPE"
+	adxXMLDeclaration = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+)
+
+// ADXScanner reads ADIF *.adx (XML) records sequentially from an io.Reader.
+// It yields the same Record type and header semantics as Scanner.
+// Use NewADXScanner to create one, then call Scan in a loop.
+//
+// APP elements are returned as APP_{PROGRAMID}_{FIELDNAME} fields.
+// Header USERDEF elements are returned as USERDEFn fields whose value is the field name
+// followed by its optional enumeration or range, exactly as they appear in an ADI header (e.g. "SHOESIZE,{5:20}").
+// Record USERDEF elements are returned as fields named by their FIELDNAME attribute.
+// TYPE attributes are reported by DataTypeIndicator and DataTypeIndicators.
+//
+//	s := adif.NewADXScanner(r)
+//	for s.Scan() {
+//	    if s.IsHeader() {
+//	        continue
+//	    }
+//	    record := s.Record()
+//	}
+//	if err := s.Err(); err != nil { ... }
+type ADXScanner struct {
+	d         *xml.Decoder
+	current   Record
+	types     map[adifield.Field]aditype.DataTypeIndicator
+	isHeader  bool
+	err       error
+	inRoot    bool
+	inRecords bool
+	ended     bool
+	records   int
+}
+
+// NewADXScanner returns an ADXScanner that reads ADX records from r.
+func NewADXScanner(r io.Reader) *ADXScanner {
+	return &ADXScanner{
+		d: xml.NewDecoder(r),
+	}
+}
+
+// Scan advances to the next record and returns true if one was found.
+// Call Record to retrieve it and IsHeader to determine its type.
+// Returns false when no more records exist or an error occurred.
+// After Scan returns false, call Err to check for any non-EOF error.
+func (s *ADXScanner) Scan() bool {
+	s.current, s.isHeader, s.err = s.next()
+	if s.err != nil {
+		return false
+	}
+	s.records++
+	return true
+}
+
+// ScanContext is like Scan, but returns false once ctx is done; Err then returns ctx.Err().
+func (s *ADXScanner) ScanContext(ctx context.Context) bool {
+	if err := ctx.Err(); err != nil {
+		s.current, s.isHeader, s.err = nil, false, err
+		return false
+	}
+	return s.Scan()
+}
+
+// Record returns the record from the most recent successful Scan call.
+func (s *ADXScanner) Record() Record { return s.current }
+
+// IsHeader reports whether the record from the most recent Scan call is a header record.
+func (s *ADXScanner) IsHeader() bool { return s.isHeader }
+
+// DataTypeIndicator returns the TYPE attribute given for field in the record from the most recent Scan call,
+// or aditype.DATATYPEINDICATOR_NONE when the element had none.
+func (s *ADXScanner) DataTypeIndicator(field adifield.Field) aditype.DataTypeIndicator {
+	return s.types[field]
+}
+
+// DataTypeIndicators returns the TYPE attributes given in the record from the most recent Scan call,
+// keyed by field, or nil when none of its elements had one.
+// Indicators are normalized to uppercase.
+func (s *ADXScanner) DataTypeIndicators() map[adifield.Field]aditype.DataTypeIndicator {
+	return s.types
+}
+
+// Err returns the first non-EOF error encountered by the ADXScanner.
+// Returns nil when Scan stopped due to io.EOF.
+// Malformed input is reported as a *ParseError wrapping ErrMalformedADX.
+func (s *ADXScanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// All returns an iterator over the remaining records, including the header, and the error that ends the scan.
+func (s *ADXScanner) All() iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for s.Scan() {
+			if !yield(s.current, nil) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// QSOs returns an iterator over the remaining QSO records, skipping the header; call Err after the loop.
+func (s *ADXScanner) QSOs() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		for s.Scan() {
+			if !s.isHeader && !yield(s.current) {
+				return
+			}
+		}
+	}
+}
+
+// Decode unmarshals the next QSO record into v as Unmarshal does, and returns io.EOF after the last one.
+func (s *ADXScanner) Decode(v any) error {
+	for s.Scan() {
+		if !s.isHeader {
+			return Unmarshal(s.current, v)
+		}
+	}
+	return s.err
+}
+
+// next reads tokens until the next HEADER or RECORD element has been consumed.
+// It returns io.EOF once the ADX element has ended, without reading anything that follows it.
+func (s *ADXScanner) next() (Record, bool, error) {
+	if s.ended {
+		return nil, false, io.EOF
+	}
+	for {
+		tok, err := s.d.Token()
+		if err != nil {
+			if err == io.EOF && !s.inRoot {
+				return nil, false, io.EOF
+			}
+			return nil, false, s.newParseError("", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToUpper(t.Name.Local)
+			switch {
+			case !s.inRoot:
+				if name != adxElementRoot {
+					return nil, false, s.newParseError("", fmt.Errorf("unexpected root element <%s>", t.Name.Local))
+				}
+				s.inRoot = true
+			case s.inRecords && name == adxElementRecord:
+				r, err := s.readRecord(false)
+				return r, false, err
+			case !s.inRecords && name == adxElementHeader:
+				r, err := s.readRecord(true)
+				return r, true, err
+			case !s.inRecords && name == adxElementRecords:
+				s.inRecords = true
+			default:
+				// Unknown elements are ignored for forward compatibility.
+				if err := s.d.Skip(); err != nil {
+					return nil, false, s.newParseError("", err)
+				}
+			}
+		case xml.EndElement:
+			if !s.inRecords {
+				s.inRoot, s.ended = false, true
+				return nil, false, io.EOF
+			}
+			s.inRecords = false
+		}
+	}
+}
+
+// readRecord reads the field elements of a HEADER or RECORD element whose start tag has already been consumed.
+func (s *ADXScanner) readRecord(isHeader bool) (Record, error) {
+	result := NewRecord()
+	s.types = nil
+	for {
+		tok, err := s.d.Token()
+		if err != nil {
+			return nil, s.newParseError("", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			field, err := adxFieldName(t, isHeader)
+			if err != nil {
+				return nil, s.newParseError(adifield.New(t.Name.Local), err)
+			}
+			value, err := s.readValue(field)
+			if err != nil {
+				return nil, err
+			}
+			if isHeader && strings.EqualFold(t.Name.Local, adxElementUserdef) {
+				value = adxUserdefHeaderValue(t, value)
+			}
+			if value == "" {
+				continue
+			}
+			result[field] = value
+			if typ := adxAttr(t, adxAttrType); typ != "" {
+				if s.types == nil {
+					s.types = make(map[adifield.Field]aditype.DataTypeIndicator)
+				}
+				r, _ := utf8.DecodeRuneInString(typ)
+				s.types[field] = aditype.NewDataTypeIndicator(r)
+			}
+		case xml.EndElement:
+			return result, nil
+		}
+	}
+}
+
+// readValue reads the character data of a field element whose start tag has already been consumed.
+func (s *ADXScanner) readValue(field adifield.Field) (string, error) {
+	var value string
+	for {
+		tok, err := s.d.Token()
+		if err != nil {
+			return "", s.newParseError(field, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.CharData:
+			value += string(t)
+		case xml.StartElement:
+			return "", s.newParseError(field, fmt.Errorf("unexpected element <%s> in field value", t.Name.Local))
+		case xml.EndElement:
+			return value, nil
+		}
+	}
+}
+
+// newParseError wraps err in a *ParseError wrapping ErrMalformedADX, positioned at the decoder's current location.
+func (s *ADXScanner) newParseError(field adifield.Field, err error) error {
+	line, column := s.d.InputPos()
+	return &ParseError{
+		Offset: s.d.InputOffset(),
+		Line:   line,
+		Column: column,
+		Record: s.records,
+		Field:  field,
+		Err:    fmt.Errorf("%w: %w", ErrMalformedADX, err),
+	}
+}
+
+// adxFieldName returns the Record field for an ADX field element.
+func adxFieldName(t xml.StartElement, isHeader bool) (adifield.Field, error) {
+	switch strings.ToUpper(t.Name.Local) {
+	case adxElementApp:
+		programID, fieldName := adxAttr(t, adxAttrProgramID), adxAttr(t, adxAttrFieldName)
+		if programID == "" || fieldName == "" {
+			return "", fmt.Errorf("<%s> requires %s and %s attributes", t.Name.Local, adxAttrProgramID, adxAttrFieldName)
+		}
+		return adifield.New(adifield.APP_ + programID + "_" + fieldName), nil
+	case adxElementUserdef:
+		if isHeader {
+			fieldID := adxAttr(t, adxAttrFieldID)
+			if fieldID == "" {
+				return "", fmt.Errorf("header <%s> requires a %s attribute", t.Name.Local, adxAttrFieldID)
+			}
+			return adifield.New(adifield.USERDEF + fieldID), nil
+		}
+		fieldName := adxAttr(t, adxAttrFieldName)
+		if fieldName == "" {
+			return "", fmt.Errorf("<%s> requires a %s attribute", t.Name.Local, adxAttrFieldName)
+		}
+		return adifield.New(fieldName), nil
+	}
+	return adifield.New(t.Name.Local), nil
+}
+
+// adxUserdefHeaderValue appends the optional ENUM or RANGE attribute of a header USERDEF element to its field name,
+// producing the same value an ADI header carries.
+func adxUserdefHeaderValue(t xml.StartElement, name string) string {
+	if enum := adxAttr(t, adxAttrEnum); enum != "" {
+		return name + userdefSeparator + enum
+	}
+	if rng := adxAttr(t, adxAttrRange); rng != "" {
+		return name + userdefSeparator + rng
+	}
+	return name
+}
+
+// adxAttr returns the value of the named attribute, matched case-insensitively, or an empty string.
+func adxAttr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,306 @@
+package adif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+	"github.com/farmergreg/spec/v6/enum/mode"
+)
+
+const adxTestDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<ADX>
+    <HEADER>
+        <!-- This is a comment -->
+        <ADIF_VER>3.1.6</ADIF_VER>
+        <PROGRAMID>MonoLog</PROGRAMID>
+        <USERDEF FIELDID="1" TYPE="N">EPC</USERDEF>
+        <USERDEF FIELDID="2" TYPE="E" ENUM="{S,M,L}">SWEATERSIZE</USERDEF>
+        <USERDEF FIELDID="3" TYPE="N" RANGE="{5:20}">SHOESIZE</USERDEF>
+    </HEADER>
+    <RECORDS>
+        <RECORD>
+            <QSO_DATE>19900620</QSO_DATE>
+            <TIME_ON>1523</TIME_ON>
+            <call>VK9NS</call>
+            <BAND>20M</BAND>
+            <MODE>RTTY</MODE>
+            <NAME_INTL>Jürgen</NAME_INTL>
+            <COMMENT></COMMENT>
+            <USERDEF FIELDNAME="SweaterSize">M</USERDEF>
+            <USERDEF FIELDNAME="ShoeSize">11</USERDEF>
+            <APP PROGRAMID="MONOLOG" FIELDNAME="Compression" TYPE="S">off</APP>
+        </RECORD>
+        <RECORD>
+            <CALL>ON4UN</CALL>
+            <NOTES_INTL>line 1&#xD;&#xA;line &lt;2&gt;</NOTES_INTL>
+        </RECORD>
+    </RECORDS>
+</ADX>
+`
+
+func TestADXScanner_Document(t *testing.T) {
+	s := NewADXScanner(strings.NewReader(adxTestDocument))
+
+	if !s.Scan() {
+		t.Fatalf("expected header; Err=%v", s.Err())
+	}
+	if !s.IsHeader() {
+		t.Fatal("expected first record to be the header")
+	}
+	hdr := s.Record()
+	want := Record{
+		adifield.ADIF_VER:  "3.1.6",
+		adifield.PROGRAMID: "MonoLog",
+		adifield.USERDEF1:  "EPC",
+		adifield.USERDEF2:  "SWEATERSIZE,{S,M,L}",
+		adifield.USERDEF3:  "SHOESIZE,{5:20}",
+	}
+	assertRecordEqual(t, hdr, want)
+	if got := s.DataTypeIndicator(adifield.USERDEF2); got != aditype.DATATYPEINDICATOR_ENUMERATION {
+		t.Errorf("USERDEF2 TYPE: got %q, want %q", got, aditype.DATATYPEINDICATOR_ENUMERATION)
+	}
+
+	if !s.Scan() {
+		t.Fatalf("expected first QSO; Err=%v", s.Err())
+	}
+	if s.IsHeader() {
+		t.Fatal("expected QSO record")
+	}
+	want = Record{
+		adifield.QSO_DATE:                       "19900620",
+		adifield.TIME_ON:                        "1523",
+		adifield.CALL:                           "VK9NS",
+		adifield.BAND:                           "20M",
+		adifield.MODE:                           "RTTY",
+		adifield.NAME_INTL:                      "Jürgen",
+		adifield.New("SWEATERSIZE"):             "M",
+		adifield.New("SHOESIZE"):                "11",
+		adifield.New("APP_MONOLOG_COMPRESSION"): "off",
+	}
+	assertRecordEqual(t, s.Record(), want)
+	if got := s.DataTypeIndicator(adifield.New("APP_MONOLOG_COMPRESSION")); got != aditype.DATATYPEINDICATOR_STRING {
+		t.Errorf("APP TYPE: got %q, want %q", got, aditype.DATATYPEINDICATOR_STRING)
+	}
+	if len(s.DataTypeIndicators()) != 1 {
+		t.Errorf("DataTypeIndicators: got %v, want only APP_MONOLOG_COMPRESSION", s.DataTypeIndicators())
+	}
+
+	if !s.Scan() {
+		t.Fatalf("expected second QSO; Err=%v", s.Err())
+	}
+	if s.DataTypeIndicators() != nil {
+		t.Errorf("DataTypeIndicators: got %v, want nil", s.DataTypeIndicators())
+	}
+	want = Record{
+		adifield.CALL:       "ON4UN",
+		adifield.NOTES_INTL: "line 1\r\nline <2>",
+	}
+	assertRecordEqual(t, s.Record(), want)
+
+	if s.Scan() {
+		t.Fatal("expected no more records")
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestADXScanner_NoHeaderAndUnknownElements(t *testing.T) {
+	adx := `<adx><EXTENSION><X>ignored</X></EXTENSION><records><NOTARECORD/><record><CALL>K9CTS</CALL></record></records></adx>`
+	s := NewADXScanner(strings.NewReader(adx))
+	count := 0
+	for s.Scan() {
+		if s.IsHeader() {
+			t.Error("unexpected header")
+		}
+		if s.Record()[adifield.CALL] != "K9CTS" {
+			t.Errorf("CALL: got %q, want %q", s.Record()[adifield.CALL], "K9CTS")
+		}
+		count++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("record count: got %d, want 1", count)
+	}
+}
+
+func TestADXScanner_Empty(t *testing.T) {
+	for _, adx := range []string{"", "  \n", `<?xml version="1.0"?><ADX/>`, `<ADX><RECORDS></RECORDS></ADX>`} {
+		s := NewADXScanner(strings.NewReader(adx))
+		if s.Scan() {
+			t.Errorf("%q: expected no records", adx)
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("%q: %v", adx, err)
+		}
+	}
+}
+
+func TestADXScanner_AfterRoot(t *testing.T) {
+	adx := `<ADX><RECORDS><RECORD><CALL>K9CTS</CALL></RECORD></RECORDS></ADX>` +
+		`<ADX><RECORDS><RECORD><CALL>W9PVA</CALL></RECORD></RECORDS></ADX>`
+	s := NewADXScanner(strings.NewReader(adx))
+	var calls []string
+	for r := range s.QSOs() {
+		calls = append(calls, r[adifield.CALL])
+	}
+	if s.Err() != nil || len(calls) != 1 || calls[0] != "K9CTS" {
+		t.Errorf("got %v, %v; want [K9CTS]", calls, s.Err())
+	}
+	if s.Scan() || s.Err() != nil {
+		t.Errorf("Scan after the ADX element: got %v", s.Err())
+	}
+}
+
+func TestADXScanner_Malformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		field adifield.Field
+	}{
+		{"Wrong root", `<ADIF><RECORDS/></ADIF>`, ""},
+		{"Truncated document", `<ADX><RECORDS><RECORD><CALL>K9CTS</CALL>`, ""},
+		{"Truncated value", `<ADX><RECORDS><RECORD><CALL>K9CTS`, adifield.CALL},
+		{"Truncated after root", `<ADX>`, ""},
+		{"Nested element in value", `<ADX><RECORDS><RECORD><CALL><X/></CALL></RECORD></RECORDS></ADX>`, adifield.CALL},
+		{"Mismatched tags", `<ADX><RECORDS><RECORD><CALL>K9CTS</BAND></RECORD></RECORDS></ADX>`, adifield.CALL},
+		{"Bad unknown element", `<ADX><OTHER><X></OTHER></ADX>`, ""},
+		{"APP missing attributes", `<ADX><RECORDS><RECORD><APP PROGRAMID="X">v</APP></RECORD></RECORDS></ADX>`, "APP"},
+		{"Header USERDEF missing FIELDID", `<ADX><HEADER><USERDEF>EPC</USERDEF></HEADER></ADX>`, adifield.USERDEF},
+		{"Record USERDEF missing FIELDNAME", `<ADX><RECORDS><RECORD><USERDEF>1</USERDEF></RECORD></RECORDS></ADX>`, adifield.USERDEF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewADXScanner(strings.NewReader(tt.data))
+			for s.Scan() {
+			}
+			if !errors.Is(s.Err(), ErrMalformedADX) {
+				t.Fatalf("Err(): got %v, want ErrMalformedADX", s.Err())
+			}
+			var pe *ParseError
+			if !errors.As(s.Err(), &pe) {
+				t.Fatalf("expected *ParseError, got %T", s.Err())
+			}
+			if pe.Field != tt.field {
+				t.Errorf("Field: got %q, want %q", pe.Field, tt.field)
+			}
+			if pe.Line < 1 {
+				t.Errorf("Line: got %d, want >= 1", pe.Line)
+			}
+			if s.Record() != nil {
+				t.Errorf("expected nil record, got %v", s.Record())
+			}
+		})
+	}
+}
+
+func TestADXScanner_ParseErrorRecordIndex(t *testing.T) {
+	adx := `<ADX><HEADER/><RECORDS><RECORD><CALL>A</CALL></RECORD><RECORD><CALL>B</CALL><X><Y/></X></RECORD></RECORDS></ADX>`
+	s := NewADXScanner(strings.NewReader(adx))
+	for s.Scan() {
+	}
+	var pe *ParseError
+	if !errors.As(s.Err(), &pe) {
+		t.Fatalf("expected *ParseError, got %v", s.Err())
+	}
+	if pe.Record != 2 {
+		t.Errorf("Record: got %d, want 2", pe.Record)
+	}
+}
+
+// assertRecordEqual fails the test when got and want differ.
+func assertRecordEqual(t *testing.T, got, want Record) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("field count: got %d (%v), want %d (%v)", len(got), got, len(want), want)
+	}
+	for field, value := range want {
+		if got[field] != value {
+			t.Errorf("%s: got %q, want %q", field, got[field], value)
+		}
+	}
+}
+
+func TestADXScanner_Decode(t *testing.T) {
+	s := NewADXScanner(strings.NewReader(adxTestDocument))
+
+	var q struct {
+		Call string
+		Mode mode.Mode
+		Time time.Time `adif:"QSO_DATE,time=TIME_ON"`
+	}
+	if err := s.Decode(&q); err != nil || q.Call != "VK9NS" || q.Mode != mode.RTTY || !q.Time.Equal(time.Date(1990, 6, 20, 15, 23, 0, 0, time.UTC)) {
+		t.Fatalf("first: got %+v, %v", q, err)
+	}
+	if err := s.Decode(&q); err != nil || q.Call != "ON4UN" {
+		t.Fatalf("second: got %+v, %v", q, err)
+	}
+	if err := s.Decode(&q); err != io.EOF {
+		t.Fatalf("end: got %v, want io.EOF", err)
+	}
+}
+
+func TestADXScanner_All(t *testing.T) {
+	s := NewADXScanner(strings.NewReader(adxTestDocument))
+	n := 0
+	for _, err := range s.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("got %d records, want 3", n)
+	}
+
+	s = NewADXScanner(strings.NewReader(adxTestDocument))
+	for range s.All() {
+		break
+	}
+	var calls []string
+	for r := range s.QSOs() {
+		calls = append(calls, r[adifield.CALL])
+		if len(calls) == 1 {
+			break
+		}
+	}
+	if len(calls) != 1 || calls[0] != "VK9NS" {
+		t.Errorf("QSOs: got %v", calls)
+	}
+
+	s = NewADXScanner(strings.NewReader("<ADX><RECORDS><RECORD><CALL>K9CTS</CALL></RECORD><RECORD><CALL>"))
+	var errs []error
+	for _, err := range s.All() {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrMalformedADX) {
+		t.Errorf("got errors %v", errs)
+	}
+	if got := slices.Collect(NewADXScanner(strings.NewReader(adxTestDocument)).QSOs()); len(got) != 2 {
+		t.Errorf("QSOs: got %d records, want 2", len(got))
+	}
+}
+
+func TestADXScanner_ScanContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewADXScanner(strings.NewReader(adxTestDocument))
+	if !s.ScanContext(ctx) || !s.IsHeader() {
+		t.Fatalf("expected header; Err=%v", s.Err())
+	}
+	cancel()
+	if s.ScanContext(ctx) || !errors.Is(s.Err(), context.Canceled) || s.Record() != nil {
+		t.Errorf("after cancel: got Err %v, Record %v", s.Err(), s.Record())
+	}
+}
@@ -0,0 +1,306 @@
+package adif
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/farmergreg/adif/v5/internal/flush"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// ADXWriter writes ADIF records to an underlying io.Writer in ADX (XML) format.
+// Obtain one with NewADXWriter, write records with WriteHeader and Write,
+// then call Flush once to write the closing tags and flush any buffered data in the underlying writer.
+//
+// APP_{PROGRAMID}_{FIELDNAME} fields are written as APP elements.
+// Header USERDEFn fields are written as USERDEF elements, and QSO fields named by them are written as
+// USERDEF elements with a FIELDNAME attribute.
+// Data type indicators configured with SetDataTypeIndicators or SetAutoDataTypeIndicators
+// are written as TYPE attributes of APP and header USERDEF elements.
+//
+// ADXWriter does not add its own buffering. Wrap the destination with bufio.NewWriter
+// for buffered output, and pass it to both NewADXWriter and Flush.
+type ADXWriter struct {
+	w         io.Writer
+	mode      WriteMode
+	types     dataTypeIndicators
+	wroteData bool
+	inRecords bool
+	finished  bool
+}
+
+// NewADXWriter returns an ADXWriter that writes ADX records to w.
+func NewADXWriter(w io.Writer) *ADXWriter {
+	return &ADXWriter{
+		w: w,
+	}
+}
+
+// SetWriteMode sets the WriteMode for this ADXWriter and returns the ADXWriter for chaining.
+func (w *ADXWriter) SetWriteMode(mode WriteMode) *ADXWriter {
+	w.mode = mode
+	return w
+}
+
+// SetDataTypeIndicators sets the data type indicators written as TYPE attributes and returns the ADXWriter for chaining.
+// It behaves like Writer.SetDataTypeIndicators.
+func (w *ADXWriter) SetDataTypeIndicators(types map[adifield.Field]aditype.DataTypeIndicator) *ADXWriter {
+	w.types.explicit = types
+	return w
+}
+
+// SetAutoDataTypeIndicators enables or disables automatic TYPE attributes and returns the ADXWriter for chaining.
+// It behaves like Writer.SetAutoDataTypeIndicators.
+func (w *ADXWriter) SetAutoDataTypeIndicators(auto bool) *ADXWriter {
+	w.types.auto = auto
+	return w
+}
+
+// WriteHeader writes the ADX HEADER element.
+// The header must be written before any QSO records and may only be written once.
+// User-defined field names declared by USERDEFn fields are remembered so that QSO records can reference them.
+// A field written as an element of its own must have a valid XML name; otherwise WriteHeader returns an error
+// wrapping ErrInvalidValue and writes nothing.
+func (w *ADXWriter) WriteHeader(r Record) error {
+	if w.finished {
+		return ErrWriteAfterFlush
+	}
+	if w.wroteData {
+		return ErrHeaderAlreadyWritten
+	}
+	w.types.learnHeader(r)
+
+	bufPtr := writerBufPool.Get().(*[]byte)
+	buf := append((*bufPtr)[:0], adxXMLDeclaration...)
+	buf = append(buf, "<"+adxElementRoot+">\n<"+adxElementHeader+">"...)
+	buf, err := w.appendFields(buf, r, true)
+	if err == nil {
+		buf = append(buf, "</"+adxElementHeader+">\n"...)
+		w.wroteData = true
+		_, err = w.w.Write(buf)
+	}
+
+	*bufPtr = buf
+	writerBufPool.Put(bufPtr)
+	return err
+}
+
+// Write appends a QSO RECORD element to the output.
+// As with WriteHeader, a field name that is not a valid XML name is reported as an error wrapping ErrInvalidValue.
+// Once Flush has closed the document, Write returns ErrWriteAfterFlush.
+func (w *ADXWriter) Write(r Record) error {
+	if w.finished {
+		return ErrWriteAfterFlush
+	}
+	wroteData, inRecords := w.wroteData, w.inRecords
+	bufPtr := writerBufPool.Get().(*[]byte)
+	buf := w.appendRecordsStart((*bufPtr)[:0])
+
+	start := len(buf)
+	buf = append(buf, "<"+adxElementRecord+">"...)
+	fieldsStart := len(buf)
+	buf, err := w.appendFields(buf, r, false)
+	if err != nil {
+		w.wroteData, w.inRecords = wroteData, inRecords
+	} else {
+		if len(buf) == fieldsStart {
+			buf = buf[:start]
+		} else {
+			buf = append(buf, "</"+adxElementRecord+">\n"...)
+		}
+		if len(buf) > 0 {
+			_, err = w.w.Write(buf)
+		}
+	}
+
+	*bufPtr = buf
+	writerBufPool.Put(bufPtr)
+	return err
+}
+
+// WriteContext is like Write, but returns ctx.Err() without writing once ctx is done.
+func (w *ADXWriter) WriteContext(ctx context.Context, r Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return w.Write(r)
+}
+
+// Flush completes the ADX document by writing the closing RECORDS and ADX tags,
+// then flushes the underlying io.Writer if it implements Flush() error (e.g. bufio.Writer).
+// The closing tags are written only by the first call; call Flush once after the last record,
+// as Write and WriteHeader return ErrWriteAfterFlush afterwards.
+func (w *ADXWriter) Flush() error {
+	if !w.finished {
+		buf := w.appendRecordsStart(nil)
+		buf = append(buf, "</"+adxElementRecords+">\n</"+adxElementRoot+">\n"...)
+		if _, err := w.w.Write(buf); err != nil {
+			return err
+		}
+		w.finished = true
+	}
+	return flush.Writer(w.w)
+}
+
+// appendRecordsStart appends the XML declaration, the ADX start tag and the RECORDS start tag as needed
+// so that a RECORD element may follow.
+func (w *ADXWriter) appendRecordsStart(buf []byte) []byte {
+	if !w.wroteData {
+		buf = append(buf, adxXMLDeclaration...)
+		buf = append(buf, "<"+adxElementRoot+">\n"...)
+		w.wroteData = true
+	}
+	if !w.inRecords {
+		buf = append(buf, "<"+adxElementRecords+">\n"...)
+		w.inRecords = true
+	}
+	return buf
+}
+
+// appendFields appends the non-empty fields of r to buf as ADX elements using the writer's WriteMode.
+func (w *ADXWriter) appendFields(buf []byte, r Record, isHeader bool) ([]byte, error) {
+	var err error
+	if w.mode == WriteModeFast {
+		for field, value := range r {
+			if buf, err = w.appendField(buf, field, value, isHeader); err != nil {
+				return buf, err
+			}
+		}
+		return buf, nil
+	}
+
+	scratchPtr := writerFieldScratchPool.Get().(*[]adifield.Field)
+	scratch := appendFieldOrderPretty((*scratchPtr)[:0], r)
+	for _, field := range scratch {
+		if buf, err = w.appendField(buf, field, r[field], isHeader); err != nil {
+			break
+		}
+	}
+	*scratchPtr = scratch
+	writerFieldScratchPool.Put(scratchPtr)
+	return buf, err
+}
+
+// appendField appends a single ADX field element to buf.
+// Returns buf unchanged when value is empty, and an error wrapping ErrInvalidValue when field
+// would be written as an element but is not a valid XML name.
+func (w *ADXWriter) appendField(buf []byte, field adifield.Field, value string, isHeader bool) ([]byte, error) {
+	if value == "" {
+		return buf, nil
+	}
+
+	if isHeader {
+		if fieldID, ok := userdefFieldID(field); ok {
+			name, constraint, _ := strings.Cut(value, userdefSeparator)
+			buf = append(buf, "<"+adxElementUserdef+" "+adxAttrFieldID+`="`...)
+			buf = append(buf, fieldID...)
+			buf = append(buf, '"')
+			buf = appendADXTypeAttr(buf, w.types.lookup(field, value, true))
+			if constraint != "" {
+				attr := adxAttrEnum
+				if strings.Contains(constraint, ":") {
+					attr = adxAttrRange
+				}
+				buf = appendXMLAttr(buf, attr, constraint)
+			}
+			buf = append(buf, '>')
+			buf = appendXMLEscaped(buf, name)
+			return append(buf, "</"+adxElementUserdef+">"...), nil
+		}
+	} else if w.types.isUserField(field) {
+		buf = append(buf, "<"+adxElementUserdef...)
+		buf = appendXMLAttr(buf, adxAttrFieldName, string(field))
+		buf = append(buf, '>')
+		buf = appendXMLEscaped(buf, value)
+		return append(buf, "</"+adxElementUserdef+">"...), nil
+	}
+
+	if rest, ok := strings.CutPrefix(string(field), adifield.APP_); ok {
+		if programID, fieldName, ok := strings.Cut(rest, "_"); ok && programID != "" && fieldName != "" {
+			buf = append(buf, "<"+adxElementApp...)
+			buf = appendXMLAttr(buf, adxAttrProgramID, programID)
+			buf = appendXMLAttr(buf, adxAttrFieldName, fieldName)
+			buf = appendADXTypeAttr(buf, w.types.lookup(field, value, false))
+			buf = append(buf, '>')
+			buf = appendXMLEscaped(buf, value)
+			return append(buf, "</"+adxElementApp+">"...), nil
+		}
+	}
+
+	if !isXMLName(string(field)) {
+		return buf, fmt.Errorf("%w: field name %q is not a valid XML name", ErrInvalidValue, field)
+	}
+	buf = append(buf, '<')
+	buf = append(buf, field...)
+	buf = append(buf, '>')
+	buf = appendXMLEscaped(buf, value)
+	buf = append(buf, '<', '/')
+	buf = append(buf, field...)
+	return append(buf, '>'), nil
+}
+
+// isXMLName reports whether s is an XML name without a namespace prefix:
+// a letter or underscore followed by letters, digits, underscores, hyphens and periods.
+func isXMLName(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r) && r != '-' && r != '.') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// appendADXTypeAttr appends a TYPE attribute to buf unless dt is aditype.DATATYPEINDICATOR_NONE.
+func appendADXTypeAttr(buf []byte, dt aditype.DataTypeIndicator) []byte {
+	if dt == aditype.DATATYPEINDICATOR_NONE {
+		return buf
+	}
+	return appendXMLAttr(buf, adxAttrType, dt.String())
+}
+
+// appendXMLAttr appends ` name="value"` to buf, escaping value.
+func appendXMLAttr(buf []byte, name, value string) []byte {
+	buf = append(buf, ' ')
+	buf = append(buf, name...)
+	buf = append(buf, '=', '"')
+	buf = appendXMLEscaped(buf, value)
+	return append(buf, '"')
+}
+
+// appendXMLEscaped appends s to buf, escaping XML special characters.
+// Line breaks and tabs are written as character references so that XML line-ending
+// normalization cannot alter multiline values.
+func appendXMLEscaped(buf []byte, s string) []byte {
+	last := 0
+	for i := 0; i < len(s); i++ {
+		var esc string
+		switch s[i] {
+		case '&':
+			esc = "&amp;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			esc = "&gt;"
+		case '"':
+			esc = "&quot;"
+		case '\'':
+			esc = "&apos;"
+		case '\r':
+			esc = "&#xD;"
+		case '\n':
+			esc = "&#xA;"
+		case '\t':
+			esc = "&#x9;"
+		default:
+			continue
+		}
+		buf = append(buf, s[last:i]...)
+		buf = append(buf, esc...)
+		last = i + 1
+	}
+	return append(buf, s[last:]...)
+}
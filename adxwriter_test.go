@@ -0,0 +1,291 @@
+package adif
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+func TestADXWriter_Write(t *testing.T) {
+	hdr := Record{
+		adifield.ADIF_VER: "3.1.6",
+		adifield.USERDEF1: "EPC",
+		adifield.USERDEF2: "SWEATERSIZE,{S,M,L}",
+		adifield.USERDEF3: "SHOESIZE,{5:20}",
+		"USERDEFX":        "not a user-defined field",
+	}
+	qso := Record{
+		adifield.CALL:                     "K9CTS",
+		adifield.BAND:                     "20M",
+		adifield.New("EPC"):               "32123",
+		adifield.New("APP_K9CTS_MY_NOTE"): `a<b & "c"`,
+		adifield.New("APP_NOSEPARATOR"):   "x",
+		adifield.NOTES:                    "line 1\r\nline 2",
+		adifield.QTH:                      "'a'\t>",
+		adifield.COMMENT:                  "",
+	}
+
+	var sb strings.Builder
+	w := NewADXWriter(&sb)
+	if err := w.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(qso); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(NewRecord()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := adxXMLDeclaration +
+		"<ADX>\n" +
+		`<HEADER><ADIF_VER>3.1.6</ADIF_VER><USERDEF FIELDID="1">EPC</USERDEF><USERDEF FIELDID="2" ENUM="{S,M,L}">SWEATERSIZE</USERDEF><USERDEF FIELDID="3" RANGE="{5:20}">SHOESIZE</USERDEF><USERDEFX>not a user-defined field</USERDEFX></HEADER>` + "\n" +
+		"<RECORDS>\n" +
+		`<RECORD><BAND>20M</BAND><CALL>K9CTS</CALL><APP PROGRAMID="K9CTS" FIELDNAME="MY_NOTE">a&lt;b &amp; &quot;c&quot;</APP><APP_NOSEPARATOR>x</APP_NOSEPARATOR><USERDEF FIELDNAME="EPC">32123</USERDEF><NOTES>line 1&#xD;&#xA;line 2</NOTES><QTH>&apos;a&apos;&#x9;&gt;</QTH></RECORD>` + "\n" +
+		"</RECORDS>\n" +
+		"</ADX>\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestADXWriter_RoundTrip(t *testing.T) {
+	f, err := testFileFS.Open("testdata/ADIF_316_test_QSOs_2025_08_27.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	src := NewDocument()
+	if _, err := src.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	w := NewADXWriter(&sb).SetWriteMode(WriteModeFast)
+	if src.Header != nil {
+		if err := w.WriteHeader(src.Header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range src.Records {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewADXScanner(strings.NewReader(sb.String()))
+	i := 0
+	for s.Scan() {
+		if s.IsHeader() {
+			assertRecordEqual(t, s.Record(), src.Header)
+			continue
+		}
+		assertRecordEqual(t, s.Record(), src.Records[i])
+		i++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if i != len(src.Records) {
+		t.Errorf("record count: got %d, want %d", i, len(src.Records))
+	}
+}
+
+func TestADXWriter_EmptyDocument(t *testing.T) {
+	var sb strings.Builder
+	w := NewADXWriter(&sb)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// A second Flush must not write the closing tags again.
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := adxXMLDeclaration + "<ADX>\n<RECORDS>\n</RECORDS>\n</ADX>\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestADXWriter_WriteHeader_Twice(t *testing.T) {
+	var sb strings.Builder
+	w := NewADXWriter(&sb)
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test"}); err != ErrHeaderAlreadyWritten {
+		t.Fatalf("got %v, want ErrHeaderAlreadyWritten", err)
+	}
+
+	w = NewADXWriter(&sb)
+	if err := w.Write(Record{adifield.CALL: "K9CTS"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test"}); err != ErrHeaderAlreadyWritten {
+		t.Fatalf("got %v, want ErrHeaderAlreadyWritten", err)
+	}
+}
+
+func TestADXWriter_WriteErrors(t *testing.T) {
+	r := Record{adifield.CALL: "K9CTS"}
+
+	if err := NewADXWriter(&mockAlwaysErrorWriter{}).WriteHeader(r); err != errMockWrite {
+		t.Errorf("WriteHeader: got %v, want errMockWrite", err)
+	}
+	if err := NewADXWriter(&mockAlwaysErrorWriter{}).Write(r); err != errMockWrite {
+		t.Errorf("Write: got %v, want errMockWrite", err)
+	}
+	if err := NewADXWriter(&mockAlwaysErrorWriter{}).Flush(); err != errMockWrite {
+		t.Errorf("Flush: got %v, want errMockWrite", err)
+	}
+}
+
+func TestADXWriter_InvalidFieldName(t *testing.T) {
+	for _, mode := range []WriteMode{WriteModePretty, WriteModeFast} {
+		var sb strings.Builder
+		w := NewADXWriter(&sb).SetWriteMode(mode)
+		if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test", "1ST": "x"}); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("WriteHeader: got %v, want ErrInvalidValue", err)
+		}
+		if err := w.Write(Record{adifield.CALL: "K9CTS", "MY FIELD": "x"}); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Write: got %v, want ErrInvalidValue", err)
+		}
+		if sb.Len() != 0 {
+			t.Errorf("expected nothing written, got %q", sb.String())
+		}
+
+		// The rejected records leave the document intact.
+		if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(Record{adifield.CALL: "K9CTS"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		s := NewADXScanner(strings.NewReader(sb.String()))
+		var calls []string
+		for r := range s.QSOs() {
+			calls = append(calls, r[adifield.CALL])
+		}
+		if s.Err() != nil || len(calls) != 1 || calls[0] != "K9CTS" {
+			t.Errorf("got %v, %v from %q", calls, s.Err(), sb.String())
+		}
+	}
+}
+
+func TestIsXMLName(t *testing.T) {
+	tests := map[string]bool{
+		"CALL": true, "_X": true, "A-1.B": true, "ÄB": true,
+		"": false, "1ST": false, "-A": false, "A B": false, "A<B": false, "A&B": false, "A:B": false,
+	}
+	for s, want := range tests {
+		if got := isXMLName(s); got != want {
+			t.Errorf("isXMLName(%q): got %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestADXWriter_WriteAfterFlush(t *testing.T) {
+	var sb strings.Builder
+	w := NewADXWriter(&sb)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test"}); err != ErrWriteAfterFlush {
+		t.Errorf("WriteHeader: got %v, want ErrWriteAfterFlush", err)
+	}
+	if err := w.Write(Record{adifield.CALL: "K9CTS"}); err != ErrWriteAfterFlush {
+		t.Errorf("Write: got %v, want ErrWriteAfterFlush", err)
+	}
+	if want := adxXMLDeclaration + "<ADX>\n<RECORDS>\n</RECORDS>\n</ADX>\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestADXWriter_Flush_Buffered(t *testing.T) {
+	var sb strings.Builder
+	bw := bufio.NewWriter(&sb)
+	w := NewADXWriter(bw)
+	if err := w.Write(Record{adifield.CALL: "K9CTS"}); err != nil {
+		t.Fatal(err)
+	}
+	if sb.Len() != 0 {
+		t.Error("expected data to be buffered before Flush")
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(sb.String(), "</ADX>\n") {
+		t.Errorf("expected complete document after Flush, got %q", sb.String())
+	}
+}
+
+func TestADXWriter_DataTypeIndicators(t *testing.T) {
+	hdr := Record{
+		adifield.USERDEF1: "EPC",
+		adifield.USERDEF2: "SHOESIZE,{5:20}",
+	}
+	qso := Record{
+		adifield.CALL:                 "K9CTS",
+		adifield.New("EPC"):           "1",
+		adifield.New("APP_K9CTS_WPM"): "25",
+	}
+
+	var sb strings.Builder
+	w := NewADXWriter(&sb).
+		SetAutoDataTypeIndicators(true).
+		SetDataTypeIndicators(map[adifield.Field]aditype.DataTypeIndicator{
+			adifield.USERDEF1:             aditype.DATATYPEINDICATOR_NUMBER,
+			adifield.New("APP_K9CTS_WPM"): aditype.DATATYPEINDICATOR_NUMBER,
+		})
+	if err := w.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(qso); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := adxXMLDeclaration +
+		"<ADX>\n" +
+		`<HEADER><USERDEF FIELDID="1" TYPE="N">EPC</USERDEF><USERDEF FIELDID="2" TYPE="N" RANGE="{5:20}">SHOESIZE</USERDEF></HEADER>` + "\n" +
+		"<RECORDS>\n" +
+		`<RECORD><CALL>K9CTS</CALL><APP PROGRAMID="K9CTS" FIELDNAME="WPM" TYPE="N">25</APP><USERDEF FIELDNAME="EPC">1</USERDEF></RECORD>` + "\n" +
+		"</RECORDS>\n" +
+		"</ADX>\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestADXWriter_WriteContext(t *testing.T) {
+	var sb strings.Builder
+	w := NewADXWriter(&sb)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := w.WriteContext(ctx, Record{adifield.CALL: "K9CTS"}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := w.WriteContext(ctx, Record{adifield.CALL: "W9PVA"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if strings.Contains(sb.String(), "W9PVA") || !strings.Contains(sb.String(), "K9CTS") {
+		t.Errorf("got %q", sb.String())
+	}
+}
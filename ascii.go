@@ -0,0 +1,124 @@
+package adif
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// ASCIIPolicy controls how a Writer handles non-ASCII characters in fields that the ADIF specification
+// restricts to ASCII in ADI files, which is every field except those of the Intl data types, such as NAME_INTL.
+type ASCIIPolicy int
+
+const (
+	// ASCIIPolicyAllow writes values unchanged. It is the default.
+	ASCIIPolicyAllow ASCIIPolicy = iota
+
+	// ASCIIPolicyTransliterate replaces non-ASCII characters with ASCII approximations, e.g. "Müller" becomes "Muller".
+	// Characters without an approximation are replaced with '?'.
+	ASCIIPolicyTransliterate
+
+	// ASCIIPolicyReject fails the write with ErrNonASCII.
+	ASCIIPolicyReject
+)
+
+// latinTransliterations holds the ASCII approximations of U+00C0 through U+017F,
+// the letters of the Latin-1 Supplement and Latin Extended-A blocks.
+var latinTransliterations = strings.Fields(`
+	A A A A A A AE C E E E E I I I I D N O O O O O x O U U U U Y TH ss
+	a a a a a a ae c e e e e i i i i d n o o o o o / o u u u u y th y
+	A a A a A a C c C c C c C c D d D d E e E e E e E e E e G g G g G g G g
+	H h H h I i I i I i I i I i IJ ij J j K k k L l L l L l L l L l N n N n N n 'n N n
+	O o O o O o OE oe R r R r R r S s S s S s S s T t T t T t
+	U u U u U u U u U u U u W w Y y Y Z z Z z Z z s`)
+
+// latinTransliterationsStart is the first character of latinTransliterations.
+const latinTransliterationsStart = 0xC0
+
+// punctuationTransliterations holds the ASCII approximations of common typographic characters.
+var punctuationTransliterations = map[rune]string{
+	' ': " ",   // no-break space
+	'«': `"`,   // left-pointing double angle quotation mark
+	'»': `"`,   // right-pointing double angle quotation mark
+	'‐': "-",   // hyphen
+	'–': "-",   // en dash
+	'—': "-",   // em dash
+	'‘': "'",   // left single quotation mark
+	'’': "'",   // right single quotation mark
+	'“': `"`,   // left double quotation mark
+	'”': `"`,   // right double quotation mark
+	'…': "...", // horizontal ellipsis
+}
+
+// isASCIIOnlyField reports whether the ADIF specification restricts values of field to ASCII in ADI files.
+// Fields that are not part of the specification, such as APP_ and user-defined fields, are ASCII-only.
+func isASCIIOnlyField(field adifield.Field) bool {
+	spec, ok := adifield.Lookup(field)
+	if !ok {
+		return true
+	}
+	switch spec.DataType {
+	case aditype.INTLCHARACTER, aditype.INTLSTRING, aditype.INTLMULTILINESTRING:
+		return false
+	}
+	return true
+}
+
+// isASCII reports whether s contains only ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// transliterate returns s with each non-ASCII character replaced by its ASCII approximation, or '?' when it has none.
+// Invalid UTF-8 bytes are replaced with '?'.
+func transliterate(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, c := range s {
+		switch {
+		case c < utf8.RuneSelf:
+			sb.WriteRune(c)
+		case c >= latinTransliterationsStart && int(c-latinTransliterationsStart) < len(latinTransliterations):
+			sb.WriteString(latinTransliterations[c-latinTransliterationsStart])
+		case punctuationTransliterations[c] != "":
+			sb.WriteString(punctuationTransliterations[c])
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
+
+// applyASCIIPolicy returns r with policy applied to its ASCII-only fields.
+// r is returned unchanged when no field needs transliteration; otherwise a copy is returned and r is not modified.
+func applyASCIIPolicy(r Record, policy ASCIIPolicy) (Record, error) {
+	if policy == ASCIIPolicyAllow {
+		return r, nil
+	}
+	var out Record
+	for field, value := range r {
+		if isASCII(value) || !isASCIIOnlyField(field) {
+			continue
+		}
+		if policy == ASCIIPolicyReject {
+			return nil, fmt.Errorf("%w: %s %q", ErrNonASCII, field, value)
+		}
+		if out == nil {
+			out = maps.Clone(r)
+		}
+		out[field] = transliterate(value)
+	}
+	if out == nil {
+		return r, nil
+	}
+	return out, nil
+}
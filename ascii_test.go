@@ -0,0 +1,62 @@
+package adif
+
+import (
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestLatinTransliterations(t *testing.T) {
+	// One approximation for each character from U+00C0 through U+017F.
+	if got, want := len(latinTransliterations), 0x180-latinTransliterationsStart; got != want {
+		t.Fatalf("got %d approximations, want %d", got, want)
+	}
+	for _, tt := range []struct {
+		c    rune
+		want string
+	}{
+		{'À', "A"}, {'ß', "ss"}, {'÷', "/"}, {'ÿ', "y"}, {'Ā', "A"}, {'Ĳ', "IJ"}, {'ŉ', "'n"}, {'Œ', "OE"}, {'ž', "z"}, {'ſ', "s"},
+	} {
+		if got := latinTransliterations[tt.c-latinTransliterationsStart]; got != tt.want {
+			t.Errorf("%c: got %q, want %q", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"K9CTS", "K9CTS"},
+		{"José Müller", "Jose Muller"},
+		{"Łódź", "Lodz"},
+		{"Ærøskøbing", "AEroskobing"},
+		{"“QRP” – 5 W…", `"QRP" - 5 W...`},
+		{"東京", "??"},
+		{"bad\xffbyte", "bad?byte"},
+		{"¿", "?"},
+	}
+	for _, tt := range tests {
+		if got := transliterate(tt.in); got != tt.want {
+			t.Errorf("transliterate(%q): got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsASCIIOnlyField(t *testing.T) {
+	tests := []struct {
+		field adifield.Field
+		want  bool
+	}{
+		{adifield.NAME, true},
+		{adifield.NOTES, true},
+		{adifield.NAME_INTL, false},
+		{adifield.NOTES_INTL, false},
+		{adifield.New("APP_K9CTS_NOTE"), true},
+	}
+	for _, tt := range tests {
+		if got := isASCIIOnlyField(tt.field); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
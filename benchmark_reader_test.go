@@ -1,8 +1,11 @@
 package adif
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
 )
 
 func BenchmarkADIRead(b *testing.B) {
@@ -20,3 +23,54 @@ func BenchmarkADIRead(b *testing.B) {
 	}
 	_ = len(records)
 }
+
+func BenchmarkADIReadParallel(b *testing.B) {
+	// Repeat the QSO records of benchmarkFile to build an input spanning many chunks.
+	eoh := strings.Index(benchmarkFile, "<EOH>") + len("<EOH>")
+	data := []byte(benchmarkFile[:eoh] + strings.Repeat(benchmarkFile[eoh:], 64))
+
+	b.Run("Sequential", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			d := NewDocument()
+			if _, err := d.ReadFrom(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Parallel", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			if _, err := ParseParallel(data, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkADIVisit(b *testing.B) {
+	var v countVisitor
+	for b.Loop() {
+		v = countVisitor{}
+		if err := NewScanner(strings.NewReader(benchmarkFile)).Visit(&v); err != nil {
+			b.Fatal(err)
+		}
+	}
+	_ = v.records
+}
+
+func BenchmarkADIReadProjection(b *testing.B) {
+	var records []Record
+	for b.Loop() {
+		records = make([]Record, 0, 10000)
+		s := NewScanner(strings.NewReader(benchmarkFile)).
+			SetProjection(adifield.CALL, adifield.QSO_DATE, adifield.BAND, adifield.MODE, adifield.QSL_RCVD)
+		for s.Scan() {
+			records = append(records, s.Record())
+		}
+		if err := s.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	_ = len(records)
+}
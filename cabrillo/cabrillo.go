@@ -0,0 +1,228 @@
+// Package cabrillo converts between ADIF records and Cabrillo 3.0 contest logs.
+//
+// Use Writer to export a header Record and QSO Records as a Cabrillo log for submission to a contest sponsor,
+// and Scanner or ReadDocument to import a Cabrillo log as Records.
+// The exchange that follows each callsign in a QSO: line is described by a Template;
+// templates for common contests are chosen from the CONTEST_ID field of the header.
+package cabrillo
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/farmergreg/adif/v5/internal/digits"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/contest"
+	"github.com/farmergreg/spec/v6/enum/mode"
+)
+
+var (
+	// ErrMalformedCabrillo is returned when a Cabrillo log does not conform to the Cabrillo specification or the exchange Template.
+	ErrMalformedCabrillo = errors.New("malformed Cabrillo")
+
+	// ErrNoHeader is returned by Writer when a QSO record is written, or the log closed, before the header.
+	ErrNoHeader = errors.New("Cabrillo header not written")
+)
+
+// Template describes the exchange of a contest as it appears in QSO: lines,
+// after the sent callsign (Sent) and after the received callsign (Received).
+//
+// Each field stands for one whitespace-separated token. A field whose value spans several tokens,
+// such as an STX_STRING of "A 72 IL" in Sweepstakes, is listed once for each token, in consecutive entries.
+// RST_SENT and RST_RCVD default to 59 for phone modes and 599 for others when a record has no report.
+type Template struct {
+	Sent     []adifield.Field
+	Received []adifield.Field
+}
+
+// defaultTemplate is the exchange of contests without a template of their own: a signal report and one more token.
+var defaultTemplate = Template{
+	Sent:     []adifield.Field{adifield.RST_SENT, adifield.STX_STRING},
+	Received: []adifield.Field{adifield.RST_RCVD, adifield.SRX_STRING},
+}
+
+var (
+	serialTemplate = Template{
+		Sent:     []adifield.Field{adifield.RST_SENT, adifield.STX},
+		Received: []adifield.Field{adifield.RST_RCVD, adifield.SRX},
+	}
+	cqZoneTemplate = Template{
+		Sent:     []adifield.Field{adifield.RST_SENT, adifield.MY_CQ_ZONE},
+		Received: []adifield.Field{adifield.RST_RCVD, adifield.CQZ},
+	}
+	sweepstakesTemplate = Template{
+		Sent:     []adifield.Field{adifield.STX, adifield.STX_STRING, adifield.STX_STRING, adifield.STX_STRING},
+		Received: []adifield.Field{adifield.SRX, adifield.PRECEDENCE, adifield.CHECK, adifield.ARRL_SECT},
+	}
+	naqpTemplate = Template{
+		Sent:     []adifield.Field{adifield.STX_STRING, adifield.STX_STRING},
+		Received: []adifield.Field{adifield.NAME, adifield.SRX_STRING},
+	}
+)
+
+// templates holds the exchanges of contests that differ from defaultTemplate.
+var templates = map[contest.Contest]Template{
+	contest.CONTEST_ARRL_FIELD_DAY: {
+		Sent:     []adifield.Field{adifield.STX_STRING, adifield.STX_STRING},
+		Received: []adifield.Field{adifield.CLASS, adifield.ARRL_SECT},
+	},
+	contest.CONTEST_ARRL_SS_CW:  sweepstakesTemplate,
+	contest.CONTEST_ARRL_SS_SSB: sweepstakesTemplate,
+	contest.CONTEST_CQ_WPX_CW:   serialTemplate,
+	contest.CONTEST_CQ_WPX_RTTY: serialTemplate,
+	contest.CONTEST_CQ_WPX_SSB:  serialTemplate,
+	contest.CONTEST_CQ_WW_CW:    cqZoneTemplate,
+	contest.CONTEST_CQ_WW_RTTY: {
+		Sent:     []adifield.Field{adifield.RST_SENT, adifield.MY_CQ_ZONE, adifield.STX_STRING},
+		Received: []adifield.Field{adifield.RST_RCVD, adifield.CQZ, adifield.SRX_STRING},
+	},
+	contest.CONTEST_CQ_WW_SSB: cqZoneTemplate,
+	contest.CONTEST_NAQP_CW:   naqpTemplate,
+	contest.CONTEST_NAQP_RTTY: naqpTemplate,
+	contest.CONTEST_NAQP_SSB:  naqpTemplate,
+	contest.CONTEST_WW_DIGI: {
+		Sent:     []adifield.Field{adifield.MY_GRIDSQUARE},
+		Received: []adifield.Field{adifield.GRIDSQUARE},
+	},
+}
+
+// DefaultTemplate returns the exchange template used for contest c when none is set with SetTemplate.
+// Contests without a template of their own use a signal report followed by STX_STRING or SRX_STRING;
+// Scanner reads other exchanges of such contests as described there.
+func DefaultTemplate(c contest.Contest) Template {
+	if t, ok := templates[contest.New(string(c))]; ok {
+		return t
+	}
+	return defaultTemplate
+}
+
+// hasTemplate reports whether contest c has a template of its own.
+func hasTemplate(c contest.Contest) bool {
+	_, ok := templates[contest.New(string(c))]
+	return ok
+}
+
+// splitTemplate returns the template used by Scanner for a QSO: line of n tokens in a contest without a template of its own
+// whose line does not fit defaultTemplate: the sent and received exchanges are assumed to have the same number of tokens,
+// which are joined into STX_STRING and SRX_STRING, and an odd token out at the end is the transmitter ID.
+func splitTemplate(n int) Template {
+	k := max((n-6)/2, 0)
+	return Template{
+		Sent:     slices.Repeat([]adifield.Field{adifield.STX_STRING}, k),
+		Received: slices.Repeat([]adifield.Field{adifield.SRX_STRING}, k),
+	}
+}
+
+// appFieldPrefix prefixes the ADIF fields that hold Cabrillo header tags without an ADIF equivalent,
+// e.g. APP_CABRILLO_CATEGORY_OPERATOR for CATEGORY-OPERATOR.
+const appFieldPrefix = "APP_CABRILLO_"
+
+// transmitterID holds the optional transmitter ID at the end of a QSO: line in multi-transmitter logs.
+const transmitterID = adifield.Field(appFieldPrefix + "TRANSMITTER_ID")
+
+// headerFields maps Cabrillo header tags to the ADIF fields that hold them, in the order they are written.
+var headerFields = []struct {
+	tag   string
+	field adifield.Field
+}{
+	{"CONTEST", adifield.CONTEST_ID},
+	{"CALLSIGN", adifield.STATION_CALLSIGN},
+	{"OPERATORS", adifield.OPERATOR},
+	{"NAME", adifield.MY_NAME},
+	{"GRID-LOCATOR", adifield.MY_GRIDSQUARE},
+	{"ADDRESS-CITY", adifield.MY_CITY},
+	{"ADDRESS-STATE-PROVINCE", adifield.MY_STATE},
+	{"ADDRESS-POSTALCODE", adifield.MY_POSTAL_CODE},
+	{"ADDRESS-COUNTRY", adifield.MY_COUNTRY},
+	{"CREATED-BY", adifield.PROGRAMID},
+}
+
+// tagField returns the ADIF field that holds the Cabrillo header tag.
+func tagField(tag string) adifield.Field {
+	for _, h := range headerFields {
+		if h.tag == tag {
+			return h.field
+		}
+	}
+	return adifield.New(appFieldPrefix + strings.ReplaceAll(tag, "-", "_"))
+}
+
+// bandDesignators holds the frequency written for each band when a record has no FREQ below 30 MHz:
+// the lower band edge in kHz for HF, and the band designator above.
+var bandDesignators = map[band.Band]string{
+	band.BAND_160M:   "1800",
+	band.BAND_80M:    "3500",
+	band.BAND_60M:    "5330",
+	band.BAND_40M:    "7000",
+	band.BAND_30M:    "10100",
+	band.BAND_20M:    "14000",
+	band.BAND_17M:    "18068",
+	band.BAND_15M:    "21000",
+	band.BAND_12M:    "24890",
+	band.BAND_10M:    "28000",
+	band.BAND_6M:     "50",
+	band.BAND_4M:     "70",
+	band.BAND_2M:     "144",
+	band.BAND_1_25M:  "222",
+	band.BAND_70CM:   "432",
+	band.BAND_33CM:   "902",
+	band.BAND_23CM:   "1.2G",
+	band.BAND_13CM:   "2.3G",
+	band.BAND_9CM:    "3.4G",
+	band.BAND_6CM:    "5.7G",
+	band.BAND_3CM:    "10G",
+	band.BAND_1_25CM: "24G",
+	band.BAND_6MM:    "47G",
+	band.BAND_4MM:    "75G",
+	band.BAND_2_5MM:  "122G",
+	band.BAND_2MM:    "134G",
+	band.BAND_1MM:    "241G",
+}
+
+// designatorBands maps the VHF and higher band designators of bandDesignators back to their bands.
+// The HF entries are frequencies in kHz, which Scanner reads as FREQ instead.
+var designatorBands = map[string]band.Band{}
+
+func init() {
+	for b, designator := range bandDesignators {
+		if !isKHz(designator) {
+			designatorBands[designator] = b
+		}
+	}
+}
+
+// isKHz reports whether a QSO: line frequency is in kHz rather than a band designator such as 50 or 1.2G.
+// Every HF frequency in kHz has at least four digits.
+func isKHz(freq string) bool {
+	return len(freq) >= 4 && digits.Only(freq)
+}
+
+// modeCode returns the Cabrillo mode code for the ADIF mode m.
+func modeCode(m mode.Mode) string {
+	switch m {
+	case mode.CW:
+		return "CW"
+	case mode.SSB, mode.AM, mode.DIGITALVOICE:
+		return "PH"
+	case mode.FM:
+		return "FM"
+	case mode.RTTY:
+		return "RY"
+	}
+	return "DG"
+}
+
+// codeModes maps Cabrillo mode codes to ADIF modes. DG has no single ADIF equivalent.
+var codeModes = map[string]mode.Mode{
+	"CW": mode.CW,
+	"PH": mode.SSB,
+	"FM": mode.FM,
+	"RY": mode.RTTY,
+}
+
+// isPhone reports whether the Cabrillo mode code is a voice mode, whose signal reports have two digits.
+func isPhone(code string) bool {
+	return code == "PH" || code == "FM"
+}
@@ -0,0 +1,80 @@
+package cabrillo
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/contest"
+	"github.com/farmergreg/spec/v6/enum/mode"
+)
+
+func TestDefaultTemplate(t *testing.T) {
+	tests := []struct {
+		contest contest.Contest
+		want    Template
+	}{
+		{contest.CONTEST_CQ_WPX_CW, serialTemplate},
+		{"cq-ww-ssb", cqZoneTemplate},
+		{contest.CONTEST_ARRL_SS_SSB, sweepstakesTemplate},
+		{contest.CONTEST_IARU_HF, defaultTemplate},
+		{"", defaultTemplate},
+	}
+	for _, tt := range tests {
+		got := DefaultTemplate(tt.contest)
+		if !slices.Equal(got.Sent, tt.want.Sent) || !slices.Equal(got.Received, tt.want.Received) {
+			t.Errorf("%s: got %v, want %v", tt.contest, got, tt.want)
+		}
+	}
+	for c := range templates {
+		if _, ok := contest.Lookup(c); !ok {
+			t.Errorf("template for unknown contest %s", c)
+		}
+	}
+}
+
+func TestTagField(t *testing.T) {
+	tests := map[string]adifield.Field{
+		"CALLSIGN":          adifield.STATION_CALLSIGN,
+		"CREATED-BY":        adifield.PROGRAMID,
+		"CATEGORY-OPERATOR": "APP_CABRILLO_CATEGORY_OPERATOR",
+		"SOAPBOX":           "APP_CABRILLO_SOAPBOX",
+	}
+	for tag, want := range tests {
+		if got := tagField(tag); got != want {
+			t.Errorf("%s: got %s, want %s", tag, got, want)
+		}
+	}
+}
+
+func TestModeCode(t *testing.T) {
+	tests := map[mode.Mode]string{
+		mode.CW:           "CW",
+		mode.SSB:          "PH",
+		mode.AM:           "PH",
+		mode.DIGITALVOICE: "PH",
+		mode.FM:           "FM",
+		mode.RTTY:         "RY",
+		mode.FT8:          "DG",
+	}
+	for m, want := range tests {
+		if got := modeCode(m); got != want {
+			t.Errorf("%s: got %s, want %s", m, got, want)
+		}
+	}
+}
+
+func TestBandDesignators(t *testing.T) {
+	for b, designator := range bandDesignators {
+		if _, ok := band.Lookup(b); !ok {
+			t.Errorf("unknown band %s", b)
+		}
+		if got, ok := designatorBands[designator]; ok != !isKHz(designator) || ok && got != b {
+			t.Errorf("%s: designatorBands[%s] = %s, %v", b, designator, got, ok)
+		}
+	}
+	if len(designatorBands) != 17 {
+		t.Errorf("got %d band designators, want 17", len(designatorBands))
+	}
+}
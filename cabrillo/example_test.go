@@ -0,0 +1,62 @@
+package cabrillo_test
+
+import (
+	"fmt"
+	"strings"
+
+	adif "github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/adif/v5/cabrillo"
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// ExampleWriter demonstrates exporting ADIF records as a Cabrillo log.
+func ExampleWriter() {
+	var sb strings.Builder
+	w := cabrillo.NewWriter(&sb)
+	w.WriteHeader(adif.Record{
+		adifield.CONTEST_ID:              "CQ-WW-CW",
+		adifield.STATION_CALLSIGN:        "K9CTS",
+		"APP_CABRILLO_CATEGORY_OPERATOR": "SINGLE-OP",
+	})
+	w.Write(adif.Record{
+		adifield.FREQ:       "14.025",
+		adifield.MODE:       "CW",
+		adifield.QSO_DATE:   "20241123",
+		adifield.TIME_ON:    "000312",
+		adifield.CALL:       "DL1ABC",
+		adifield.MY_CQ_ZONE: "4",
+		adifield.CQZ:        "14",
+	})
+	w.Close()
+
+	// Cabrillo lines end with CR LF.
+	fmt.Print(strings.ReplaceAll(sb.String(), "\r\n", "\n"))
+
+	// Output:
+	// START-OF-LOG: 3.0
+	// CONTEST: CQ-WW-CW
+	// CALLSIGN: K9CTS
+	// CATEGORY-OPERATOR: SINGLE-OP
+	// QSO: 14025 CW 2024-11-23 0003 K9CTS 599 4 DL1ABC 599 14
+	// END-OF-LOG:
+}
+
+// ExampleReadDocument demonstrates importing a Cabrillo log as ADIF records.
+func ExampleReadDocument() {
+	log := `START-OF-LOG: 3.0
+CONTEST: NAQP-CW
+CALLSIGN: K9CTS
+QSO: 7030 CW 2025-01-11 1800 K9CTS GREG IL W9PVA BOB WI
+END-OF-LOG:
+`
+	d, err := cabrillo.ReadDocument(strings.NewReader(log))
+	if err != nil {
+		panic(err)
+	}
+	for _, r := range d.Records {
+		fmt.Println(r[adifield.CALL], r[adifield.BAND], r[adifield.NAME], r[adifield.SRX_STRING], r[adifield.STX_STRING])
+	}
+
+	// Output:
+	// W9PVA 40M BOB WI GREG IL
+}
@@ -0,0 +1,307 @@
+package cabrillo
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/adif/v5/internal/digits"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/contest"
+)
+
+// Scanner reads a Cabrillo log as ADIF records: first the header, then one record for each QSO: line.
+// Obtain one with NewScanner and call Scan until it returns false, then check Err.
+//
+// Header tags become the fields that Writer writes them from, so CALLSIGN is read into STATION_CALLSIGN
+// and CATEGORY-OPERATOR into APP_CABRILLO_CATEGORY_OPERATOR; a repeated tag such as SOAPBOX is joined with CR LF.
+// QSO records hold FREQ for HF frequencies, BAND, MODE (except for DG, which names no single mode),
+// QSO_DATE, TIME_ON, STATION_CALLSIGN, CALL, the exchange fields of the template, the CONTEST_ID of the header,
+// and APP_CABRILLO_TRANSMITTER_ID when the line has a transmitter ID.
+// A frequency equal to the lower band edge that Writer writes for a record without FREQ, such as 7000, sets BAND only.
+// X-QSO: lines are skipped, and reading stops at END-OF-LOG:. Header tags that follow a QSO: line are malformed.
+//
+// Without SetTemplate, the template of a contest that has one is used, as chosen by DefaultTemplate.
+// For other contests, QSO: lines that fit the default template of a signal report and one token are read with it;
+// otherwise the sent and received exchanges are assumed to have the same number of tokens, and are read
+// into STX_STRING and SRX_STRING, with an odd token out at the end read as the transmitter ID.
+type Scanner struct {
+	r           *bufio.Reader
+	template    Template
+	hasTemplate bool
+	split       bool // whether QSO: lines that do not fit template are read with splitTemplate
+
+	header   adif.Record
+	record   adif.Record
+	isHeader bool
+	pending  string // the line that ended the header, read before the header was returned
+	done     bool
+	err      error
+
+	offset     int64 // offset of the next line
+	line       int   // one-based number of the current line
+	lineOffset int64 // offset of the current line
+	records    int
+}
+
+// NewScanner returns a Scanner that reads a Cabrillo log from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: bufio.NewReader(r)}
+}
+
+// SetTemplate sets the exchange template used to read QSO: lines and returns the Scanner for chaining.
+// Without it, the template is chosen by DefaultTemplate from the CONTEST tag of the header.
+func (s *Scanner) SetTemplate(t Template) *Scanner {
+	s.template, s.hasTemplate = t, true
+	return s
+}
+
+// Scan advances to the next record, which is available through Record.
+// It returns false at END-OF-LOG:, at the end of the input, or on error.
+func (s *Scanner) Scan() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	if s.header == nil {
+		s.err = s.readHeader()
+		s.record, s.isHeader = s.header, s.err == nil
+		return s.err == nil
+	}
+	s.isHeader = false
+	for {
+		line := s.pending
+		s.pending = ""
+		if line == "" {
+			var err error
+			if line, err = s.readLine(); err != nil {
+				if err != io.EOF {
+					s.err = err
+				}
+				return false
+			}
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		tag, value, _ := strings.Cut(line, ":")
+		switch strings.ToUpper(strings.TrimSpace(tag)) {
+		case "X-QSO":
+			continue
+		case "END-OF-LOG":
+			s.done = true
+			return false
+		case "QSO":
+			s.records++
+			s.record, s.err = s.parseQSO(line, len(tag)+1, value)
+			return s.err == nil
+		}
+		// Header tags may not follow QSO lines.
+		s.err = s.newParseError(0, "")
+		return false
+	}
+}
+
+// Record returns the most recent record read by Scan.
+func (s *Scanner) Record() adif.Record {
+	return s.record
+}
+
+// IsHeader reports whether the most recent record read by Scan is the header.
+func (s *Scanner) IsHeader() bool {
+	return s.isHeader
+}
+
+// Err returns the first error encountered by Scan, or nil at the end of the log.
+// Malformed input is reported as an *adif.ParseError wrapping ErrMalformedCabrillo,
+// whose Record counts the header as record 0.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// ReadDocument reads the Cabrillo log in r into a Document whose Header holds the Cabrillo header tags.
+func ReadDocument(r io.Reader) (*adif.Document, error) {
+	d := adif.NewDocument()
+	s := NewScanner(r)
+	for s.Scan() {
+		if s.IsHeader() {
+			d.Header = s.Record()
+			continue
+		}
+		d.Records = append(d.Records, s.Record())
+	}
+	return d, s.Err()
+}
+
+// readHeader reads START-OF-LOG: and the header tags that follow, up to the first QSO line or END-OF-LOG:.
+func (s *Scanner) readHeader() error {
+	s.header = adif.NewRecord()
+	started := false
+	for {
+		line, err := s.readLine()
+		if err == io.EOF && started {
+			return nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				return s.newParseError(0, "")
+			}
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		tag, value, found := strings.Cut(line, ":")
+		tag = strings.ToUpper(strings.TrimSpace(tag))
+		if !started {
+			if tag != "START-OF-LOG" {
+				return s.newParseError(0, "")
+			}
+			started = true
+			continue
+		}
+		if !found || tag == "" {
+			return s.newParseError(0, "")
+		}
+		if tag == "QSO" || tag == "X-QSO" || tag == "END-OF-LOG" {
+			s.pending = line
+			break
+		}
+		field := tagField(tag)
+		value = strings.TrimSpace(value)
+		if field == adifield.CONTEST_ID {
+			value = strings.ToUpper(value)
+		}
+		if prev := s.header[field]; prev != "" {
+			value = prev + "\r\n" + value
+		}
+		s.header[field] = value
+	}
+	if !s.hasTemplate {
+		c := contest.New(s.header[adifield.CONTEST_ID])
+		s.template, s.split = DefaultTemplate(c), !hasTemplate(c)
+	}
+	return nil
+}
+
+// parseQSO parses the tokens of a QSO: line, which begin at byte start of line.
+func (s *Scanner) parseQSO(line string, start int, value string) (adif.Record, error) {
+	tokens, columns := splitTokens(value, start)
+	t := s.template
+	if s.split && !fits(t, len(tokens)) {
+		t = splitTemplate(len(tokens))
+	}
+	if !fits(t, len(tokens)) {
+		return nil, s.newParseError(0, "")
+	}
+	sent, received := len(t.Sent), len(t.Received)
+
+	r := adif.NewRecord()
+	freq := tokens[0]
+	if b, ok := designatorBands[freq]; ok {
+		r.SetBand(b)
+	} else if khz, err := strconv.Atoi(freq); err == nil && digits.Only(freq) {
+		mhz := float64(khz) / 1000
+		spec, ok := band.FindBandByMHz(mhz)
+		if ok {
+			r.SetBand(spec.Key)
+		}
+		if !ok || bandDesignators[spec.Key] != freq {
+			r.SetFloat(adifield.FREQ, mhz)
+		}
+	} else {
+		return nil, s.newParseError(columns[0], adifield.FREQ)
+	}
+
+	code := strings.ToUpper(tokens[1])
+	if m, ok := codeModes[code]; ok {
+		r.SetMode(m)
+	} else if code != "DG" {
+		return nil, s.newParseError(columns[1], adifield.MODE)
+	}
+
+	date, err := time.Parse("2006-01-02", tokens[2])
+	if err != nil {
+		return nil, s.newParseError(columns[2], adifield.QSO_DATE)
+	}
+	r[adifield.QSO_DATE] = date.Format("20060102")
+	if _, err := time.Parse("1504", tokens[3]); err != nil {
+		return nil, s.newParseError(columns[3], adifield.TIME_ON)
+	}
+	r[adifield.TIME_ON] = tokens[3]
+
+	r[adifield.STATION_CALLSIGN] = tokens[4]
+	setExchange(r, t.Sent, tokens[5:5+sent])
+	r[adifield.CALL] = tokens[5+sent]
+	setExchange(r, t.Received, tokens[6+sent:6+sent+received])
+	if len(tokens) > 6+sent+received {
+		r[transmitterID] = tokens[len(tokens)-1]
+	}
+	if id := s.header[adifield.CONTEST_ID]; id != "" {
+		r[adifield.CONTEST_ID] = id
+	}
+	return r, nil
+}
+
+// fits reports whether a QSO: line of n tokens holds the exchange of t, with or without a transmitter ID.
+func fits(t Template, n int) bool {
+	n -= 6 + len(t.Sent) + len(t.Received)
+	return n == 0 || n == 1
+}
+
+// setExchange stores the exchange tokens in their fields, joining the tokens of a field listed more than once with a space.
+func setExchange(r adif.Record, fields []adifield.Field, tokens []string) {
+	for i, field := range fields {
+		if prev := r[field]; prev != "" {
+			r[field] = prev + " " + tokens[i]
+		} else {
+			r[field] = tokens[i]
+		}
+	}
+}
+
+// splitTokens splits value at whitespace, returning each token with its zero-based byte column within the line,
+// where value begins at byte start of the line.
+func splitTokens(value string, start int) (tokens []string, columns []int) {
+	for i := 0; i < len(value); {
+		if value[i] == ' ' || value[i] == '\t' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(value) && value[j] != ' ' && value[j] != '\t' {
+			j++
+		}
+		tokens, columns = append(tokens, value[i:j]), append(columns, start+i)
+		i = j
+	}
+	return tokens, columns
+}
+
+// readLine returns the next line without its line ending, or io.EOF at the end of the input.
+func (s *Scanner) readLine() (string, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	s.lineOffset = s.offset
+	s.offset += int64(len(line))
+	s.line++
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// newParseError returns a *adif.ParseError for the current line, at zero-based byte column of the line.
+// Before the first line is read, it is positioned at the start of the input.
+func (s *Scanner) newParseError(column int, field adifield.Field) *adif.ParseError {
+	return &adif.ParseError{
+		Offset: s.lineOffset + int64(column),
+		Line:   max(s.line, 1),
+		Column: column + 1,
+		Record: s.records,
+		Field:  field,
+		Err:    ErrMalformedCabrillo,
+	}
+}
@@ -0,0 +1,237 @@
+package cabrillo
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// errRead is returned by failReader.
+var errRead = errors.New("read failed")
+
+// failReader returns data, then errRead.
+type failReader struct{ data string }
+
+func (r *failReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errRead
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestScanner(t *testing.T) {
+	d, err := ReadDocument(strings.NewReader(testLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHeader := testHeader()
+	wantHeader[adifield.CONTEST_ID] = "CQ-WPX-CW"
+	delete(wantHeader, adifield.CALL)
+	if !maps.Equal(d.Header, wantHeader) {
+		t.Errorf("Header: got %v, want %v", d.Header, wantHeader)
+	}
+	want := []adif.Record{
+		{adifield.FREQ: "14.025", adifield.BAND: "20M", adifield.MODE: "CW", adifield.QSO_DATE: "20240525", adifield.TIME_ON: "0001",
+			adifield.STATION_CALLSIGN: "K9CTS", adifield.RST_SENT: "599", adifield.STX: "1",
+			adifield.CALL: "DL1ABC", adifield.RST_RCVD: "579", adifield.SRX: "17", adifield.CONTEST_ID: "CQ-WPX-CW"},
+		{adifield.BAND: "80M", adifield.MODE: "CW", adifield.QSO_DATE: "20240525", adifield.TIME_ON: "0102",
+			adifield.STATION_CALLSIGN: "K9CTS", adifield.RST_SENT: "599", adifield.STX: "2",
+			adifield.CALL: "JA1XYZ", adifield.RST_RCVD: "599", adifield.SRX: "305", adifield.CONTEST_ID: "CQ-WPX-CW",
+			"APP_CABRILLO_TRANSMITTER_ID": "1"},
+		{adifield.BAND: "2M", adifield.MODE: "SSB", adifield.QSO_DATE: "20240525", adifield.TIME_ON: "2359",
+			adifield.STATION_CALLSIGN: "W9PVA", adifield.RST_SENT: "59", adifield.STX: "3",
+			adifield.CALL: "N0CALL", adifield.RST_RCVD: "57", adifield.SRX: "4", adifield.CONTEST_ID: "CQ-WPX-CW"},
+	}
+	if len(d.Records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(d.Records), len(want))
+	}
+	for i := range want {
+		if !maps.Equal(d.Records[i], want[i]) {
+			t.Errorf("record %d: got %v, want %v", i, d.Records[i], want[i])
+		}
+	}
+}
+
+func TestScanner_Exchanges(t *testing.T) {
+	log := "\r\nSTART-OF-LOG: 3.0\r\n" +
+		"CONTEST: ARRL-SS-CW\r\n" +
+		"\r\n" +
+		"QSO: 7040 cw 2024-11-02 2100 K9CTS 12 A 72 IL W9PVA 34 B 99 WI\r\n" +
+		"\r\n" +
+		"X-QSO: 7040 CW 2024-11-02 2101 K9CTS 13 A 72 IL NOPE\n" +
+		"QSO: 50 DG 2024-11-02 2102 K9CTS 14 A 72 IL K1ABC 7 Q 01 CT\n" +
+		"END-OF-LOG:\r\n" +
+		"QSO: ignored after the end of the log\r\n"
+	s := NewScanner(strings.NewReader(log))
+	var records []adif.Record
+	for s.Scan() {
+		if !s.IsHeader() {
+			records = append(records, s.Record())
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	want := []adif.Record{
+		{adifield.FREQ: "7.04", adifield.BAND: "40M", adifield.MODE: "CW", adifield.QSO_DATE: "20241102", adifield.TIME_ON: "2100",
+			adifield.STATION_CALLSIGN: "K9CTS", adifield.STX: "12", adifield.STX_STRING: "A 72 IL",
+			adifield.CALL: "W9PVA", adifield.SRX: "34", adifield.PRECEDENCE: "B", adifield.CHECK: "99", adifield.ARRL_SECT: "WI",
+			adifield.CONTEST_ID: "ARRL-SS-CW"},
+		{adifield.BAND: "6M", adifield.QSO_DATE: "20241102", adifield.TIME_ON: "2102",
+			adifield.STATION_CALLSIGN: "K9CTS", adifield.STX: "14", adifield.STX_STRING: "A 72 IL",
+			adifield.CALL: "K1ABC", adifield.SRX: "7", adifield.PRECEDENCE: "Q", adifield.CHECK: "01", adifield.ARRL_SECT: "CT",
+			adifield.CONTEST_ID: "ARRL-SS-CW"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if !maps.Equal(records[i], want[i]) {
+			t.Errorf("record %d: got %v, want %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestScanner_SetTemplate(t *testing.T) {
+	log := "START-OF-LOG: 3.0\nQSO: 1.2G FM 2024-06-08 1800 K9CTS EN52 W9PVA EN43\n"
+	s := NewScanner(strings.NewReader(log)).SetTemplate(Template{
+		Sent:     []adifield.Field{adifield.MY_GRIDSQUARE},
+		Received: []adifield.Field{adifield.GRIDSQUARE},
+	})
+	if !s.Scan() || !s.IsHeader() || len(s.Record()) != 0 {
+		t.Fatalf("header: got %v, %v", s.Record(), s.Err())
+	}
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	r := s.Record()
+	if r[adifield.BAND] != "23CM" || r[adifield.MODE] != "FM" || r[adifield.MY_GRIDSQUARE] != "EN52" || r[adifield.GRIDSQUARE] != "EN43" {
+		t.Errorf("got %v", r)
+	}
+	if _, ok := r[adifield.CONTEST_ID]; ok {
+		t.Errorf("got CONTEST_ID %q without a CONTEST tag", r[adifield.CONTEST_ID])
+	}
+	if s.Scan() || s.Err() != nil {
+		t.Errorf("got %v at the end of the input", s.Err())
+	}
+
+	d, err := ReadDocument(strings.NewReader("START-OF-LOG: 3.0\nCALLSIGN: K9CTS"))
+	if err != nil || d.Header[adifield.STATION_CALLSIGN] != "K9CTS" || len(d.Records) != 0 {
+		t.Errorf("header only: got %v, %v", d, err)
+	}
+}
+
+func TestScanner_UnknownContest(t *testing.T) {
+	log := "START-OF-LOG: 3.0\r\nCONTEST: IARU-HF\r\n" +
+		"QSO: 14000 CW 2024-07-13 1200 K9CTS 599 8 DL1ABC 599 DA0HQ\r\n" +
+		"QSO: 14001 CW 2024-07-13 1201 K9CTS 599 8 DL1ABC 599 DA0HQ 1\r\n" +
+		"QSO: 21001 CW 2024-07-13 1202 K9CTS 599 8 IL G4XYZ 599 27 KW\r\n" +
+		"QSO: 21002 CW 2024-07-13 1203 K9CTS 599 8 IL G4XYZ 599 27 KW 1\r\n" +
+		"QSO: 21003 CW 2024-07-13 1204 K9CTS G4XYZ\r\n"
+	want := []adif.Record{
+		{adifield.BAND: "20M", adifield.RST_SENT: "599", adifield.STX_STRING: "8", adifield.RST_RCVD: "599", adifield.SRX_STRING: "DA0HQ"},
+		{adifield.FREQ: "14.001", adifield.RST_SENT: "599", adifield.STX_STRING: "8", adifield.SRX_STRING: "DA0HQ", transmitterID: "1"},
+		{adifield.STX_STRING: "599 8 IL", adifield.CALL: "G4XYZ", adifield.SRX_STRING: "599 27 KW", adifield.RST_SENT: ""},
+		{adifield.STX_STRING: "599 8 IL", adifield.SRX_STRING: "599 27 KW", transmitterID: "1"},
+		{adifield.CALL: "G4XYZ", adifield.STX_STRING: "", adifield.SRX_STRING: ""},
+	}
+	d, err := ReadDocument(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(d.Records), len(want))
+	}
+	for i, fields := range want {
+		for field, v := range fields {
+			if got := d.Records[i][field]; got != v {
+				t.Errorf("record %d: %s got %q, want %q", i, field, got, v)
+			}
+		}
+	}
+
+	_, err = ReadDocument(strings.NewReader("START-OF-LOG: 3.0\r\nCONTEST: IARU-HF\r\nQSO: 21003 CW 2024-07-13 1204 K9CTS\r\n"))
+	if !errors.Is(err, ErrMalformedCabrillo) {
+		t.Errorf("no received call: got %v, want ErrMalformedCabrillo", err)
+	}
+}
+
+func TestScanner_ParseErrors(t *testing.T) {
+	const header = "START-OF-LOG: 3.0\r\nCONTEST: CQ-WPX-SSB\r\n"
+	tests := []struct {
+		name   string
+		log    string
+		line   int
+		column int
+		record int
+		field  adifield.Field
+	}{
+		{"empty", "", 1, 1, 0, ""},
+		{"no START-OF-LOG", "CONTEST: CQ-WPX-SSB\r\n", 1, 1, 0, ""},
+		{"only blank lines", "\r\n\r\n", 2, 1, 0, ""},
+		{"header line without tag", header + "CQ-WPX-SSB\r\n", 3, 1, 0, ""},
+		{"tag after QSO", header + "QSO: 14250 PH 2024-03-30 0000 K9CTS 59 1 DL1ABC 59 1\r\nSOAPBOX: late\r\n", 4, 1, 1, ""},
+		{"too few tokens", header + "QSO: 14250 PH 2024-03-30 0000 K9CTS 59 1 DL1ABC 59\r\n", 3, 1, 1, ""},
+		{"too many tokens", header + "QSO: 14250 PH 2024-03-30 0000 K9CTS 59 1 DL1ABC 59 1 0 0\r\n", 3, 1, 1, ""},
+		{"frequency", header + "QSO:  14.25 PH 2024-03-30 0000 K9CTS 59 1 DL1ABC 59 1\r\n", 3, 7, 1, adifield.FREQ},
+		{"mode", header + "QSO: 14250 SSB 2024-03-30 0000 K9CTS 59 1 DL1ABC 59 1\r\n", 3, 12, 1, adifield.MODE},
+		{"date", header + "QSO: 14250 PH 2024-02-30 0000 K9CTS 59 1 DL1ABC 59 1\r\n", 3, 15, 1, adifield.QSO_DATE},
+		{"time", header + "QSO: 14250 PH 2024-03-30 2400 K9CTS 59 1 DL1ABC 59 1\r\n", 3, 26, 1, adifield.TIME_ON},
+	}
+	for _, tt := range tests {
+		s := NewScanner(strings.NewReader(tt.log))
+		for s.Scan() {
+		}
+		var pe *adif.ParseError
+		if !errors.As(s.Err(), &pe) || !errors.Is(pe, ErrMalformedCabrillo) {
+			t.Errorf("%s: got %v, want a *ParseError", tt.name, s.Err())
+			continue
+		}
+		lines := strings.SplitAfter(tt.log, "\n")
+		offset := int64(len(strings.Join(lines[:max(tt.line-1, 0)], ""))) + int64(tt.column-1)
+		if pe.Line != tt.line || pe.Column != tt.column || pe.Offset != offset || pe.Record != tt.record || pe.Field != tt.field {
+			t.Errorf("%s: got %v, want line %d, column %d, offset %d, record %d, field %s",
+				tt.name, pe, tt.line, tt.column, offset, tt.record, tt.field)
+		}
+		if s.Scan() {
+			t.Errorf("%s: Scan returned true after an error", tt.name)
+		}
+	}
+}
+
+func TestScanner_ReadErrors(t *testing.T) {
+	for _, data := range []string{"", "START-OF-LOG: 3.0\r\n", testLog[:strings.Index(testLog, "QSO: 3500")]} {
+		if _, err := ReadDocument(&failReader{data: data}); err != errRead {
+			t.Errorf("%q: got %v, want errRead", data, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	d, err := ReadDocument(strings.NewReader(testLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	w := NewWriter(&sb)
+	if err := w.WriteHeader(d.Header); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range d.Records {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != testLog {
+		t.Errorf("got\n%s\nwant\n%s", sb.String(), testLog)
+	}
+}
@@ -0,0 +1,233 @@
+package cabrillo
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/adif/v5/internal/flush"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/contest"
+)
+
+// Writer writes ADIF records as a Cabrillo 3.0 log.
+// Obtain one with NewWriter, write the header with WriteHeader and each QSO with Write,
+// then call Close once to end the log.
+//
+// Writer does not add its own buffering. Wrap the destination with bufio.NewWriter
+// for buffered output; Close flushes it.
+type Writer struct {
+	w           io.Writer
+	template    Template
+	hasTemplate bool
+	call        string
+	wroteHeader bool
+}
+
+// NewWriter returns a Writer that writes a Cabrillo log to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// SetTemplate sets the exchange template used for QSO: lines and returns the Writer for chaining.
+// Without it, the template is chosen by DefaultTemplate from the CONTEST_ID of the header.
+func (w *Writer) SetTemplate(t Template) *Writer {
+	w.template, w.hasTemplate = t, true
+	return w
+}
+
+// WriteHeader writes START-OF-LOG: and the header tags held by r.
+// r must have a CONTEST_ID that is a member of the Contest enumeration; STATION_CALLSIGN is written as CALLSIGN,
+// and is the sent callsign of QSO records without their own.
+// Fields such as OPERATOR and MY_GRIDSQUARE are written as their Cabrillo tags,
+// and APP_CABRILLO_ fields as the tags they name, so APP_CABRILLO_CATEGORY_OPERATOR is written as CATEGORY-OPERATOR.
+// Values with CR LF line breaks, such as a multi-line SOAPBOX, are written as one tag per line.
+// Other fields are not written.
+//
+// The error wraps adif.ErrFieldNotFound or adif.ErrInvalidValue if CONTEST_ID is missing or unknown,
+// or is adif.ErrHeaderAlreadyWritten if the header was already written.
+func (w *Writer) WriteHeader(r adif.Record) error {
+	if w.wroteHeader {
+		return adif.ErrHeaderAlreadyWritten
+	}
+	id := r[adifield.CONTEST_ID]
+	if id == "" {
+		return fmt.Errorf("%w: %s", adif.ErrFieldNotFound, adifield.CONTEST_ID)
+	}
+	c := contest.New(id)
+	if _, ok := contest.Lookup(c); !ok {
+		return fmt.Errorf("%w: %s %q is not a valid %s", adif.ErrInvalidValue, adifield.CONTEST_ID, id, adifield.CONTEST_ID)
+	}
+
+	buf := []byte("START-OF-LOG: 3.0\r\n")
+	for _, h := range headerFields {
+		v := r[h.field]
+		if h.field == adifield.CONTEST_ID {
+			v = string(c)
+		}
+		buf = appendTag(buf, h.tag, v)
+	}
+	var extra []adifield.Field
+	for field := range r {
+		if strings.HasPrefix(string(field), appFieldPrefix) {
+			extra = append(extra, field)
+		}
+	}
+	slices.Sort(extra)
+	for _, field := range extra {
+		tag := strings.ReplaceAll(strings.TrimPrefix(string(field), appFieldPrefix), "_", "-")
+		buf = appendTag(buf, tag, r[field])
+	}
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+
+	w.wroteHeader = true
+	w.call = r[adifield.STATION_CALLSIGN]
+	if !w.hasTemplate {
+		w.template = DefaultTemplate(c)
+	}
+	return nil
+}
+
+// appendTag appends a header line for each line of value, or nothing if value is empty.
+func appendTag(buf []byte, tag, value string) []byte {
+	if value == "" {
+		return buf
+	}
+	for line := range strings.SplitSeq(value, "\r\n") {
+		buf = append(buf, tag...)
+		buf = append(buf, ": "...)
+		buf = append(buf, line...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// Write writes r as a QSO: line.
+// r must have a FREQ or BAND, a MODE, QSO_DATE, TIME_ON and CALL, and a value for each exchange field of the template.
+// Frequencies below 30 MHz are written in kHz, and higher ones as the band designator, e.g. 144 or 1.2G.
+// Without a FREQ, an HF BAND is written as its lower band edge in kHz, e.g. 7000, which Scanner reads back as BAND only.
+// MODE is written as CW, PH, FM, RY or, for any other mode, DG.
+// A value in APP_CABRILLO_TRANSMITTER_ID is written as the transmitter ID of multi-transmitter logs.
+//
+// The error wraps adif.ErrFieldNotFound or adif.ErrInvalidValue for a missing or malformed field,
+// including an exchange value with a different number of tokens than the template expects,
+// or is ErrNoHeader if WriteHeader has not been called. Nothing is written on error.
+func (w *Writer) Write(r adif.Record) error {
+	if !w.wroteHeader {
+		return ErrNoHeader
+	}
+	freq, err := frequency(r)
+	if err != nil {
+		return err
+	}
+	m, err := r.Mode()
+	if err != nil {
+		return err
+	}
+	code := modeCode(m)
+	t, err := r.Time(adifield.QSO_DATE, adifield.TIME_ON)
+	if err != nil {
+		return err
+	}
+	sentCall := r[adifield.STATION_CALLSIGN]
+	if sentCall == "" {
+		sentCall = w.call
+	}
+	if sentCall == "" {
+		return fmt.Errorf("%w: %s", adif.ErrFieldNotFound, adifield.STATION_CALLSIGN)
+	}
+	call := r[adifield.CALL]
+	if call == "" {
+		return fmt.Errorf("%w: %s", adif.ErrFieldNotFound, adifield.CALL)
+	}
+
+	tokens := []string{"QSO:", freq, code, t.Format("2006-01-02"), t.Format("1504"), sentCall}
+	if tokens, err = appendExchange(tokens, r, w.template.Sent, code); err != nil {
+		return err
+	}
+	tokens = append(tokens, call)
+	if tokens, err = appendExchange(tokens, r, w.template.Received, code); err != nil {
+		return err
+	}
+	if id := r[transmitterID]; id != "" {
+		tokens = append(tokens, id)
+	}
+	_, err = io.WriteString(w.w, strings.Join(tokens, " ")+"\r\n")
+	return err
+}
+
+// Close writes END-OF-LOG: and flushes the underlying io.Writer if it implements Flush() error (e.g. bufio.Writer).
+// It returns ErrNoHeader if WriteHeader has not been called. Close does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if !w.wroteHeader {
+		return ErrNoHeader
+	}
+	if _, err := io.WriteString(w.w, "END-OF-LOG:\r\n"); err != nil {
+		return err
+	}
+	return flush.Writer(w.w)
+}
+
+// frequency returns the QSO: line frequency of r: kHz below 30 MHz, otherwise the band designator.
+// Without a FREQ, HF bands are written as their lower band edge in kHz.
+func frequency(r adif.Record) (string, error) {
+	if r[adifield.FREQ] == "" {
+		b, err := r.Band()
+		if err != nil {
+			return "", err
+		}
+		if designator, ok := bandDesignators[b]; ok {
+			return designator, nil
+		}
+		return "", fmt.Errorf("%w: %s %q has no Cabrillo frequency", adif.ErrInvalidValue, adifield.BAND, r[adifield.BAND])
+	}
+	mhz, err := r.Float(adifield.FREQ)
+	if err != nil {
+		return "", err
+	}
+	if mhz > 0 && mhz < 30 {
+		return strconv.Itoa(int(math.Round(mhz * 1000))), nil
+	}
+	if spec, ok := band.FindBandByMHz(mhz); ok {
+		if designator, ok := bandDesignators[spec.Key]; ok {
+			return designator, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s %q has no Cabrillo frequency", adif.ErrInvalidValue, adifield.FREQ, r[adifield.FREQ])
+}
+
+// appendExchange appends the exchange tokens of r described by fields.
+// A run of the same field takes one token from its value per entry; RST fields default to 59 or 599.
+func appendExchange(tokens []string, r adif.Record, fields []adifield.Field, code string) ([]string, error) {
+	for i := 0; i < len(fields); {
+		field, n := fields[i], 1
+		for i+n < len(fields) && fields[i+n] == field {
+			n++
+		}
+		i += n
+
+		v := r[field]
+		if v == "" && (field == adifield.RST_SENT || field == adifield.RST_RCVD) {
+			v = "599"
+			if isPhone(code) {
+				v = "59"
+			}
+		}
+		if v == "" {
+			return nil, fmt.Errorf("%w: %s", adif.ErrFieldNotFound, field)
+		}
+		values := strings.Fields(v)
+		if len(values) != n {
+			return nil, fmt.Errorf("%w: %s %q is not %d exchange tokens", adif.ErrInvalidValue, field, v, n)
+		}
+		tokens = append(tokens, values...)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,199 @@
+package cabrillo
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// errWrite is returned by failWriter.
+var errWrite = errors.New("write failed")
+
+// failWriter accepts up to n bytes, then fails.
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, errWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func testHeader() adif.Record {
+	return adif.Record{
+		adifield.CONTEST_ID:              "cq-wpx-cw",
+		adifield.STATION_CALLSIGN:        "K9CTS",
+		adifield.OPERATOR:                "K9CTS W9PVA",
+		adifield.MY_GRIDSQUARE:           "EN52",
+		adifield.PROGRAMID:               "Test",
+		adifield.CALL:                    "not written",
+		"APP_CABRILLO_CATEGORY_OPERATOR": "MULTI-OP",
+		"APP_CABRILLO_SOAPBOX":           "Great conditions\r\nThanks for the QSOs",
+	}
+}
+
+const testLog = "START-OF-LOG: 3.0\r\n" +
+	"CONTEST: CQ-WPX-CW\r\n" +
+	"CALLSIGN: K9CTS\r\n" +
+	"OPERATORS: K9CTS W9PVA\r\n" +
+	"GRID-LOCATOR: EN52\r\n" +
+	"CREATED-BY: Test\r\n" +
+	"CATEGORY-OPERATOR: MULTI-OP\r\n" +
+	"SOAPBOX: Great conditions\r\n" +
+	"SOAPBOX: Thanks for the QSOs\r\n" +
+	"QSO: 14025 CW 2024-05-25 0001 K9CTS 599 1 DL1ABC 579 17\r\n" +
+	"QSO: 3500 CW 2024-05-25 0102 K9CTS 599 2 JA1XYZ 599 305 1\r\n" +
+	"QSO: 144 PH 2024-05-25 2359 W9PVA 59 3 N0CALL 57 4\r\n" +
+	"END-OF-LOG:\r\n"
+
+func testRecords() []adif.Record {
+	return []adif.Record{
+		{adifield.FREQ: "14.0251", adifield.MODE: "CW", adifield.QSO_DATE: "20240525", adifield.TIME_ON: "000130",
+			adifield.CALL: "DL1ABC", adifield.RST_RCVD: "579", adifield.STX: "1", adifield.SRX: "17"},
+		{adifield.BAND: "80m", adifield.MODE: "cw", adifield.QSO_DATE: "20240525", adifield.TIME_ON: "0102",
+			adifield.CALL: "JA1XYZ", adifield.STX: "2", adifield.SRX: "305", "APP_CABRILLO_TRANSMITTER_ID": "1"},
+		{adifield.FREQ: "144.2", adifield.MODE: "SSB", adifield.QSO_DATE: "20240525", adifield.TIME_ON: "2359",
+			adifield.STATION_CALLSIGN: "W9PVA", adifield.CALL: "N0CALL", adifield.RST_RCVD: "57", adifield.STX: "3", adifield.SRX: "4"},
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var sb strings.Builder
+	bw := bufio.NewWriter(&sb)
+	w := NewWriter(bw)
+	if err := w.WriteHeader(testHeader()); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range testRecords() {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != testLog {
+		t.Errorf("got\n%s\nwant\n%s", sb.String(), testLog)
+	}
+	if err := w.WriteHeader(testHeader()); err != adif.ErrHeaderAlreadyWritten {
+		t.Errorf("second WriteHeader: got %v, want ErrHeaderAlreadyWritten", err)
+	}
+}
+
+func TestWriter_SetTemplate(t *testing.T) {
+	var sb strings.Builder
+	w := NewWriter(&sb).SetTemplate(Template{
+		Sent:     []adifield.Field{adifield.STX_STRING, adifield.STX_STRING},
+		Received: []adifield.Field{adifield.SRX_STRING},
+	})
+	if err := w.WriteHeader(adif.Record{adifield.CONTEST_ID: "IARU-HF", adifield.STATION_CALLSIGN: "K9CTS"}); err != nil {
+		t.Fatal(err)
+	}
+	r := adif.Record{adifield.FREQ: "21.3", adifield.MODE: "FT8", adifield.QSO_DATE: "20240713", adifield.TIME_ON: "1200",
+		adifield.CALL: "DL1ABC", adifield.STX_STRING: "A  IL", adifield.SRX_STRING: "DARC"}
+	if err := w.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "QSO: 21300 DG 2024-07-13 1200 K9CTS A IL DL1ABC DARC\r\n"; !strings.HasSuffix(sb.String(), want) {
+		t.Errorf("got %q, want suffix %q", sb.String(), want)
+	}
+}
+
+func TestWriter_Errors(t *testing.T) {
+	w := NewWriter(&strings.Builder{})
+	if err := w.Write(testRecords()[0]); err != ErrNoHeader {
+		t.Errorf("Write: got %v, want ErrNoHeader", err)
+	}
+	if err := w.Close(); err != ErrNoHeader {
+		t.Errorf("Close: got %v, want ErrNoHeader", err)
+	}
+	if err := w.WriteHeader(adif.Record{}); !errors.Is(err, adif.ErrFieldNotFound) {
+		t.Errorf("no CONTEST_ID: got %v", err)
+	}
+	if err := w.WriteHeader(adif.Record{adifield.CONTEST_ID: "NOT-A-CONTEST"}); !errors.Is(err, adif.ErrInvalidValue) {
+		t.Errorf("unknown CONTEST_ID: got %v", err)
+	}
+	if err := w.WriteHeader(adif.Record{adifield.CONTEST_ID: "CQ-WW-CW"}); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := adif.Record{adifield.FREQ: "7.01", adifield.MODE: "CW", adifield.QSO_DATE: "20241123", adifield.TIME_ON: "0000",
+		adifield.STATION_CALLSIGN: "K9CTS", adifield.CALL: "DL1ABC", adifield.MY_CQ_ZONE: "4", adifield.CQZ: "14"}
+	if err := w.Write(valid); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		field adifield.Field
+		value string
+		err   error
+	}{
+		{"no frequency", adifield.FREQ, "", adif.ErrFieldNotFound},
+		{"bad FREQ", adifield.FREQ, "7,01", adif.ErrInvalidValue},
+		{"FREQ outside bands", adifield.FREQ, "1000000", adif.ErrInvalidValue},
+		{"FREQ without designator", adifield.FREQ, "41", adif.ErrInvalidValue},
+		{"no MODE", adifield.MODE, "", adif.ErrFieldNotFound},
+		{"no QSO_DATE", adifield.QSO_DATE, "", adif.ErrFieldNotFound},
+		{"no STATION_CALLSIGN", adifield.STATION_CALLSIGN, "", adif.ErrFieldNotFound},
+		{"no CALL", adifield.CALL, "", adif.ErrFieldNotFound},
+		{"no sent exchange", adifield.MY_CQ_ZONE, "", adif.ErrFieldNotFound},
+		{"no received exchange", adifield.CQZ, "", adif.ErrFieldNotFound},
+		{"too many tokens", adifield.CQZ, "14 15", adif.ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		r := adif.Record{}
+		for field, value := range valid {
+			r[field] = value
+		}
+		r[tt.field] = tt.value
+		if err := w.Write(r); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	r := adif.Record{adifield.BAND: "5M"}
+	for field, value := range valid {
+		if field != adifield.FREQ {
+			r[field] = value
+		}
+	}
+	if err := w.Write(r); !errors.Is(err, adif.ErrInvalidValue) {
+		t.Errorf("BAND without designator: got %v", err)
+	}
+	r[adifield.BAND] = "20"
+	if err := w.Write(r); !errors.Is(err, adif.ErrInvalidValue) {
+		t.Errorf("bad BAND: got %v", err)
+	}
+}
+
+func TestWriter_WriteErrors(t *testing.T) {
+	if err := NewWriter(&failWriter{}).WriteHeader(testHeader()); err != errWrite {
+		t.Errorf("WriteHeader: got %v, want errWrite", err)
+	}
+	header := strings.Index(testLog, "QSO:")
+	w := NewWriter(&failWriter{n: header})
+	if err := w.WriteHeader(testHeader()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(testRecords()[0]); err != errWrite {
+		t.Errorf("Write: got %v, want errWrite", err)
+	}
+
+	w = NewWriter(&failWriter{n: len(testLog) - len("END-OF-LOG:\r\n")})
+	if err := w.WriteHeader(testHeader()); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range testRecords() {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != errWrite {
+		t.Errorf("Close: got %v, want errWrite", err)
+	}
+}
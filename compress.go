@@ -0,0 +1,163 @@
+package adif
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	gzipMagic = []byte{0x1F, 0x8B}
+	zipMagic  = []byte{'P', 'K', 0x03, 0x04}
+)
+
+// File is an ADI file opened by Open. It embeds the Scanner that reads the file.
+type File struct {
+	*Scanner
+	f *os.File
+}
+
+// Open opens the named file for reading with a Scanner, decompressing it as NewScannerAuto does.
+// Call Close when done.
+func Open(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewScannerAuto(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &File{Scanner: s, f: f}, nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+// NewScannerAuto returns a Scanner that reads ADI records from r, which may be plain, gzip compressed, or a zip archive.
+// The format is detected from the first bytes of r; only the standard library is used to decompress.
+//
+// The .adi members of a zip archive are read one after another, in archive order, as if they were a single file;
+// each member with a header yields its own header record, so use IsHeader or QSOs to tell them apart.
+// A zip archive starts at the current position of r. It is read in place when r is a regular *os.File
+// or has Seek and Size methods, like *bytes.Reader; otherwise it is read into memory first.
+// ErrNoADIFiles is returned when it has no .adi members.
+func NewScannerAuto(r io.Reader) (*Scanner, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	// A short or failed Peek leaves the input to be read, and any error reported, by the Scanner.
+	magic, _ := br.Peek(len(zipMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return NewScanner(zr), nil
+	case bytes.HasPrefix(magic, zipMagic):
+		ra, size, err := readerAt(r, br)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			return nil, err
+		}
+		members := &zipMembers{}
+		for _, f := range zr.File {
+			// Skip directories and the AppleDouble files that macOS adds alongside each member.
+			if strings.EqualFold(path.Ext(f.Name), ".adi") && !f.FileInfo().IsDir() && !strings.HasPrefix(path.Base(f.Name), "._") {
+				members.files = append(members.files, f)
+			}
+		}
+		if len(members.files) == 0 {
+			return nil, ErrNoADIFiles
+		}
+		return NewScanner(members), nil
+	}
+	return NewScanner(br), nil
+}
+
+// readerAt returns the rest of r, from the position br reads next, as an io.ReaderAt with its size
+// when r supports random access, and otherwise the remaining contents of br in memory.
+func readerAt(r io.Reader, br *bufio.Reader) (io.ReaderAt, int64, error) {
+	size := int64(-1)
+	switch ra := r.(type) {
+	case *os.File:
+		if fi, err := ra.Stat(); err == nil && fi.Mode().IsRegular() {
+			size = fi.Size()
+		}
+	case interface{ Size() int64 }:
+		size = ra.Size()
+	}
+	if ra, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok && size >= 0 {
+		// br has read ahead of the next byte it returns.
+		if pos, err := ra.Seek(0, io.SeekCurrent); err == nil {
+			base := pos - int64(br.Buffered())
+			return io.NewSectionReader(ra, base, size-base), size - base, nil
+		}
+	}
+	data, err := io.ReadAll(br)
+	return bytes.NewReader(data), int64(len(data)), err
+}
+
+// zipMembers reads the contents of zip archive members one after another.
+type zipMembers struct {
+	files   []*zip.File
+	current io.ReadCloser
+}
+
+func (z *zipMembers) Read(p []byte) (int, error) {
+	for {
+		if z.current == nil {
+			if len(z.files) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := z.files[0].Open()
+			if err != nil {
+				return 0, err
+			}
+			z.current, z.files = rc, z.files[1:]
+		}
+		n, err := z.current.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		_ = z.current.Close() // checksums are verified by Read
+		z.current = nil
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// GzipWriter is a Writer that compresses its output with gzip. Obtain one with NewGzipWriter.
+type GzipWriter struct {
+	*Writer
+	zw *gzip.Writer
+}
+
+// NewGzipWriter returns a Writer that writes gzip compressed ADI records to w, e.g. for an .adi.gz file.
+// Call Close when done to complete the gzip stream.
+func NewGzipWriter(w io.Writer) *GzipWriter {
+	zw := gzip.NewWriter(w)
+	return &GzipWriter{Writer: NewWriter(zw), zw: zw}
+}
+
+// Close completes the gzip stream and flushes it to the underlying io.Writer, which is not closed.
+func (w *GzipWriter) Close() error {
+	return w.zw.Close()
+}
@@ -0,0 +1,297 @@
+package adif
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// gzipBytes returns data compressed with gzip.
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipBytes returns a zip archive holding the given members in order.
+func zipBytes(t *testing.T, members ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, m[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// scanCalls returns the CALL of each QSO record read by s.
+func scanCalls(t *testing.T, s *Scanner) []string {
+	t.Helper()
+	var calls []string
+	for r := range s.QSOs() {
+		calls = append(calls, r[adifield.CALL])
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	return calls
+}
+
+// onlyReader hides every method of r except Read.
+type onlyReader struct{ r io.Reader }
+
+func (o onlyReader) Read(p []byte) (int, error) { return o.r.Read(p) }
+
+func TestNewScannerAuto(t *testing.T) {
+	adi := "Test\n<PROGRAMID:4>Test<EOH><CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>\n"
+	archive := zipBytes(t,
+		[2]string{"logs/", ""},
+		[2]string{"logs/first.adi", adi},
+		[2]string{"readme.txt", "<CALL:5>N0CALL<EOR>"},
+		[2]string{"__MACOSX/logs/._second.ADI", "\x00\x05\x16\x07"},
+		[2]string{"logs/second.ADI", "<CALL:5>N9XYZ<EOR>"},
+	)
+	tests := []struct {
+		name string
+		r    io.Reader
+		want []string
+	}{
+		{"Plain", bytes.NewReader([]byte(adi)), []string{"K9CTS", "W9PVA"}},
+		{"Gzip", bytes.NewReader(gzipBytes(t, []byte(adi))), []string{"K9CTS", "W9PVA"}},
+		{"Zip", bytes.NewReader(archive), []string{"K9CTS", "W9PVA", "N9XYZ"}},
+		{"Zip stream", onlyReader{bytes.NewReader(archive)}, []string{"K9CTS", "W9PVA", "N9XYZ"}},
+		{"Empty", bytes.NewReader(nil), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScannerAuto(tt.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := scanCalls(t, s)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// failSeeker is a *bytes.Reader whose Seek method fails.
+type failSeeker struct{ *bytes.Reader }
+
+func (failSeeker) Seek(int64, int) (int64, error) { return 0, errors.New("seek failed") }
+
+func TestNewScannerAuto_ZipPosition(t *testing.T) {
+	prefix := "<CALL:5>N0CALL<EOR>"
+	data := append([]byte(prefix), zipBytes(t, [2]string{"a.adi", "<CALL:5>K9CTS<EOR>"})...)
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "log.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	br := bytes.NewReader(data)
+	fr := bytes.NewReader(data)
+	for _, r := range []io.Seeker{f, br, fr} {
+		if _, err := r.Seek(int64(len(prefix)), io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each reader is positioned at the start of the archive, past the prefix.
+	readers := map[string]io.Reader{"File": f, "bytes.Reader": br, "Seek failed": failSeeker{fr}}
+	for name, r := range readers {
+		s, err := NewScannerAuto(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := scanCalls(t, s); len(got) != 1 || got[0] != "K9CTS" {
+			t.Errorf("%s: got %v, want [K9CTS]", name, got)
+		}
+	}
+
+	// The archive starts after what br has read ahead, not at the start of the file.
+	if _, err := f.Seek(int64(len(prefix)), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	b := bufio.NewReader(f)
+	if _, err := b.Peek(4); err != nil {
+		t.Fatal(err)
+	}
+	ra, size, err := readerAt(f, b)
+	if err != nil || size != int64(len(data)-len(prefix)) {
+		t.Fatalf("readerAt: got size %d, %v; want %d", size, err, len(data)-len(prefix))
+	}
+	magic := make([]byte, 4)
+	if _, err := ra.ReadAt(magic, 0); err != nil || string(magic) != "PK\x03\x04" {
+		t.Errorf("readerAt: got %q, %v at offset 0", magic, err)
+	}
+}
+
+func TestNewScannerAuto_TestFiles(t *testing.T) {
+	data, err := testFileFS.ReadFile("testdata/N3FJP-AClogAdif.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := readSequential(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, compressed := range [][]byte{gzipBytes(t, data), zipBytes(t, [2]string{"N3FJP.adi", string(data)})} {
+		s, err := NewScannerAuto(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records []Record
+		for r := range s.QSOs() {
+			records = append(records, r)
+		}
+		if s.Err() != nil || len(records) != len(want.Records) {
+			t.Fatalf("got %d records, %v; want %d", len(records), s.Err(), len(want.Records))
+		}
+		for i := range records {
+			assertRecordEqual(t, records[i], want.Records[i])
+		}
+	}
+}
+
+func TestNewScannerAuto_Errors(t *testing.T) {
+	gz := gzipBytes(t, []byte("<CALL:5>K9CTS<EOR>"))
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"Bad gzip header", []byte{0x1F, 0x8B, 0, 0, 0, 0, 0, 0, 0, 0}, gzip.ErrHeader},
+		{"Bad zip", []byte("PK\x03\x04 not really"), zip.ErrFormat},
+		{"No .adi members", zipBytes(t, [2]string{"readme.txt", "hello"}), ErrNoADIFiles},
+	}
+	for _, tt := range tests {
+		if s, err := NewScannerAuto(bytes.NewReader(tt.data)); s != nil || !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// A truncated gzip stream fails while scanning.
+	s, err := NewScannerAuto(bytes.NewReader(gz[:len(gz)-4]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s.Scan() {
+	}
+	if !errors.Is(s.Err(), io.ErrUnexpectedEOF) {
+		t.Errorf("truncated gzip: got %v, want io.ErrUnexpectedEOF", s.Err())
+	}
+
+	// An archive that cannot be read into memory.
+	r := &mockFailReader{backingData: zipBytes(t, [2]string{"a.adi", "<CALL:5>K9CTS<EOR>"}), maxBytes: 64}
+	if _, err := NewScannerAuto(r); err == nil {
+		t.Error("read failure: expected an error")
+	}
+}
+
+func TestZipMembers_OpenError(t *testing.T) {
+	archive := zipBytes(t, [2]string{"a.adi", "<CALL:5>K9CTS<EOR>"})
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := *zr.File[0]
+	f.Method = 99 // unsupported compression method
+	if _, err := io.ReadAll(&zipMembers{files: []*zip.File{&f}}); !errors.Is(err, zip.ErrAlgorithm) {
+		t.Errorf("got %v, want zip.ErrAlgorithm", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	adi := "<CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>"
+	files := map[string][]byte{
+		"log.adi":    []byte(adi),
+		"log.adi.gz": gzipBytes(t, []byte(adi)),
+		"log.zip":    zipBytes(t, [2]string{"log.adi", adi}),
+		"notes.zip":  zipBytes(t, [2]string{"notes.txt", adi}),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"log.adi", "log.adi.gz", "log.zip"} {
+		f, err := Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := scanCalls(t, f.Scanner); len(got) != 2 || got[1] != "W9PVA" {
+			t.Errorf("%s: got %v", name, got)
+		}
+		if err := f.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := Open(filepath.Join(dir, "notes.zip")); !errors.Is(err, ErrNoADIFiles) {
+		t.Errorf("notes.zip: got %v, want ErrNoADIFiles", err)
+	}
+	if _, err := Open(filepath.Join(dir, "missing.adi")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing.adi: got %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestGzipWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewGzipWriter(&buf)
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(Record{adifield.CALL: "K9CTS"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewScannerAuto(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scanCalls(t, s); len(got) != 1 || got[0] != "K9CTS" {
+		t.Errorf("got %v", got)
+	}
+}
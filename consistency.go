@@ -0,0 +1,114 @@
+package adif
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/farmergreg/adif/v5/internal/digits"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/dxccentitycode"
+	"github.com/farmergreg/spec/v6/enum/primaryadministrativesubdivision"
+	"github.com/farmergreg/spec/v6/enum/submode"
+)
+
+const (
+	// RuleFreqBand reports a FREQ that lies outside the BAND of the same record.
+	RuleFreqBand Rule = "FREQ_BAND"
+
+	// RuleFreqRxBandRx reports a FREQ_RX that lies outside the BAND_RX of the same record.
+	RuleFreqRxBandRx Rule = "FREQ_RX_BAND_RX"
+
+	// RuleSubmodeMode reports a SUBMODE that belongs to a different MODE than the one in the same record.
+	RuleSubmodeMode Rule = "SUBMODE_MODE"
+
+	// RuleStateDXCC reports a STATE that is not a primary administrative subdivision of the DXCC entity of the same record.
+	RuleStateDXCC Rule = "STATE_DXCC"
+
+	// RuleMyStateMyDXCC reports a MY_STATE that is not a primary administrative subdivision of the MY_DXCC entity of the same record.
+	RuleMyStateMyDXCC Rule = "MY_STATE_MY_DXCC"
+)
+
+// crossFieldCheck checks the consistency of related fields within a record.
+// check returns the issue found, if any; checks stay silent when a field they rely on is missing or invalid
+// on its own, because the single-field rules already report that.
+type crossFieldCheck struct {
+	rule  Rule
+	check func(r Record) (Issue, bool)
+}
+
+// crossFieldChecks lists the consistency checks run by Validator.Validate in addition to the single-field rules.
+var crossFieldChecks = []crossFieldCheck{
+	{RuleFreqBand, func(r Record) (Issue, bool) { return checkFreqBand(r, adifield.FREQ, adifield.BAND, RuleFreqBand) }},
+	{RuleFreqRxBandRx, func(r Record) (Issue, bool) {
+		return checkFreqBand(r, adifield.FREQ_RX, adifield.BAND_RX, RuleFreqRxBandRx)
+	}},
+	{RuleSubmodeMode, checkSubmodeMode},
+	{RuleStateDXCC, func(r Record) (Issue, bool) { return checkStateDXCC(r, adifield.STATE, adifield.DXCC, RuleStateDXCC) }},
+	{RuleMyStateMyDXCC, func(r Record) (Issue, bool) {
+		return checkStateDXCC(r, adifield.MY_STATE, adifield.MY_DXCC, RuleMyStateMyDXCC)
+	}},
+}
+
+// checkFreqBand reports when the frequency in freqField, in MHz, lies outside the band named in bandField.
+func checkFreqBand(r Record, freqField, bandField adifield.Field, rule Rule) (Issue, bool) {
+	freq, b := r[freqField], r[bandField]
+	if freq == "" || b == "" || !isADIFNumber(freq) {
+		return Issue{}, false
+	}
+	spec, ok := band.Lookup(band.New(b))
+	if !ok {
+		return Issue{}, false
+	}
+	mhz, _ := strconv.ParseFloat(freq, 64)
+	if mhz >= spec.LowerFreqMHz.ToFloat64() && mhz <= spec.UpperFreqMHz.ToFloat64() {
+		return Issue{}, false
+	}
+	msg := fmt.Sprintf("%s MHz is outside %s %s (%s to %s MHz)", freq, bandField, spec.Key, spec.LowerFreqMHz, spec.UpperFreqMHz)
+	return Issue{freqField, freq, rule, SeverityError, msg}, true
+}
+
+// checkSubmodeMode reports when SUBMODE belongs to a different mode than MODE.
+func checkSubmodeMode(r Record) (Issue, bool) {
+	sub, m := r[adifield.SUBMODE], r[adifield.MODE]
+	if sub == "" || m == "" {
+		return Issue{}, false
+	}
+	spec, ok := submode.Lookup(submode.New(sub))
+	if !ok || strings.EqualFold(spec.Mode, m) {
+		return Issue{}, false
+	}
+	msg := fmt.Sprintf("%q belongs to MODE %s, not %s", sub, spec.Mode, m)
+	return Issue{adifield.SUBMODE, sub, RuleSubmodeMode, SeverityError, msg}, true
+}
+
+// checkStateDXCC reports when the subdivision in stateField does not exist within the entity in dxccField.
+// Entities for which the specification defines no primary administrative subdivisions are not checked.
+func checkStateDXCC(r Record, stateField, dxccField adifield.Field, rule Rule) (Issue, bool) {
+	state, dxcc := r[stateField], r[dxccField]
+	if state == "" || len(dxcc) > 4 || !digits.Only(dxcc) {
+		return Issue{}, false
+	}
+	code := dxccentitycode.DXCCEntityCode(atoi(dxcc))
+	if !entitiesWithSubdivisions()[code] {
+		return Issue{}, false
+	}
+	if _, ok := primaryadministrativesubdivision.LookupByCodeAndDXCC(primaryadministrativesubdivision.New(state), code); ok {
+		return Issue{}, false
+	}
+	entity, _ := dxccentitycode.Lookup(code)
+	msg := fmt.Sprintf("%q is not a primary administrative subdivision of %s %s (%s)", state, dxccField, dxcc, entity.EntityName)
+	return Issue{stateField, state, rule, SeverityError, msg}, true
+}
+
+// entitiesWithSubdivisions returns the set of DXCC entities for which primary administrative subdivisions are defined.
+// It is built on first use so that programs that never validate do not pay for it.
+var entitiesWithSubdivisions = sync.OnceValue(func() map[dxccentitycode.DXCCEntityCode]bool {
+	entities := make(map[dxccentitycode.DXCCEntityCode]bool)
+	for _, spec := range primaryadministrativesubdivision.List() {
+		entities[spec.DXCCEntityCode] = true
+	}
+	return entities
+})
@@ -0,0 +1,113 @@
+package adif
+
+import (
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestValidator_CrossField(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       Record
+		rule    Rule // empty when the record is consistent
+		field   adifield.Field
+		message string
+	}{
+		{"FREQ in BAND", Record{adifield.FREQ: "14.061", adifield.BAND: "20m"}, "", "", ""},
+		{"FREQ at band edge", Record{adifield.FREQ: "7.3", adifield.BAND: "40M"}, "", "", ""},
+		{"FREQ outside BAND", Record{adifield.FREQ: "14.061", adifield.BAND: "40M"}, RuleFreqBand, adifield.FREQ,
+			"14.061 MHz is outside BAND 40M (7 to 7.3 MHz)"},
+		{"FREQ without BAND", Record{adifield.FREQ: "14.061"}, "", "", ""},
+		{"FREQ not a number", Record{adifield.FREQ: "14,061", adifield.BAND: "40M"}, RuleDataType, adifield.FREQ,
+			`"14,061" is not a valid NUMBER`},
+		{"FREQ with unknown BAND", Record{adifield.FREQ: "14.061", adifield.BAND: "20"}, RuleEnumeration, adifield.BAND,
+			`"20" is not a valid BAND`},
+		{"FREQ_RX outside BAND_RX", Record{adifield.FREQ_RX: "145.825", adifield.BAND_RX: "40m"}, RuleFreqRxBandRx, adifield.FREQ_RX,
+			"145.825 MHz is outside BAND_RX 40M (7 to 7.3 MHz)"},
+		{"SUBMODE of MODE", Record{adifield.MODE: "ssb", adifield.SUBMODE: "USB"}, "", "", ""},
+		{"SUBMODE of other MODE", Record{adifield.MODE: "FM", adifield.SUBMODE: "USB"}, RuleSubmodeMode, adifield.SUBMODE,
+			`"USB" belongs to MODE SSB, not FM`},
+		{"SUBMODE without MODE", Record{adifield.SUBMODE: "USB"}, "", "", ""},
+		{"STATE of DXCC", Record{adifield.STATE: "wi", adifield.DXCC: "291"}, "", "", ""},
+		{"STATE of other DXCC", Record{adifield.STATE: "AK", adifield.DXCC: "291"}, RuleStateDXCC, adifield.STATE,
+			`"AK" is not a primary administrative subdivision of DXCC 291 (UNITED STATES OF AMERICA)`},
+		{"MY_STATE of other MY_DXCC", Record{adifield.MY_STATE: "ON", adifield.MY_DXCC: "291"}, RuleMyStateMyDXCC, adifield.MY_STATE,
+			`"ON" is not a primary administrative subdivision of MY_DXCC 291 (UNITED STATES OF AMERICA)`},
+		{"STATE without DXCC", Record{adifield.STATE: "AK"}, "", "", ""},
+		{"STATE of DXCC without subdivisions", Record{adifield.STATE: "XX", adifield.DXCC: "0"}, "", "", ""},
+		{"STATE with invalid DXCC", Record{adifield.STATE: "AK", adifield.DXCC: "USA"}, RuleEnumeration, adifield.DXCC,
+			`"USA" is not a valid DXCC`},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := v.Validate(tt.r)
+			if tt.rule == "" {
+				if issues != nil {
+					t.Fatalf("unexpected issues %v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("got %v, want one %s issue", issues, tt.rule)
+			}
+			if got := issues[0]; got.Rule != tt.rule || got.Field != tt.field || got.Message != tt.message {
+				t.Errorf("got %s %s %q, want %s %s %q", got.Rule, got.Field, got.Message, tt.rule, tt.field, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidator_CrossField_Disabled(t *testing.T) {
+	r := Record{
+		adifield.FREQ:     "14.061",
+		adifield.BAND:     "40m",
+		adifield.FREQ_RX:  "14.061",
+		adifield.BAND_RX:  "40m",
+		adifield.MODE:     "FM",
+		adifield.SUBMODE:  "USB",
+		adifield.STATE:    "AK",
+		adifield.DXCC:     "291",
+		adifield.MY_STATE: "AK",
+		adifield.MY_DXCC:  "291",
+	}
+	tests := []struct {
+		rule Rule
+		want int
+	}{
+		{RuleFreqBand, 4},
+		{RuleFreqRxBandRx, 4},
+		{RuleSubmodeMode, 4},
+		{RuleStateDXCC, 4},
+		{RuleMyStateMyDXCC, 4},
+	}
+	if got := len(NewValidator().Validate(r)); got != 5 {
+		t.Fatalf("all rules: got %d issues, want 5", got)
+	}
+	for _, tt := range tests {
+		issues := NewValidator().SetRuleEnabled(tt.rule, false).Validate(r)
+		if len(issues) != tt.want {
+			t.Errorf("%s disabled: got %d issues (%v), want %d", tt.rule, len(issues), issues, tt.want)
+		}
+	}
+}
+
+func TestValidator_CrossField_StateRulesSeparate(t *testing.T) {
+	r := Record{adifield.STATE: "AK", adifield.DXCC: "291", adifield.MY_STATE: "ON", adifield.MY_DXCC: "291"}
+	tests := []struct {
+		disabled Rule
+		rule     Rule
+		field    adifield.Field
+	}{
+		{RuleStateDXCC, RuleMyStateMyDXCC, adifield.MY_STATE},
+		{RuleMyStateMyDXCC, RuleStateDXCC, adifield.STATE},
+	}
+	for _, tt := range tests {
+		issues := NewValidator().SetRuleEnabled(tt.disabled, false).Validate(r)
+		if len(issues) != 1 || issues[0].Rule != tt.rule || issues[0].Field != tt.field {
+			t.Errorf("%s disabled: got %v, want one %s issue for %s", tt.disabled, issues, tt.rule, tt.field)
+		}
+	}
+}
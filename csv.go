@@ -0,0 +1,274 @@
+package adif
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/farmergreg/adif/v5/internal/flush"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// CSVWriter writes QSO records as CSV, one row per record, after a header row of field names.
+// Obtain one with NewCSVWriter, write records with Write or WriteAll, then call Flush once.
+type CSVWriter struct {
+	w       io.Writer
+	cw      *csv.Writer
+	columns []adifield.Field
+	row     []string
+}
+
+// NewCSVWriter returns a CSVWriter that writes to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: w, cw: csv.NewWriter(w)}
+}
+
+// SetColumns sets the fields written, one per column in the given order, and returns the CSVWriter for chaining.
+// Fields of a record that are not listed are not written.
+// Without it, the columns are those of the first record written, in the pretty order of WriteModePretty,
+// or, for WriteAll, those of all its records.
+func (w *CSVWriter) SetColumns(columns ...adifield.Field) *CSVWriter {
+	w.columns = columns
+	return w
+}
+
+// Write writes r as a CSV row, first writing the header row if this is the first record.
+// When SetColumns has not been called, fields that are not in the first record are not written.
+func (w *CSVWriter) Write(r Record) error {
+	if w.row == nil {
+		if w.columns == nil {
+			w.columns = appendFieldOrderPretty(nil, r)
+		}
+		w.row = make([]string, len(w.columns))
+		for i, field := range w.columns {
+			w.row[i] = string(field)
+		}
+		if err := w.cw.Write(w.row); err != nil {
+			return err
+		}
+	}
+	for i, field := range w.columns {
+		w.row[i] = r[field]
+	}
+	return w.cw.Write(w.row)
+}
+
+// WriteAll writes records with Write and then calls Flush.
+// When SetColumns has not been called, the columns are the fields of all records, in pretty order.
+func (w *CSVWriter) WriteAll(records []Record) error {
+	if w.columns == nil && w.row == nil {
+		union := NewRecord()
+		for _, r := range records {
+			for field := range r {
+				union[field] = ""
+			}
+		}
+		w.columns = appendFieldOrderPretty(nil, union)
+	}
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Flush writes any buffered rows and then flushes the underlying io.Writer if it implements Flush() error (e.g. bufio.Writer).
+func (w *CSVWriter) Flush() error {
+	w.cw.Flush()
+	if err := w.cw.Error(); err != nil {
+		return err
+	}
+	return flush.Writer(w.w)
+}
+
+// CSVColumn maps a CSV column to an ADIF field, for use with CSVScanner.SetMapping.
+type CSVColumn struct {
+	// Field is the field that receives the value of the column.
+	Field adifield.Field
+
+	// Layout, when set, is the time.Parse layout of the column, e.g. "01/02/2006" or "2006-01-02 15:04".
+	// The parsed time is stored in Field as an ADIF DATE, or as an ADIF TIME when Field is a TIME field such as TIME_ON.
+	Layout string
+
+	// TimeField, when set with Layout, receives the time of day of the parsed time, while Field receives its date,
+	// e.g. {Field: QSO_DATE, TimeField: TIME_ON, Layout: "2006-01-02 15:04"} for a combined column.
+	// It is not set when Layout has no time of day, such as "2006-01-02".
+	TimeField adifield.Field
+
+	// Transform, when set, is applied to each non-empty value before Layout.
+	// An error returned by Transform stops the scan and is reported in a *CSVError.
+	Transform func(value string) (string, error)
+
+	hasTime bool // whether Layout includes a time of day, set by SetMapping
+}
+
+// CSVScanner reads QSO records from CSV with a header row, such as a spreadsheet export.
+// Obtain one with NewCSVScanner, then call Scan until it returns false and check Err.
+//
+// Each column is read into the field given for its header by SetMapping.
+// Columns without a mapping are read into the field their header names, when it is an ADIF field or an APP_ field,
+// so the output of CSVWriter is read back without a mapping. Other columns are ignored.
+// Values are trimmed of surrounding whitespace, line breaks within them are stored as CR LF, and empty values are not stored.
+type CSVScanner struct {
+	cr      *csv.Reader
+	mapping map[string]CSVColumn
+	columns []*CSVColumn // per column index, nil for ignored columns
+	record  Record
+	row     int
+	err     error
+}
+
+// NewCSVScanner returns a CSVScanner that reads CSV from r.
+// Rows may have fewer or more cells than the header row; missing cells are empty and extra cells are ignored.
+func NewCSVScanner(r io.Reader) *CSVScanner {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	return &CSVScanner{cr: cr}
+}
+
+// SetMapping sets the CSVColumn of each column by its header name, matched case-insensitively,
+// and returns the CSVScanner for chaining. Field and TimeField names are normalized to uppercase.
+// For example, {"Date": {Field: adifield.QSO_DATE, Layout: "02/01/2006"}} reads the "Date" column as a DATE.
+func (s *CSVScanner) SetMapping(mapping map[string]CSVColumn) *CSVScanner {
+	s.mapping = make(map[string]CSVColumn, len(mapping))
+	for header, column := range mapping {
+		column.Field = adifield.New(string(column.Field))
+		if column.TimeField != "" {
+			column.TimeField = adifield.New(string(column.TimeField))
+		}
+		column.hasTime = layoutHasTime(column.Layout)
+		s.mapping[strings.ToUpper(strings.TrimSpace(header))] = column
+	}
+	return s
+}
+
+// Scan reads the next row into a Record, which is then available through Record.
+// It returns false at the end of the input or on error.
+func (s *CSVScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.columns == nil {
+		if s.err = s.readHeader(); s.err != nil {
+			return false
+		}
+	}
+	cells, err := s.cr.Read()
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		return false
+	}
+	s.row++
+	r := NewRecord()
+	for i, cell := range cells[:min(len(cells), len(s.columns))] {
+		if c := s.columns[i]; c != nil {
+			// encoding/csv reads every line break as LF; ADIF line breaks are CR LF.
+			value := strings.ReplaceAll(strings.TrimSpace(cell), "\n", "\r\n")
+			if err := c.store(r, value); err != nil {
+				s.err = &CSVError{Row: s.row, Column: i + 1, Field: c.Field, Err: err}
+				return false
+			}
+		}
+	}
+	s.record = r
+	return true
+}
+
+// Record returns the most recent record read by Scan.
+func (s *CSVScanner) Record() Record {
+	return s.record
+}
+
+// Err returns the first error encountered by Scan, or nil at the end of the input.
+// Malformed CSV is reported as a *csv.ParseError, and values rejected by a CSVColumn as a *CSVError.
+func (s *CSVScanner) Err() error {
+	return s.err
+}
+
+// readHeader reads the header row and resolves the CSVColumn of each column.
+func (s *CSVScanner) readHeader() error {
+	headers, err := s.cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.row = 1
+	s.columns = make([]*CSVColumn, len(headers))
+	for i, header := range headers {
+		if i == 0 {
+			header = strings.TrimPrefix(header, "\uFEFF") // spreadsheets often write a UTF-8 byte order mark
+		}
+		name := strings.ToUpper(strings.TrimSpace(header))
+		if c, ok := s.mapping[name]; ok {
+			s.columns[i] = &c
+			continue
+		}
+		field := adifield.New(name)
+		if _, ok := adifield.Lookup(field); ok || strings.HasPrefix(name, "APP_") {
+			s.columns[i] = &CSVColumn{Field: field}
+		}
+	}
+	return nil
+}
+
+// store stores value in r as described by c.
+func (c *CSVColumn) store(r Record, value string) error {
+	if value != "" && c.Transform != nil {
+		var err error
+		if value, err = c.Transform(value); err != nil {
+			return err
+		}
+	}
+	if value == "" {
+		return nil
+	}
+	if c.Layout == "" {
+		r[c.Field] = value
+		return nil
+	}
+	t, err := time.Parse(c.Layout, value)
+	if err != nil {
+		return fmt.Errorf("%w: %s %q does not match layout %q", ErrInvalidValue, c.Field, value, c.Layout)
+	}
+	switch {
+	case c.TimeField != "" && c.hasTime:
+		r.SetTime(c.Field, c.TimeField, t)
+	case isTimeField(c.Field):
+		r[c.Field] = t.Format("150405")
+	default:
+		r[c.Field] = t.Format("20060102")
+	}
+	return nil
+}
+
+// layoutHasTime reports whether the time.Parse layout includes a time of day,
+// by formatting two times that differ only in their time of day.
+func layoutHasTime(layout string) bool {
+	midnight := time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)
+	return midnight.Format(layout) != midnight.Add(13*time.Hour+4*time.Minute+5*time.Second).Format(layout)
+}
+
+// isTimeField reports whether the ADIF data type of field is TIME.
+func isTimeField(field adifield.Field) bool {
+	spec, ok := adifield.Lookup(field)
+	return ok && spec.DataType == aditype.TIME
+}
+
+// ReadCSV reads all rows of the CSV in r, mapped as by CSVScanner.SetMapping, into a Document without a header.
+// A nil mapping reads the columns whose headers name fields.
+func ReadCSV(r io.Reader, mapping map[string]CSVColumn) (*Document, error) {
+	s := NewCSVScanner(r).SetMapping(mapping)
+	d := NewDocument()
+	for s.Scan() {
+		d.Records = append(d.Records, s.Record())
+	}
+	return d, s.Err()
+}
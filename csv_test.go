@@ -0,0 +1,194 @@
+package adif
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestCSVWriter(t *testing.T) {
+	records := []Record{
+		{adifield.CALL: "K9CTS", adifield.QSO_DATE: "20240101", adifield.TIME_ON: "1200", adifield.BAND: "20M", "APP_TEST": "a, \"b\""},
+		{adifield.CALL: "W9PVA", adifield.QSO_DATE: "20240102", adifield.TIME_ON: "1300", adifield.MODE: "CW", adifield.COMMENT: "line 1\r\nline 2"},
+	}
+	tests := []struct {
+		name    string
+		columns []adifield.Field
+		want    string
+	}{
+		{"pretty order", nil, "QSO_DATE,TIME_ON,BAND,MODE,CALL,APP_TEST,COMMENT\n" +
+			"20240101,1200,20M,,K9CTS,\"a, \"\"b\"\"\",\n" +
+			"20240102,1300,,CW,W9PVA,,\"line 1\r\nline 2\"\n"},
+		{"columns", []adifield.Field{adifield.CALL, adifield.FREQ, adifield.QSO_DATE}, "CALL,FREQ,QSO_DATE\n" +
+			"K9CTS,,20240101\n" +
+			"W9PVA,,20240102\n"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		bw := bufio.NewWriter(&sb)
+		w := NewCSVWriter(bw)
+		if tt.columns != nil {
+			w.SetColumns(tt.columns...)
+		}
+		if err := w.WriteAll(records); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, sb.String(), tt.want)
+		}
+	}
+
+	// Without SetColumns or WriteAll, the first record chooses the columns.
+	var sb strings.Builder
+	w := NewCSVWriter(&sb)
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "QSO_DATE,TIME_ON,BAND,CALL,APP_TEST\n20240101,1200,20M,K9CTS,\"a, \"\"b\"\"\"\n20240102,1300,,W9PVA,\n"; sb.String() != want {
+		t.Errorf("Write: got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestCSVWriter_Errors(t *testing.T) {
+	long := strings.Repeat("x", 5000) // larger than the buffer of csv.Writer
+	if err := NewCSVWriter(&mockFailWriter{}).SetColumns(adifield.Field(long)).Write(Record{}); err == nil {
+		t.Error("header row: expected an error")
+	}
+	if err := NewCSVWriter(&mockFailWriter{}).WriteAll([]Record{{adifield.CALL: long}}); err == nil {
+		t.Error("row: expected an error")
+	}
+	if err := NewCSVWriter(&mockFailWriter{}).WriteAll([]Record{{adifield.CALL: "K9CTS"}}); err == nil {
+		t.Error("Flush: expected an error")
+	}
+}
+
+func TestCSVScanner(t *testing.T) {
+	data := "\uFEFFDate,Time, Call ,Freq MHz,When,Mode,Notes,APP_TEST,Ignored\n" +
+		"03/05/2024,1:02 PM,k9cts,14.074,2024-03-05 13:02,FT8,,x,y\n" +
+		"\n" +
+		"03/06/2024,,w9pva,,,\n" +
+		"03/07/2024,23:59,N0CALL,7.1,,cw,\"multi\nline\",z,extra,cells\n"
+	mapping := map[string]CSVColumn{
+		"date":     {Field: adifield.QSO_DATE, Layout: "01/02/2006"},
+		"time":     {Field: adifield.TIME_ON, Layout: "3:04 PM", Transform: func(v string) (string, error) { return strings.Replace(v, "23:59", "11:59 PM", 1), nil }},
+		"CALL":     {Field: adifield.CALL, Transform: func(v string) (string, error) { return strings.ToUpper(v), nil }},
+		"Freq MHz": {Field: adifield.FREQ},
+		"When":     {Field: adifield.QSO_DATE_OFF, TimeField: adifield.TIME_OFF, Layout: "2006-01-02 15:04"},
+	}
+	d, err := ReadCSV(strings.NewReader(data), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Record{
+		{adifield.QSO_DATE: "20240305", adifield.TIME_ON: "130200", adifield.CALL: "K9CTS", adifield.FREQ: "14.074",
+			adifield.QSO_DATE_OFF: "20240305", adifield.TIME_OFF: "130200", adifield.MODE: "FT8", "APP_TEST": "x"},
+		{adifield.QSO_DATE: "20240306", adifield.CALL: "W9PVA"},
+		{adifield.QSO_DATE: "20240307", adifield.TIME_ON: "235900", adifield.CALL: "N0CALL", adifield.FREQ: "7.1",
+			adifield.MODE: "cw", adifield.NOTES: "multi\r\nline", "APP_TEST": "z"},
+	}
+	if d.Header != nil || len(d.Records) != len(want) {
+		t.Fatalf("got header %v and %d records, want %d", d.Header, len(d.Records), len(want))
+	}
+	for i := range want {
+		assertRecordEqual(t, d.Records[i], want[i])
+	}
+}
+
+func TestCSVScanner_SetMapping_Normalized(t *testing.T) {
+	data := "Logged,Day,Op\n2024-03-05 13:02,2024-03-06,k9cts\n"
+	mapping := map[string]CSVColumn{
+		"Logged": {Field: "qso_date", TimeField: "time_on", Layout: "2006-01-02 15:04"},
+		"Day":    {Field: "qso_date_off", TimeField: "time_off", Layout: "2006-01-02"},
+		"Op":     {Field: "operator"},
+	}
+	d, err := ReadCSV(strings.NewReader(data), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Record{adifield.QSO_DATE: "20240305", adifield.TIME_ON: "130200", adifield.QSO_DATE_OFF: "20240306", adifield.OPERATOR: "k9cts"}
+	if len(d.Records) != 1 {
+		t.Fatalf("got %d records", len(d.Records))
+	}
+	assertRecordEqual(t, d.Records[0], want)
+	if _, err := d.Records[0].Time(adifield.QSO_DATE, adifield.TIME_ON); err != nil {
+		t.Errorf("Time: %v", err)
+	}
+}
+
+func TestCSVScanner_RoundTrip(t *testing.T) {
+	records := []Record{
+		{adifield.CALL: "K9CTS", adifield.QSO_DATE: "20240101", adifield.COMMENT: "a, \"b\"\r\nc", "APP_TEST": "1"},
+		{adifield.CALL: "W9PVA", adifield.BAND: "40M"},
+	}
+	var sb strings.Builder
+	if err := NewCSVWriter(&sb).WriteAll(records); err != nil {
+		t.Fatal(err)
+	}
+	s := NewCSVScanner(strings.NewReader(sb.String()))
+	for i := 0; s.Scan(); i++ {
+		assertRecordEqual(t, s.Record(), records[i])
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+}
+
+func TestCSVScanner_Errors(t *testing.T) {
+	errTransform := errors.New("bad value")
+	mapping := map[string]CSVColumn{
+		"Date": {Field: adifield.QSO_DATE, Layout: "2006-01-02"},
+		"Call": {Field: adifield.CALL, Transform: func(v string) (string, error) {
+			if v == "bad" {
+				return "", errTransform
+			}
+			return v, nil
+		}},
+	}
+	tests := []struct {
+		name string
+		data string
+		err  error
+		row  int
+		col  int
+	}{
+		{"layout", "Call,Date\nK9CTS,2024-01-01\nW9PVA,01/02/2024\n", ErrInvalidValue, 3, 2},
+		{"transform", "Date,Call\n2024-01-01,bad\n", errTransform, 2, 2},
+	}
+	for _, tt := range tests {
+		_, err := ReadCSV(strings.NewReader(tt.data), mapping)
+		var ce *CSVError
+		if !errors.As(err, &ce) || !errors.Is(err, tt.err) || ce.Row != tt.row || ce.Column != tt.col {
+			t.Errorf("%s: got %v, want row %d, column %d: %v", tt.name, err, tt.row, tt.col, tt.err)
+		}
+	}
+	err := (&CSVError{Row: 3, Column: 2, Field: adifield.QSO_DATE, Err: errTransform}).Error()
+	if want := "adif: CSV row 3, column 2, field QSO_DATE: bad value"; err != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+
+	var pe *csv.ParseError
+	for _, data := range []string{"Call,\"Date\n", "Call\n\"K9CTS\n"} {
+		if _, err := ReadCSV(strings.NewReader(data), nil); !errors.As(err, &pe) {
+			t.Errorf("%q: got %v, want a *csv.ParseError", data, err)
+		}
+	}
+
+	s := NewCSVScanner(strings.NewReader("Call\n\"K9CTS\n"))
+	if s.Scan() || s.Scan() || !errors.As(s.Err(), &pe) {
+		t.Errorf("Scan after an error: got %v", s.Err())
+	}
+
+	s = NewCSVScanner(strings.NewReader(""))
+	if s.Scan() || s.Scan() || s.Err() != nil {
+		t.Errorf("empty input: got %v", s.Err())
+	}
+}
@@ -0,0 +1,117 @@
+package adif
+
+import (
+	"strings"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// userdefSeparator separates a user-defined field name from its optional enumeration or range in a USERDEFn header value.
+const userdefSeparator = ","
+
+// dataTypeIndicators decides which data type indicator, if any, a writer emits for each field.
+// A nil *dataTypeIndicators emits none.
+type dataTypeIndicators struct {
+	// explicit holds caller supplied indicators; they take precedence over automatic ones.
+	explicit map[adifield.Field]aditype.DataTypeIndicator
+
+	// auto enables indicators for APP_ fields, header USERDEFn fields and the user-defined fields they declare.
+	auto bool
+
+	// userFields holds the user-defined fields declared by the header and their indicators.
+	userFields map[adifield.Field]aditype.DataTypeIndicator
+}
+
+// enabled reports whether any indicators may be emitted.
+func (d *dataTypeIndicators) enabled() bool {
+	return d.auto || len(d.explicit) > 0
+}
+
+// learnHeader records the user-defined fields declared by the USERDEFn fields of header r.
+func (d *dataTypeIndicators) learnHeader(r Record) {
+	for field, value := range r {
+		if _, ok := userdefFieldID(field); !ok || value == "" {
+			continue
+		}
+		if d.userFields == nil {
+			d.userFields = make(map[adifield.Field]aditype.DataTypeIndicator)
+		}
+		name, constraint, _ := strings.Cut(value, userdefSeparator)
+		dt, ok := d.explicitIndicator(field)
+		if !ok {
+			dt = userdefDataTypeIndicator(constraint)
+		}
+		d.userFields[adifield.New(name)] = dt
+	}
+}
+
+// isUserField reports whether field was declared as a user-defined field by the header.
+func (d *dataTypeIndicators) isUserField(field adifield.Field) bool {
+	_, ok := d.userFields[field]
+	return ok
+}
+
+// lookup returns the data type indicator to write for field, or aditype.DATATYPEINDICATOR_NONE.
+func (d *dataTypeIndicators) lookup(field adifield.Field, value string, isHeader bool) aditype.DataTypeIndicator {
+	if d == nil {
+		return aditype.DATATYPEINDICATOR_NONE
+	}
+	if dt, ok := d.explicitIndicator(field); ok {
+		return dt
+	}
+	if !d.auto {
+		return aditype.DATATYPEINDICATOR_NONE
+	}
+	if isHeader {
+		if _, ok := userdefFieldID(field); ok {
+			_, constraint, _ := strings.Cut(value, userdefSeparator)
+			return userdefDataTypeIndicator(constraint)
+		}
+		return aditype.DATATYPEINDICATOR_NONE
+	}
+	if dt, ok := d.userFields[field]; ok {
+		return dt
+	}
+	if strings.HasPrefix(string(field), adifield.APP_) {
+		if strings.Contains(value, "\r\n") {
+			return aditype.DATATYPEINDICATOR_MULTILINESTRING
+		}
+		return aditype.DATATYPEINDICATOR_STRING
+	}
+	return aditype.DATATYPEINDICATOR_NONE
+}
+
+// explicitIndicator returns the caller supplied indicator for field.
+// Indicators other than an uppercase ASCII letter are ignored, as ADI cannot carry them.
+func (d *dataTypeIndicators) explicitIndicator(field adifield.Field) (aditype.DataTypeIndicator, bool) {
+	dt, ok := d.explicit[field]
+	return dt, ok && dt >= 'A' && dt <= 'Z'
+}
+
+// userdefDataTypeIndicator infers the data type of a user-defined field from its optional USERDEFn constraint.
+// A range such as {5:20} implies a Number, an enumeration such as {S,M,L} implies an Enumeration,
+// and no constraint implies a String.
+func userdefDataTypeIndicator(constraint string) aditype.DataTypeIndicator {
+	switch {
+	case strings.Contains(constraint, ":"):
+		return aditype.DATATYPEINDICATOR_NUMBER
+	case constraint != "":
+		return aditype.DATATYPEINDICATOR_ENUMERATION
+	}
+	return aditype.DATATYPEINDICATOR_STRING
+}
+
+// userdefFieldID returns n when field is a header USERDEFn field.
+func userdefFieldID(field adifield.Field) (string, bool) {
+	id, ok := strings.CutPrefix(string(field), adifield.USERDEF)
+	if !ok || id == "" {
+		return "", false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return "", false
+		}
+	}
+	return id, true
+}
@@ -1,20 +1,55 @@
 package adif
 
 import (
+	"bufio"
 	"io"
+	"iter"
 	"strings"
+
+	"github.com/farmergreg/adif/v5/internal/flush"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
 )
 
 // Document holds a complete ADIF document in memory.
 // Header is nil when the source contains no header record.
+// Format selects whether WriteTo produces ADI, ADX or NDJSON; ReadFrom sets it to the format it detected.
 //
 // For large files that should not be fully loaded into memory, use Scanner instead.
 type Document struct {
+	// Preamble is the free-form text written before the header fields, such as a logger's comments.
+	// ReadFrom stores the preamble found in an ADI source so that a read-then-write cycle preserves it.
+	// When empty, WriteTo uses the default preamble of NewWriter. It is not written in ADX or NDJSON format.
+	Preamble string `json:"-"`
+
 	// Header is the ADIF header record, or nil if no header is present.
 	Header Record `json:"HEADER,omitempty"`
 
 	// Records contains all QSO records.
 	Records []Record `json:"RECORDS"`
+
+	// Format is the serialization used by WriteTo and String.
+	Format Format `json:"-"`
+
+	// DataTypes holds the data type indicators to write for each field, or nil for none.
+	// ReadFrom collects the indicators found in the source so that a read-then-write cycle preserves them. They are not written in NDJSON format.
+	DataTypes map[adifield.Field]aditype.DataTypeIndicator `json:"-"`
+}
+
+// recordScanner is the common interface of Scanner and ADXScanner, and of NDJSONScanner through ndjsonRecordScanner.
+type recordScanner interface {
+	Scan() bool
+	Record() Record
+	IsHeader() bool
+	DataTypeIndicators() map[adifield.Field]aditype.DataTypeIndicator
+	Err() error
+}
+
+// recordWriter is the common interface of Writer, ADXWriter and NDJSONWriter.
+type recordWriter interface {
+	WriteHeader(r Record) error
+	Write(r Record) error
+	Flush() error
 }
 
 // NewDocument returns an empty Document.
@@ -24,29 +59,62 @@ func NewDocument() *Document {
 	}
 }
 
-// ReadFrom reads an ADI document from r, appending its records to this Document.
+// ReadFrom reads an ADI, ADX or NDJSON document from r, appending its records to this Document.
+// The format is detected from the leading bytes of r and stored in Format.
+// The returned count is the number of bytes consumed, excluding any that were read ahead of the point where reading stopped.
 // Implements io.ReaderFrom.
 func (d *Document) ReadFrom(r io.Reader) (int64, error) {
 	cr := &countingReader{r: r}
-	s := NewScanner(cr)
+	br := bufio.NewReader(cr)
+	d.Format = detectFormat(br)
+
+	var s recordScanner
+	var adi *Scanner
+	switch d.Format {
+	case FormatADX:
+		s = NewADXScanner(br)
+	case FormatNDJSON:
+		s = ndjsonRecordScanner{NewNDJSONScanner(br)}
+	default:
+		adi = NewScanner(br)
+		s = adi
+	}
 	for s.Scan() {
+		for field, dataType := range s.DataTypeIndicators() {
+			if d.DataTypes == nil {
+				d.DataTypes = make(map[adifield.Field]aditype.DataTypeIndicator)
+			}
+			d.DataTypes[field] = dataType
+		}
 		if s.IsHeader() {
 			if d.Header != nil || len(d.Records) > 0 {
-				return cr.n, ErrUnexpectedHeader
+				return cr.n - int64(br.Buffered()), ErrUnexpectedHeader
 			}
 			d.Header = s.Record()
+			if adi != nil {
+				d.Preamble = adi.Preamble()
+			}
 		} else {
 			d.Records = append(d.Records, s.Record())
 		}
 	}
-	return cr.n, s.Err()
+	return cr.n - int64(br.Buffered()), s.Err()
 }
 
-// WriteTo writes the document in ADI format to w.
+// WriteTo writes the document to w in the format selected by Format.
 // Implements io.WriterTo.
 func (d *Document) WriteTo(w io.Writer) (int64, error) {
 	cw := &countingWriter{w: w}
-	wr := NewWriter(cw)
+	var wr recordWriter
+	if d.Format == FormatADX {
+		wr = NewADXWriter(cw).SetDataTypeIndicators(d.DataTypes)
+	} else if d.Format == FormatNDJSON {
+		wr = NewNDJSONWriter(cw)
+	} else if d.Preamble != "" {
+		wr = NewWriterWithPreamble(cw, d.Preamble).SetDataTypeIndicators(d.DataTypes)
+	} else {
+		wr = NewWriter(cw).SetDataTypeIndicators(d.DataTypes)
+	}
 	if d.Header != nil {
 		if err := wr.WriteHeader(d.Header); err != nil {
 			return cw.n, err
@@ -57,14 +125,31 @@ func (d *Document) WriteTo(w io.Writer) (int64, error) {
 			return cw.n, err
 		}
 	}
-	// Writer adds no buffering of its own; flush the original writer directly.
-	if err := flushWriter(w); err != nil {
+	// Writers add no buffering of their own, so flushing cw only completes the document;
+	// flush the original writer directly.
+	if err := wr.Flush(); err != nil {
+		return cw.n, err
+	}
+	if err := flush.Writer(w); err != nil {
 		return cw.n, err
 	}
 	return cw.n, nil
 }
 
-// String returns the document serialized as an ADI string.
+// Filter returns an iterator over the QSO records for which pred returns true, in order.
+//
+//	for r := range d.Filter(func(r adif.Record) bool { return r[adifield.BAND] == "20m" }) { ... }
+func (d *Document) Filter(pred func(Record) bool) iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		for _, r := range d.Records {
+			if pred(r) && !yield(r) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the document serialized in the format selected by Format.
 // Returns an empty string when the document has no header and no records.
 // Implements fmt.Stringer.
 func (d *Document) String() string {
@@ -3,10 +3,12 @@ package adif
 import (
 	"encoding/json"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
 )
 
 var errMockFlush = errors.New("mock flush error")
@@ -37,8 +39,8 @@ func TestDocument_ReadFrom_QSOsOnly(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if n == 0 {
-		t.Error("expected non-zero bytes read")
+	if n != int64(len(adi)) {
+		t.Errorf("got %d bytes read, want %d", n, len(adi))
 	}
 	if d.Header != nil {
 		t.Error("expected nil header")
@@ -78,10 +80,14 @@ func TestDocument_ReadFrom_WithHeader(t *testing.T) {
 func TestDocument_ReadFrom_MultipleHeaders(t *testing.T) {
 	adi := "<PROGRAMID:7>MonoLog<EOH><PROGRAMID:5>Other<EOH><CALL:5>W9PVA<EOR>"
 	d := NewDocument()
-	_, err := d.ReadFrom(strings.NewReader(adi))
+	n, err := d.ReadFrom(strings.NewReader(adi))
 	if !errors.Is(err, ErrUnexpectedHeader) {
 		t.Fatalf("got %v, want ErrDocumentMultipleHeaders", err)
 	}
+	// The count stops at the second header, not at the end of what was read ahead.
+	if want := int64(strings.LastIndex(adi, "<EOH>") + len("<EOH>")); n != want {
+		t.Errorf("got %d bytes read, want %d", n, want)
+	}
 }
 
 func TestDocument_WriteTo_RoundTrip(t *testing.T) {
@@ -206,3 +212,170 @@ func TestDocument_JSON_RoundTrip(t *testing.T) {
 		t.Errorf("Records[1] CALL: got %q, want %q", d2.Records[1][adifield.CALL], "K9CTS")
 	}
 }
+
+func TestDocument_ReadFrom_ADX(t *testing.T) {
+	d := NewDocument()
+	if _, err := d.ReadFrom(strings.NewReader(adxTestDocument)); err != nil {
+		t.Fatal(err)
+	}
+	if d.Format != FormatADX {
+		t.Errorf("Format: got %v, want FormatADX", d.Format)
+	}
+	if d.Header[adifield.PROGRAMID] != "MonoLog" {
+		t.Errorf("header PROGRAMID: got %q, want %q", d.Header[adifield.PROGRAMID], "MonoLog")
+	}
+	if len(d.Records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(d.Records))
+	}
+	if d.Records[1][adifield.CALL] != "ON4UN" {
+		t.Errorf("Records[1] CALL: got %q, want %q", d.Records[1][adifield.CALL], "ON4UN")
+	}
+}
+
+func TestDocument_ReadFrom_ADXMalformed(t *testing.T) {
+	d := NewDocument()
+	_, err := d.ReadFrom(strings.NewReader("<ADX><RECORDS><RECORD>"))
+	if !errors.Is(err, ErrMalformedADX) {
+		t.Fatalf("got %v, want ErrMalformedADX", err)
+	}
+}
+
+func TestDocument_WriteTo_ADXRoundTrip(t *testing.T) {
+	d := NewDocument()
+	d.Format = FormatADX
+	d.Header = Record{adifield.PROGRAMID: "MonoLog"}
+	d.Records = append(d.Records, Record{adifield.CALL: "W9PVA", adifield.NAME_INTL: "Jürgen"})
+
+	s := d.String()
+	if !strings.HasPrefix(s, "<?xml") {
+		t.Fatalf("expected ADX output, got %q", s)
+	}
+
+	d2 := NewDocument()
+	if _, err := d2.ReadFrom(strings.NewReader(s)); err != nil {
+		t.Fatal(err)
+	}
+	if d2.Format != FormatADX {
+		t.Errorf("Format: got %v, want FormatADX", d2.Format)
+	}
+	assertRecordEqual(t, d2.Header, d.Header)
+	if len(d2.Records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(d2.Records))
+	}
+	assertRecordEqual(t, d2.Records[0], d.Records[0])
+}
+
+func TestDocument_WriteTo_ADXCloseError(t *testing.T) {
+	// An empty ADX document still writes its closing tags, which fail here.
+	d := NewDocument()
+	d.Format = FormatADX
+	_, err := d.WriteTo(&mockAlwaysErrorWriter{})
+	if err != errMockWrite {
+		t.Fatalf("got %v, want errMockWrite", err)
+	}
+}
+
+func TestDocument_DataTypes_RoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatADI, FormatADX} {
+		f, err := testFileFS.Open("testdata/ADIF_316_test_QSOs_2025_08_27.adi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := NewDocument()
+		_, err = d.ReadFrom(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.DataTypes[adifield.USERDEF1]; got != aditype.DATATYPEINDICATOR_BOOLEAN {
+			t.Fatalf("USERDEF1: got %q, want %q", got, aditype.DATATYPEINDICATOR_BOOLEAN)
+		}
+
+		d.Format = format
+		d2 := NewDocument()
+		if _, err := d2.ReadFrom(strings.NewReader(d.String())); err != nil {
+			t.Fatal(err)
+		}
+
+		// ADX has no TYPE attribute for ordinary fields such as CALL.
+		want := d.DataTypes
+		if format == FormatADX {
+			want = make(map[adifield.Field]aditype.DataTypeIndicator)
+			for field, dataType := range d.DataTypes {
+				if _, ok := userdefFieldID(field); ok || strings.HasPrefix(string(field), adifield.APP_) {
+					want[field] = dataType
+				}
+			}
+		}
+		if len(d2.DataTypes) != len(want) {
+			t.Errorf("format %d: got %d indicators, want %d", format, len(d2.DataTypes), len(want))
+		}
+		for field, dataType := range want {
+			if d2.DataTypes[field] != dataType {
+				t.Errorf("format %d: %s: got %q, want %q", format, field, d2.DataTypes[field], dataType)
+			}
+		}
+	}
+}
+
+func TestDocument_Preamble_RoundTrip(t *testing.T) {
+	f, err := testFileFS.Open("testdata/lotwreport.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	d := NewDocument()
+	if _, err := d.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(d.Preamble, "ARRL Logbook of the World Status Report\n") {
+		t.Fatalf("unexpected preamble %q", d.Preamble)
+	}
+
+	out := d.String()
+	if !strings.HasPrefix(out, d.Preamble+"<") {
+		t.Errorf("output does not begin with the original preamble: %q", out[:min(len(out), 200)])
+	}
+
+	d2 := NewDocument()
+	if _, err := d2.ReadFrom(strings.NewReader(out)); err != nil {
+		t.Fatal(err)
+	}
+	if d2.Preamble != d.Preamble {
+		t.Errorf("preamble after round trip: got %q, want %q", d2.Preamble, d.Preamble)
+	}
+}
+
+func TestDocument_Preamble_Default(t *testing.T) {
+	d := NewDocument()
+	d.Header = Record{adifield.PROGRAMID: "MonoLog"}
+	if out := d.String(); !strings.HasPrefix(out, adiHeaderPreamble) {
+		t.Errorf("expected default preamble, got %q", out)
+	}
+}
+
+func TestDocument_Filter(t *testing.T) {
+	d := NewDocument()
+	d.Records = append(d.Records,
+		Record{adifield.CALL: "K9CTS", adifield.BAND: "20m"},
+		Record{adifield.CALL: "W9PVA", adifield.BAND: "40m"},
+		Record{adifield.CALL: "N9XYZ", adifield.BAND: "20M"},
+	)
+	on20m := func(r Record) bool { return strings.EqualFold(r[adifield.BAND], "20m") }
+
+	var calls []string
+	for r := range d.Filter(on20m) {
+		calls = append(calls, r[adifield.CALL])
+	}
+	if want := []string{"K9CTS", "N9XYZ"}; !slices.Equal(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+
+	for r := range d.Filter(on20m) {
+		if r[adifield.CALL] != "K9CTS" {
+			t.Errorf("got %v", r)
+		}
+		break
+	}
+}
@@ -0,0 +1,109 @@
+package adif
+
+import (
+	"bytes"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Encoding identifies the character encoding of ADI input.
+type Encoding int
+
+const (
+	// EncodingUTF8 passes values through unchanged. It is the default, and also suits plain ASCII input.
+	EncodingUTF8 Encoding = iota
+
+	// EncodingWindows1252 decodes values from Windows-1252, the default code page of Western European Windows loggers.
+	EncodingWindows1252
+
+	// EncodingISO88591 decodes values from ISO-8859-1 (Latin-1).
+	EncodingISO88591
+)
+
+// utf8BOM is the UTF-8 encoding of the byte order mark U+FEFF.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// charset maps the bytes 0x80 through 0xFF of a single-byte encoding to Unicode.
+// Bytes below 0x80 are ASCII in every supported encoding.
+type charset [128]rune
+
+var iso88591, windows1252 charset
+
+// windows1252High holds the characters of Windows-1252 bytes 0x80 through 0x9F.
+// The five undefined bytes map to the C1 controls of the same value, as ISO-8859-1 does.
+var windows1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func init() {
+	for i := range iso88591 {
+		iso88591[i] = rune(0x80 + i)
+	}
+	windows1252 = iso88591
+	copy(windows1252[:], windows1252High[:])
+}
+
+// charsets holds the charset of each Encoding, or nil for EncodingUTF8.
+var charsets = [...]*charset{
+	EncodingUTF8:        nil,
+	EncodingWindows1252: &windows1252,
+	EncodingISO88591:    &iso88591,
+}
+
+// SetEncoding declares the character encoding of the input and returns the Scanner for chaining.
+// Values and the preamble are converted to UTF-8 as they are read, while data lengths continue to count
+// bytes of the input as written, which for Windows-1252 and ISO-8859-1 is one byte per character.
+// Unknown encodings are treated as EncodingUTF8.
+//
+// Input that starts with a UTF-8 byte order mark is read as UTF-8 whatever the declared encoding,
+// and the byte order mark is skipped rather than becoming part of the preamble.
+func (s *Scanner) SetEncoding(enc Encoding) *Scanner {
+	s.charset = nil
+	if enc >= 0 && int(enc) < len(charsets) {
+		s.charset = charsets[enc]
+	}
+	return s
+}
+
+// skipBOM skips a UTF-8 byte order mark at the read position, after which the input is read as UTF-8.
+func (s *Scanner) skipBOM() {
+	if bom, _ := s.r.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		s.advance(bom)
+		_, _ = s.r.Discard(len(bom)) // cannot fail after a successful Peek
+		s.charset = nil
+	}
+}
+
+// decodeString returns s converted from the Scanner's charset to UTF-8.
+// s is returned unchanged when the input is UTF-8 or s is ASCII.
+func (s *Scanner) decodeString(v string) string {
+	if s.charset == nil || isASCII(v) {
+		return v
+	}
+	return string(s.charset.appendUTF8(make([]byte, 0, len(v)*2), v))
+}
+
+// decodeVolatile returns v converted from the Scanner's charset to UTF-8, using the reusable decoded buffer.
+// v is returned unchanged when the input is UTF-8 or v is ASCII.
+//
+// IMPORTANT: The returned slice is VOLATILE and will be invalidated by the next call. Callers must not retain it.
+func (s *Scanner) decodeVolatile(v []byte) []byte {
+	if s.charset == nil || len(v) == 0 || isASCII(unsafe.String(&v[0], len(v))) {
+		return v
+	}
+	s.decoded = s.charset.appendUTF8(s.decoded[:0], unsafe.String(&v[0], len(v)))
+	return s.decoded
+}
+
+// appendUTF8 appends the UTF-8 encoding of src, which is encoded in c, to dst and returns the extended slice.
+func (c *charset) appendUTF8(dst []byte, src string) []byte {
+	for i := 0; i < len(src); i++ {
+		if b := src[i]; b < utf8.RuneSelf {
+			dst = append(dst, b)
+		} else {
+			dst = utf8.AppendRune(dst, c[b-utf8.RuneSelf])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,90 @@
+package adif
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestScannerSetEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		enc      Encoding
+		adi      string
+		preamble string
+		want     Record
+	}{
+		{"UTF-8", EncodingUTF8, "Caf\xc3\xa9\n<EOH><NAME:5>Jos\xc3\xa9<EOR>", "Café\n", Record{adifield.NAME: "José"}},
+		{"Windows-1252", EncodingWindows1252, "Caf\xe9\n<EOH><NAME:4>Jos\xe9<QTH:9>\x80 M\xfcnchen<CALL:5>K9CTS<EOR>", "Café\n", Record{adifield.NAME: "José", adifield.QTH: "€ München", adifield.CALL: "K9CTS"}},
+		{"ISO-8859-1", EncodingISO88591, "Caf\xe9\n<EOH><NAME:4>Jos\xe9<QTH:9>\x80 M\xfcnchen<EOR>", "Café\n", Record{adifield.NAME: "José", adifield.QTH: "\u0080 München"}},
+		{"Unknown", Encoding(99), "<NAME:4>Jos\xe9<EOR>", "", Record{adifield.NAME: "Jos\xe9"}},
+		{"BOM overrides declared encoding", EncodingWindows1252, "\xef\xbb\xbfCaf\xc3\xa9\n<EOH><NAME:5>Jos\xc3\xa9<EOR>", "Café\n", Record{adifield.NAME: "José"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(tt.adi)).SetEncoding(tt.enc)
+			var got Record
+			for s.Scan() {
+				if !s.IsHeader() {
+					got = s.Record()
+				}
+			}
+			if s.Err() != nil {
+				t.Fatal(s.Err())
+			}
+			if s.Preamble() != tt.preamble {
+				t.Errorf("Preamble: got %q, want %q", s.Preamble(), tt.preamble)
+			}
+			assertRecordEqual(t, got, tt.want)
+
+			var v recordVisitor
+			if err := NewScanner(strings.NewReader(tt.adi)).SetEncoding(tt.enc).Visit(&v); err != nil {
+				t.Fatal(err)
+			}
+			assertRecordEqual(t, v.records[0], tt.want)
+		})
+	}
+}
+
+func TestScannerBOM(t *testing.T) {
+	data, err := testFileFS.ReadFile("testdata/Log4OM.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, utf8BOM) {
+		t.Fatal("expected Log4OM.adi to start with a byte order mark")
+	}
+	s := NewScanner(bytes.NewReader(data))
+	if !s.Scan() || !s.IsHeader() {
+		t.Fatalf("expected header; Err=%v", s.Err())
+	}
+	if !strings.HasPrefix(s.Preamble(), "#") {
+		t.Errorf("got preamble %.10q, want it to start after the byte order mark", s.Preamble())
+	}
+
+	// Without a preamble, the record after the byte order mark is a QSO record whose position counts the skipped bytes.
+	s = NewScanner(strings.NewReader("\xef\xbb\xbf<CALL:5>K9CTS<EOR><CALL:X>"))
+	if !s.Scan() || s.IsHeader() || s.Preamble() != "" {
+		t.Fatalf("got header %v, preamble %q, Err=%v", s.IsHeader(), s.Preamble(), s.Err())
+	}
+	s.Scan()
+	if pe, ok := s.Err().(*ParseError); !ok || pe.Offset != 21 {
+		t.Errorf("got %v, want a *ParseError at offset 21", s.Err())
+	}
+}
+
+func TestCharsets(t *testing.T) {
+	for _, c := range []*charset{&iso88591, &windows1252} {
+		for b, r := range c {
+			if !utf8.ValidRune(r) || r < utf8.RuneSelf {
+				t.Errorf("byte %#x: got %U", b+utf8.RuneSelf, r)
+			}
+		}
+	}
+	if windows1252[0] != '€' || windows1252[0x9F-0x80] != 'Ÿ' || windows1252[0xE9-0x80] != 'é' || iso88591[0] != 0x80 {
+		t.Error("unexpected charset contents")
+	}
+}
@@ -0,0 +1,180 @@
+package adif
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/farmergreg/adif/v5/internal/digits"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/antpath"
+	"github.com/farmergreg/spec/v6/enum/arrlsection"
+	"github.com/farmergreg/spec/v6/enum/award"
+	"github.com/farmergreg/spec/v6/enum/awardsponsor"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/contest"
+	"github.com/farmergreg/spec/v6/enum/continent"
+	"github.com/farmergreg/spec/v6/enum/credit"
+	"github.com/farmergreg/spec/v6/enum/dxccentitycode"
+	"github.com/farmergreg/spec/v6/enum/eqslag"
+	"github.com/farmergreg/spec/v6/enum/mode"
+	"github.com/farmergreg/spec/v6/enum/morsekeytype"
+	"github.com/farmergreg/spec/v6/enum/propagationmode"
+	"github.com/farmergreg/spec/v6/enum/qslmedium"
+	"github.com/farmergreg/spec/v6/enum/qslrcvd"
+	"github.com/farmergreg/spec/v6/enum/qslsent"
+	"github.com/farmergreg/spec/v6/enum/qslvia"
+	"github.com/farmergreg/spec/v6/enum/qsocomplete"
+	"github.com/farmergreg/spec/v6/enum/qsodownloadstatus"
+	"github.com/farmergreg/spec/v6/enum/qsouploadstatus"
+	"github.com/farmergreg/spec/v6/enum/submode"
+)
+
+// enumCheck reports whether value is a member of an enumeration and, if so, whether that member is import-only.
+// Members are matched case-insensitively.
+type enumCheck func(value string) (ok, importOnly bool)
+
+// fieldEnumerations maps fields to the enumeration their values must be drawn from.
+// Fields whose enumeration depends on another field, such as STATE and CNTY on DXCC, are not listed.
+var fieldEnumerations = map[adifield.Field]enumCheck{
+	adifield.ANT_PATH:                   checkAntPath,
+	adifield.ARRL_SECT:                  checkARRLSection,
+	adifield.MY_ARRL_SECT:               checkARRLSection,
+	adifield.AWARD_GRANTED:              isSponsoredAwardList,
+	adifield.AWARD_SUBMITTED:            isSponsoredAwardList,
+	adifield.BAND:                       checkBand,
+	adifield.BAND_RX:                    checkBand,
+	adifield.CONT:                       checkContinent,
+	adifield.CONTEST_ID:                 checkContest,
+	adifield.CREDIT_GRANTED:             isCreditList,
+	adifield.CREDIT_SUBMITTED:           isCreditList,
+	adifield.DXCC:                       isDXCCEntityCode,
+	adifield.MY_DXCC:                    isDXCCEntityCode,
+	adifield.EQSL_AG:                    checkEQSLAG,
+	adifield.MODE:                       checkMode,
+	adifield.SUBMODE:                    checkSubMode,
+	adifield.MORSE_KEY_TYPE:             checkMorseKeyType,
+	adifield.MY_MORSE_KEY_TYPE:          checkMorseKeyType,
+	adifield.PROP_MODE:                  checkPropagationMode,
+	adifield.QSL_RCVD:                   checkQSLRcvd,
+	adifield.DCL_QSL_RCVD:               checkQSLRcvd,
+	adifield.EQSL_QSL_RCVD:              checkQSLRcvd,
+	adifield.LOTW_QSL_RCVD:              checkQSLRcvd,
+	adifield.QSL_SENT:                   checkQSLSent,
+	adifield.DCL_QSL_SENT:               checkQSLSent,
+	adifield.EQSL_QSL_SENT:              checkQSLSent,
+	adifield.LOTW_QSL_SENT:              checkQSLSent,
+	adifield.QSL_RCVD_VIA:               checkQSLVia,
+	adifield.QSL_SENT_VIA:               checkQSLVia,
+	adifield.QSO_COMPLETE:               checkQSOComplete,
+	adifield.QRZCOM_QSO_DOWNLOAD_STATUS: checkQSODownloadStatus,
+	adifield.CLUBLOG_QSO_UPLOAD_STATUS:  checkQSOUploadStatus,
+	adifield.HAMLOGEU_QSO_UPLOAD_STATUS: checkQSOUploadStatus,
+	adifield.HAMQTH_QSO_UPLOAD_STATUS:   checkQSOUploadStatus,
+	adifield.HRDLOG_QSO_UPLOAD_STATUS:   checkQSOUploadStatus,
+	adifield.QRZCOM_QSO_UPLOAD_STATUS:   checkQSOUploadStatus,
+}
+
+// enumCheck values for the spec enum packages whose members are looked up by value.
+var (
+	checkAntPath           = enumOf(antpath.Lookup, func(s antpath.Spec) bool { return bool(s.IsImportOnly) })
+	checkARRLSection       = enumOf(arrlsection.Lookup, func(s arrlsection.Spec) bool { return bool(s.IsImportOnly) })
+	checkBand              = enumOf(band.Lookup, func(s band.Spec) bool { return bool(s.IsImportOnly) })
+	checkContest           = enumOf(contest.Lookup, func(s contest.Spec) bool { return bool(s.IsImportOnly) })
+	checkContinent         = enumOf(continent.Lookup, func(s continent.Spec) bool { return bool(s.IsImportOnly) })
+	checkEQSLAG            = enumOf(eqslag.Lookup, func(s eqslag.Spec) bool { return bool(s.IsImportOnly) })
+	checkMode              = enumOf(mode.Lookup, func(s mode.Spec) bool { return bool(s.IsImportOnly) })
+	checkMorseKeyType      = enumOf(morsekeytype.Lookup, func(s morsekeytype.Spec) bool { return bool(s.IsImportOnly) })
+	checkPropagationMode   = enumOf(propagationmode.Lookup, func(s propagationmode.Spec) bool { return bool(s.IsImportOnly) })
+	checkQSLRcvd           = enumOf(qslrcvd.Lookup, func(s qslrcvd.Spec) bool { return bool(s.IsImportOnly) })
+	checkQSLSent           = enumOf(qslsent.Lookup, func(s qslsent.Spec) bool { return bool(s.IsImportOnly) })
+	checkQSLVia            = enumOf(qslvia.Lookup, func(s qslvia.Spec) bool { return bool(s.IsImportOnly) })
+	checkQSOComplete       = enumOf(qsocomplete.Lookup, func(s qsocomplete.Spec) bool { return bool(s.IsImportOnly) })
+	checkQSODownloadStatus = enumOf(qsodownloadstatus.Lookup, func(s qsodownloadstatus.Spec) bool { return bool(s.IsImportOnly) })
+	checkQSOUploadStatus   = enumOf(qsouploadstatus.Lookup, func(s qsouploadstatus.Spec) bool { return bool(s.IsImportOnly) })
+	checkSubMode           = enumOf(submode.Lookup, func(s submode.Spec) bool { return bool(s.IsImportOnly) })
+)
+
+// enumTypes maps the spec enum types that Marshal and Unmarshal recognize to the enumeration their values are drawn from.
+var enumTypes = map[reflect.Type]enumCheck{
+	reflect.TypeFor[antpath.AntPath]():                     checkAntPath,
+	reflect.TypeFor[arrlsection.ARRLSection]():             checkARRLSection,
+	reflect.TypeFor[band.Band]():                           checkBand,
+	reflect.TypeFor[contest.Contest]():                     checkContest,
+	reflect.TypeFor[continent.Continent]():                 checkContinent,
+	reflect.TypeFor[dxccentitycode.DXCCEntityCode]():       isDXCCEntityCode,
+	reflect.TypeFor[eqslag.EQSLAG]():                       checkEQSLAG,
+	reflect.TypeFor[mode.Mode]():                           checkMode,
+	reflect.TypeFor[morsekeytype.MorseKeyType]():           checkMorseKeyType,
+	reflect.TypeFor[propagationmode.PropagationMode]():     checkPropagationMode,
+	reflect.TypeFor[qslrcvd.QSLRcvd]():                     checkQSLRcvd,
+	reflect.TypeFor[qslsent.QSLSent]():                     checkQSLSent,
+	reflect.TypeFor[qslvia.QSLVia]():                       checkQSLVia,
+	reflect.TypeFor[qsocomplete.QSOComplete]():             checkQSOComplete,
+	reflect.TypeFor[qsodownloadstatus.QSODownloadStatus](): checkQSODownloadStatus,
+	reflect.TypeFor[qsouploadstatus.QSOUploadStatus]():     checkQSOUploadStatus,
+	reflect.TypeFor[submode.SubMode]():                     checkSubMode,
+}
+
+// enumOf returns an enumCheck backed by the Lookup function of a spec enum package.
+// All spec enumeration members are uppercase, so values are uppercased before lookup.
+func enumOf[K ~string, S any](lookup func(K) (S, bool), isImportOnly func(S) bool) enumCheck {
+	return func(value string) (bool, bool) {
+		spec, ok := lookup(K(strings.ToUpper(value)))
+		return ok, ok && isImportOnly(spec)
+	}
+}
+
+// isDXCCEntityCode checks a DXCC entity code such as 291. The code 0 means "not within a DXCC entity".
+func isDXCCEntityCode(value string) (bool, bool) {
+	if len(value) > 4 || !digits.Only(value) {
+		return false, false
+	}
+	spec, ok := dxccentitycode.Lookup(dxccentitycode.DXCCEntityCode(atoi(value)))
+	return ok, ok && bool(spec.IsImportOnly)
+}
+
+// isCreditList checks a comma-delimited list of credits, each optionally followed by a colon
+// and an ampersand-delimited list of QSL media (e.g. IOTA,WAS:LOTW&CARD,DXCC:CARD).
+// Members of the Award enumeration are accepted in place of credits but are import-only.
+func isCreditList(value string) (bool, bool) {
+	importOnly := false
+	for item := range strings.SplitSeq(value, ",") {
+		name, media, hasMedia := strings.Cut(item, ":")
+		if spec, ok := credit.Lookup(credit.New(name)); ok {
+			importOnly = importOnly || bool(spec.IsImportOnly)
+		} else if _, ok := award.Lookup(award.New(name)); ok && !hasMedia {
+			importOnly = true
+		} else {
+			return false, false
+		}
+		if !hasMedia {
+			continue
+		}
+		for medium := range strings.SplitSeq(media, "&") {
+			spec, ok := qslmedium.Lookup(qslmedium.New(medium))
+			if !ok {
+				return false, false
+			}
+			importOnly = importOnly || bool(spec.IsImportOnly)
+		}
+	}
+	return true, importOnly
+}
+
+// isSponsoredAwardList checks a comma-delimited list of sponsored awards, each of which
+// must begin with a sponsor prefix such as ADIF_ or ARRL_.
+func isSponsoredAwardList(value string) (bool, bool) {
+	importOnly := false
+	for item := range strings.SplitSeq(value, ",") {
+		i := strings.IndexByte(item, '_')
+		if i < 0 || i == len(item)-1 {
+			return false, false
+		}
+		spec, ok := awardsponsor.Lookup(awardsponsor.New(item[:i+1]))
+		if !ok {
+			return false, false
+		}
+		importOnly = importOnly || bool(spec.IsImportOnly)
+	}
+	return true, importOnly
+}
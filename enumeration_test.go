@@ -0,0 +1,116 @@
+package adif
+
+import (
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestValidator_Enumerations(t *testing.T) {
+	tests := []struct {
+		field    adifield.Field
+		value    string
+		rule     Rule // empty when the value is valid
+		severity Severity
+	}{
+		{adifield.ANT_PATH, "g", "", 0},
+		{adifield.EQSL_AG, "Y", "", 0},
+		{adifield.BAND, "20m", "", 0},
+		{adifield.BAND, "20M", "", 0},
+		{adifield.BAND, "20", RuleEnumeration, SeverityError},
+		{adifield.BAND_RX, "70cm", "", 0},
+		{adifield.MODE, "ssb", "", 0},
+		{adifield.MODE, "USB", RuleEnumeration, SeverityError},
+		{adifield.MODE, "C4FM", RuleImportOnly, SeverityWarning},
+		{adifield.SUBMODE, "usb", "", 0},
+		{adifield.SUBMODE, "NOTASUBMODE", RuleEnumeration, SeverityWarning},
+		{adifield.CONTEST_ID, "ARRL-FIELD-DAY", "", 0},
+		{adifield.CONTEST_ID, "WIQP", RuleEnumeration, SeverityWarning},
+		{adifield.CONTEST_ID, "RAC", RuleImportOnly, SeverityWarning},
+		{adifield.ARRL_SECT, "wi", "", 0},
+		{adifield.MY_ARRL_SECT, "XX", RuleEnumeration, SeverityError},
+		{adifield.CONT, "NA", "", 0},
+		{adifield.CONT, "XX", RuleEnumeration, SeverityError},
+		{adifield.QSL_RCVD, "y", "", 0},
+		{adifield.LOTW_QSL_RCVD, "V", RuleImportOnly, SeverityWarning},
+		{adifield.QSL_SENT, "Q", "", 0},
+		{adifield.QSL_SENT, "X", RuleEnumeration, SeverityError},
+		{adifield.QSL_SENT_VIA, "B", "", 0},
+		{adifield.PROP_MODE, "SAT", "", 0},
+		{adifield.PROP_MODE, "MOON", RuleEnumeration, SeverityError},
+		{adifield.DXCC, "291", "", 0},
+		{adifield.MY_DXCC, "0", "", 0},
+		{adifield.DXCC, "9999", RuleEnumeration, SeverityError},
+		{adifield.DXCC, "12345", RuleEnumeration, SeverityError},
+		{adifield.DXCC, "US", RuleEnumeration, SeverityError},
+		{adifield.QSO_COMPLETE, "NIL", "", 0},
+		{adifield.CLUBLOG_QSO_UPLOAD_STATUS, "M", "", 0},
+		{adifield.QRZCOM_QSO_DOWNLOAD_STATUS, "Y", "", 0},
+		{adifield.MORSE_KEY_TYPE, "DP", "", 0},
+		{adifield.CREDIT_GRANTED, "IOTA,WAS:LOTW&CARD,DXCC:card", "", 0},
+		{adifield.CREDIT_SUBMITTED, "CQWAZ_CW", RuleImportOnly, SeverityWarning},
+		{adifield.CREDIT_SUBMITTED, "CQWAZ_CW:CARD", RuleEnumeration, SeverityError},
+		{adifield.CREDIT_GRANTED, "WAS:PIGEON", RuleEnumeration, SeverityError},
+		{adifield.CREDIT_GRANTED, "NOTACREDIT", RuleEnumeration, SeverityError},
+		{adifield.AWARD_GRANTED, "ADIF_CENTURY_BASIC,ARRL_DXCC", "", 0},
+		{adifield.AWARD_GRANTED, "ADIF", RuleEnumeration, SeverityError},
+		{adifield.AWARD_GRANTED, "ADIF_", RuleEnumeration, SeverityError},
+		{adifield.AWARD_SUBMITTED, "NOTASPONSOR_AWARD", RuleEnumeration, SeverityError},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		issues := v.Validate(Record{tt.field: tt.value})
+		if tt.rule == "" {
+			if issues != nil {
+				t.Errorf("%s=%q: unexpected issues %v", tt.field, tt.value, issues)
+			}
+			continue
+		}
+		if len(issues) != 1 {
+			t.Errorf("%s=%q: got %v, want one %s issue", tt.field, tt.value, issues, tt.rule)
+			continue
+		}
+		if issues[0].Rule != tt.rule || issues[0].Severity != tt.severity {
+			t.Errorf("%s=%q: got %s %s, want %s %s", tt.field, tt.value, issues[0].Severity, issues[0].Rule, tt.severity, tt.rule)
+		}
+	}
+}
+
+func TestValidator_SetRuleEnabled(t *testing.T) {
+	r := Record{
+		adifield.MODE:     "USB",
+		adifield.QSL_RCVD: "V",
+		adifield.GUEST_OP: "W9PVA",
+		adifield.CQZ:      "41",
+		adifield.QSO_DATE: "2023",
+	}
+	tests := []struct {
+		rule Rule
+		want int
+	}{
+		{RuleEnumeration, 3}, // also skips the import-only QSL_RCVD value
+		{RuleImportOnly, 3},
+		{RuleRange, 4},
+		{RuleDataType, 4},
+	}
+	if got := len(NewValidator().Validate(r)); got != 5 {
+		t.Fatalf("all rules: got %d issues, want 5", got)
+	}
+	for _, tt := range tests {
+		issues := NewValidator().SetRuleEnabled(tt.rule, false).Validate(r)
+		if len(issues) != tt.want {
+			t.Errorf("%s disabled: got %d issues (%v), want %d", tt.rule, len(issues), issues, tt.want)
+		}
+		for _, issue := range issues {
+			if issue.Rule == tt.rule {
+				t.Errorf("%s disabled: got %v", tt.rule, issue)
+			}
+		}
+	}
+
+	v := NewValidator().SetRuleEnabled(RuleRange, false).SetRuleEnabled(RuleRange, true)
+	if got := len(v.Validate(r)); got != 5 {
+		t.Errorf("re-enabled: got %d issues, want 5", got)
+	}
+}
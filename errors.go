@@ -1,18 +1,134 @@
 package adif
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
 
 var (
 	// ErrMalformedADI is returned when the ADI formatted data does not conform to the ADIF specification.
 	ErrMalformedADI = errors.New("malformed ADI")
 
-	// ErrTooManyUniqueFields is returned when the number of unique field names exceeds the internal limit.
+	// ErrMalformedADX is returned when the ADX formatted data is not well-formed XML or does not conform to the ADIF specification.
+	ErrMalformedADX = errors.New("malformed ADX")
+
+	// ErrMalformedNDJSON is returned when a line of NDJSON formatted data is not a JSON object of string values.
+	ErrMalformedNDJSON = errors.New("malformed NDJSON")
+
+	// ErrTooManyUniqueFields is returned when the number of unique field names exceeds ScannerOptions.MaxUniqueFields.
 	// This prevents denial of service attacks from malformed ADI files with unlimited unique field names.
 	ErrTooManyUniqueFields = errors.New("too many unique field names")
 
+	// ErrFieldTooLong is returned when a field's data length exceeds ScannerOptions.MaxFieldLength.
+	ErrFieldTooLong = errors.New("field value too long")
+
+	// ErrTooManyFields is returned when a record has more fields than ScannerOptions.MaxFieldsPerRecord.
+	ErrTooManyFields = errors.New("too many fields in record")
+
+	// ErrRecordTooLarge is returned when a record spans more input bytes than ScannerOptions.MaxRecordBytes.
+	ErrRecordTooLarge = errors.New("record too large")
+
+	// ErrTooManyRecords is returned when the input holds more QSO records than ScannerOptions.MaxRecords.
+	ErrTooManyRecords = errors.New("too many records")
+
 	// ErrUnexpectedHeader is returned when a header is encountered after QSO records have already been read, or after a header has already been processed.
 	ErrUnexpectedHeader = errors.New("unexpected header")
 
 	// ErrHeaderAlreadyWritten is returned when attempting to write more than one header record.
 	ErrHeaderAlreadyWritten = errors.New("header already written")
+
+	// ErrWriteAfterFlush is returned by ADXWriter when a record is written after Flush has closed the ADX document.
+	ErrWriteAfterFlush = errors.New("write after flush")
+
+	// ErrNonASCII is returned by a Writer using ASCIIPolicyReject when an ASCII-only field contains non-ASCII characters.
+	ErrNonASCII = errors.New("non-ASCII value in ASCII-only field")
+
+	// ErrFieldNotFound is returned by the typed Record accessors when the requested field is missing or empty.
+	ErrFieldNotFound = errors.New("field not found")
+
+	// ErrInvalidValue is returned by the typed Record accessors when a field value does not conform to its ADIF data type.
+	ErrInvalidValue = errors.New("invalid field value")
+
+	// ErrUnsupportedType is returned by Marshal, Unmarshal and Scanner.Decode when a value or struct field cannot be mapped to a record.
+	ErrUnsupportedType = errors.New("unsupported type")
+
+	// ErrNoADIFiles is returned by NewScannerAuto and Open when a zip archive contains no .adi files.
+	ErrNoADIFiles = errors.New("no .adi files in zip archive")
+
+	// ErrMalformedIndex is returned by Index.ReadFrom when its input is not an index written by Index.WriteTo.
+	ErrMalformedIndex = errors.New("malformed index")
 )
+
+// ParseError describes where and why a Scanner, ADXScanner or NDJSONScanner failed to parse its input.
+// It wraps ErrMalformedADI, ErrMalformedADX, ErrMalformedNDJSON, ErrTooManyUniqueFields or the error of an exceeded ScannerOptions limit,
+// so errors.Is continues to work.
+type ParseError struct {
+	// Offset is the zero-based byte offset of the '<' that starts the failing data specifier.
+	// For ADX input, it is the decoder offset at which the problem was detected,
+	// and for NDJSON input, the offset of the offending byte of the line, or of the line when it is not known.
+	Offset int64
+
+	// Line is the one-based line number of Offset.
+	Line int
+
+	// Column is the one-based byte column of Offset within Line.
+	Column int
+
+	// Record is the zero-based index of the record being read, counting the header record if present.
+	Record int
+
+	// Field is the field being read, or empty when the field name could not be determined.
+	Field adifield.Field
+
+	// Err is the underlying sentinel error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("adif: line %d, column %d (offset %d), record %d: %v", e.Line, e.Column, e.Offset, e.Record, e.Err)
+	}
+	return fmt.Sprintf("adif: line %d, column %d (offset %d), record %d, field %s: %v", e.Line, e.Column, e.Offset, e.Record, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// moved returns a copy of e positioned within an enclosing input,
+// in which the input that e describes begins at offset, on line at column, after records records.
+func (e *ParseError) moved(offset int64, line, column, records int) *ParseError {
+	m := *e
+	m.Offset += offset
+	m.Record += records
+	if e.Line == 1 {
+		m.Column += column - 1
+	}
+	m.Line += line - 1
+	return &m
+}
+
+// CSVError describes a CSV value that CSVScanner could not store in its field.
+type CSVError struct {
+	// Row is the one-based row number of the value, counting the header row as row 1.
+	Row int
+
+	// Column is the one-based column number of the value.
+	Column int
+
+	// Field is the field that the column is mapped to.
+	Field adifield.Field
+
+	// Err is the error returned by CSVColumn.Transform, or an error wrapping ErrInvalidValue when the value does not match CSVColumn.Layout.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("adif: CSV row %d, column %d, field %s: %v", e.Row, e.Column, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CSVError) Unwrap() error { return e.Err }
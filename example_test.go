@@ -2,7 +2,9 @@ package adif_test
 
 import (
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	adif "github.com/farmergreg/adif/v5"
 	"github.com/farmergreg/spec/v6/adifield"
@@ -93,3 +95,137 @@ func ExampleDocument() {
 	// Call: W9PVA, Band: 20m
 	// Call: K9CTS, Band: 40m
 }
+
+// ExampleValidator demonstrates checking a QSO record against the ADIF field specifications.
+func ExampleValidator() {
+	r := adif.Record{
+		adifield.CALL:     "K9CTS",
+		adifield.QSO_DATE: "20230230",
+		adifield.TIME_ON:  "1200",
+		adifield.CQZ:      "41",
+	}
+
+	v := adif.NewValidator()
+	for _, issue := range v.Validate(r) {
+		fmt.Println(issue)
+	}
+
+	// Output:
+	// error: CQZ: 41 is outside the range 1 to 40
+	// error: QSO_DATE: "20230230" is not a valid DATE
+}
+
+// ExampleScanner_Decode demonstrates streaming QSO records straight into a struct.
+func ExampleScanner_Decode() {
+	adiData := `
+<PROGRAMID:4>Test
+<EOH>
+<CALL:5>K9CTS<QSO_DATE:8>20230101<TIME_ON:4>1200<FREQ:6>14.074<MODE:3>ft8<APP_TEST_ID:2>42<eor>
+`
+
+	type QSO struct {
+		Call  string                    `adif:"CALL"`
+		Time  time.Time                 `adif:"QSO_DATE,time=TIME_ON"`
+		Freq  float64                   `adif:"FREQ"`
+		Mode  mode.Mode                 `adif:"MODE"`
+		Extra map[adifield.Field]string `adif:",remain"`
+	}
+
+	s := adif.NewScanner(strings.NewReader(adiData))
+	for {
+		var q QSO
+		err := s.Decode(&q)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		fmt.Println(q.Call, q.Time.Format(time.RFC3339), q.Freq, q.Mode, q.Extra)
+	}
+
+	// Output:
+	// K9CTS 2023-01-01T12:00:00Z 14.074 FT8 map[APP_TEST_ID:42]
+}
+
+// ExampleScanner_QSOs demonstrates ranging over QSO records without handling the header.
+func ExampleScanner_QSOs() {
+	adiData := `
+<PROGRAMID:4>Test
+<EOH>
+<CALL:5>K9CTS<BAND:3>20m<eor>
+<CALL:5>W9PVA<BAND:3>40m<eor>
+<CALL:5>N9XYZ<BAND:3>20m<eor>
+`
+
+	s := adif.NewScanner(strings.NewReader(adiData))
+	for r := range s.QSOs() {
+		if r[adifield.BAND] == "20m" {
+			fmt.Println(r[adifield.CALL])
+		}
+	}
+	if err := s.Err(); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// K9CTS
+	// N9XYZ
+}
+
+// bandCounter is a Visitor that counts QSOs per band without building records.
+type bandCounter map[string]int
+
+func (c bandCounter) OnField(field adifield.Field, value []byte) error {
+	if field == adifield.BAND {
+		c[strings.ToUpper(string(value))]++
+	}
+	return nil
+}
+
+func (c bandCounter) OnEOR() error { return nil }
+func (c bandCounter) OnEOH() error { return nil }
+
+// ExampleScanner_Visit demonstrates gathering statistics without allocating a Record per QSO.
+func ExampleScanner_Visit() {
+	adiData := `
+<PROGRAMID:4>Test
+<EOH>
+<CALL:5>K9CTS<BAND:3>20m<eor>
+<CALL:5>W9PVA<BAND:3>40m<eor>
+<CALL:5>N9XYZ<BAND:3>20M<eor>
+`
+
+	counts := bandCounter{}
+	if err := adif.NewScanner(strings.NewReader(adiData)).Visit(counts); err != nil {
+		panic(err)
+	}
+	fmt.Println(counts["20M"], counts["40M"])
+
+	// Output:
+	// 2 1
+}
+
+// ExampleCSVScanner_SetMapping demonstrates importing a spreadsheet export with its own column names.
+func ExampleCSVScanner_SetMapping() {
+	csvData := `Date,UTC,Callsign,Band,Mode
+25/05/2024,14:02,k9cts,20m,SSB
+26/05/2024,01:15,w9pva,40m,CW
+`
+	upper := func(v string) (string, error) { return strings.ToUpper(v), nil }
+	s := adif.NewCSVScanner(strings.NewReader(csvData)).SetMapping(map[string]adif.CSVColumn{
+		"Date":     {Field: adifield.QSO_DATE, Layout: "02/01/2006"},
+		"UTC":      {Field: adifield.TIME_ON, Layout: "15:04"},
+		"Callsign": {Field: adifield.CALL, Transform: upper},
+	})
+	for s.Scan() {
+		r := s.Record()
+		fmt.Println(r[adifield.QSO_DATE], r[adifield.TIME_ON], r[adifield.CALL], r[adifield.BAND], r[adifield.MODE])
+	}
+	if err := s.Err(); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// 20240525 140200 K9CTS 20m SSB
+	// 20240526 011500 W9PVA 40m CW
+}
@@ -0,0 +1,45 @@
+package adif
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// Format identifies the serialization used by an ADIF document.
+type Format int
+
+const (
+	// FormatADI is the tag based ADI format. It is the default.
+	FormatADI Format = iota
+
+	// FormatADX is the XML based ADX format.
+	FormatADX
+
+	// FormatNDJSON is newline delimited JSON, as written by NDJSONWriter.
+	FormatNDJSON
+)
+
+// formatSniffLen is the number of leading bytes examined by detectFormat.
+const formatSniffLen = 512
+
+// detectFormat peeks at the start of br to determine whether it holds ADI, ADX or NDJSON data.
+// ADX documents begin with an XML declaration or the ADX root element, and NDJSON documents with a JSON object,
+// optionally preceded by a UTF-8 byte order mark and whitespace. Anything else is treated as ADI.
+// No input is consumed.
+func detectFormat(br *bufio.Reader) Format {
+	head, _ := br.Peek(formatSniffLen)
+	head = bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+	if hasPrefixFold(head, "<?xml") || hasPrefixFold(head, "<"+adxElementRoot) {
+		return FormatADX
+	}
+	if len(head) > 0 && head[0] == '{' {
+		return FormatNDJSON
+	}
+	return FormatADI
+}
+
+// hasPrefixFold reports whether b begins with prefix, ignoring ASCII case.
+func hasPrefixFold(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && bytes.EqualFold(b[:len(prefix)], []byte(prefix))
+}
@@ -0,0 +1,41 @@
+package adif
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Format
+	}{
+		{"Empty", "", FormatADI},
+		{"ADI record", "<CALL:5>K9CTS<EOR>", FormatADI},
+		{"ADI preamble", "Generated by MonoLog\n<EOH>", FormatADI},
+		{"ADI preamble mentioning XML", "<?xm", FormatADI},
+		{"XML declaration", `<?xml version="1.0"?><ADX/>`, FormatADX},
+		{"Upper case declaration", `<?XML version="1.0"?><ADX/>`, FormatADX},
+		{"ADX root", "<ADX></ADX>", FormatADX},
+		{"Lower case root", "<adx></adx>", FormatADX},
+		{"Leading whitespace", " \r\n\t<ADX></ADX>", FormatADX},
+		{"Byte order mark", "\xEF\xBB\xBF<?xml version=\"1.0\"?><ADX/>", FormatADX},
+		{"NDJSON", `{"CALL":"K9CTS"}`, FormatNDJSON},
+		{"NDJSON header", "\xEF\xBB\xBF\r\n{\"HEADER\":{}}", FormatNDJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReader(strings.NewReader(tt.data))
+			if got := detectFormat(br); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			// detectFormat must not consume input.
+			if n := br.Buffered(); n != len(tt.data) {
+				t.Errorf("buffered: got %d, want %d", n, len(tt.data))
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package adif
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// indexMagic identifies an Index sidecar and the version of its format.
+const indexMagic = "ADIFIDX\x01"
+
+// IndexEntry locates one QSO record within the input of an Index.
+type IndexEntry struct {
+	// Offset is the byte offset at which the record begins, directly after the preceding record or header.
+	Offset int64
+
+	// Length is the number of bytes from Offset through the end of the record's <EOR> tag.
+	Length int64
+
+	// Line is the one-based line number of Offset.
+	Line int
+
+	// Column is the one-based byte column of Offset within Line.
+	Column int
+}
+
+// Index locates the QSO records of an ADI file, so that single records can be read without loading the whole file.
+// It optionally holds the values of secondary key fields, such as CALL, QSO_DATE and BAND, for each record.
+// Build one with BuildIndex, and save it alongside the file with WriteTo to avoid rebuilding it with ReadFrom.
+//
+// An Index is safe for concurrent use once built or read.
+type Index struct {
+	// Size is the number of input bytes indexed.
+	// Compare it with the size of the file to detect a file that changed after the index was built.
+	Size int64
+
+	header  bool             // whether the input has a header record
+	keys    []adifield.Field // secondary key fields
+	entries []IndexEntry
+	values  []string           // len(keys) key values for each entry, in entry order
+	lookup  []map[string][]int // for each key, the entries holding each uppercased value, in entry order
+}
+
+// BuildIndex reads the ADI input r in one streaming pass and returns an Index of its QSO records,
+// holding the values of the given key fields for each record.
+// Only the key field values are retained; all other values are skipped in the input without being copied.
+// Fields that follow the last <EOR> tag are not indexed.
+//
+// Parse failures are reported as a *ParseError, as Scanner.Visit does.
+func BuildIndex(r io.Reader, keys ...adifield.Field) (*Index, error) {
+	x := &Index{}
+	b := &indexBuilder{x: x, s: NewScanner(r), slots: make(map[adifield.Field]int, len(keys))}
+	for _, key := range keys {
+		key = adifield.New(string(key))
+		if _, ok := b.slots[key]; !ok {
+			b.slots[key] = len(x.keys)
+			x.keys = append(x.keys, key)
+		}
+	}
+	b.current = make([]string, len(x.keys))
+	if len(x.keys) > 0 {
+		b.s.SetProjection(x.keys...)
+	}
+	b.mark()
+	if err := b.s.Visit(b); err != nil {
+		return nil, err
+	}
+	x.Size = b.s.offset
+	x.buildLookup()
+	return x, nil
+}
+
+// indexBuilder is the Visitor that builds an Index.
+type indexBuilder struct {
+	x       *Index
+	s       *Scanner
+	slots   map[adifield.Field]int // position of each key field in current
+	current []string               // key values of the record being read
+	start   IndexEntry             // position of the record being read
+}
+
+func (b *indexBuilder) OnField(field adifield.Field, value []byte) error {
+	if i, ok := b.slots[field]; ok {
+		b.current[i] = string(value)
+	}
+	return nil
+}
+
+func (b *indexBuilder) OnEOR() error {
+	e := b.start
+	e.Length = b.s.offset - e.Offset
+	b.x.entries = append(b.x.entries, e)
+	b.x.values = append(b.x.values, b.current...)
+	clear(b.current)
+	b.mark()
+	return nil
+}
+
+func (b *indexBuilder) OnEOH() error {
+	b.x.header = true
+	clear(b.current)
+	b.mark()
+	return nil
+}
+
+// mark records the read position as the start of the next record.
+func (b *indexBuilder) mark() {
+	b.start = IndexEntry{Offset: b.s.offset, Line: b.s.line, Column: int(b.s.offset-b.s.lineStart) + 1}
+}
+
+// Len returns the number of QSO records in the index.
+func (x *Index) Len() int { return len(x.entries) }
+
+// Keys returns the secondary key fields of the index. The returned slice must not be modified.
+func (x *Index) Keys() []adifield.Field { return x.keys }
+
+// Entry returns the location of QSO record i, which must be in the range [0, Len()).
+func (x *Index) Entry(i int) IndexEntry { return x.entries[i] }
+
+// Key returns the value of the key field in QSO record i, which must be in the range [0, Len()).
+// It returns an empty string when the record has no such field or field is not a key of the index.
+func (x *Index) Key(i int, field adifield.Field) string {
+	k := slices.Index(x.keys, adifield.New(string(field)))
+	if k < 0 {
+		return ""
+	}
+	return x.values[i*len(x.keys)+k]
+}
+
+// Lookup returns the numbers of the QSO records whose key field equals value, ignoring case, in input order.
+// It returns nil when no record matches or field is not a key of the index.
+// Records are found through a map of the key values, so the cost does not grow with the number of records.
+func (x *Index) Lookup(field adifield.Field, value string) []int {
+	k := slices.Index(x.keys, adifield.New(string(field)))
+	if k < 0 {
+		return nil
+	}
+	return slices.Clone(x.lookup[k][strings.ToUpper(value)])
+}
+
+// buildLookup builds the maps used by Lookup from the key values of the entries.
+func (x *Index) buildLookup() {
+	x.lookup = make([]map[string][]int, len(x.keys))
+	for k := range x.keys {
+		x.lookup[k] = make(map[string][]int)
+	}
+	for i := range x.entries {
+		for k := range x.keys {
+			value := strings.ToUpper(x.values[i*len(x.keys)+k])
+			x.lookup[k][value] = append(x.lookup[k][value], i)
+		}
+	}
+}
+
+// ReadRecordAt reads QSO record i, which must be in the range [0, Len()), from r, the input that was indexed.
+// Parse failures, such as those caused by a file that changed after the index was built,
+// are reported as a *ParseError positioned within the whole input.
+func (x *Index) ReadRecordAt(r io.ReaderAt, i int) (Record, error) {
+	e := x.entries[i]
+	s := NewScanner(io.NewSectionReader(r, e.Offset, e.Length))
+	if s.Scan() && !s.IsHeader() {
+		return s.Record(), nil
+	}
+	err := s.Err()
+	if err == nil || s.IsHeader() {
+		err = &ParseError{Line: 1, Column: 1, Err: ErrMalformedADI}
+	}
+	pe, ok := err.(*ParseError)
+	if !ok {
+		return nil, err
+	}
+	recordsBefore := i
+	if x.header {
+		recordsBefore++
+	}
+	return nil, pe.moved(e.Offset, e.Line, e.Column, recordsBefore)
+}
+
+// WriteTo writes the index to w in a compact binary format, e.g. to a sidecar file next to the indexed file.
+// Implements io.WriterTo.
+func (x *Index) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var scratch []byte
+	putUvarint := func(v uint64) {
+		scratch = binary.AppendUvarint(scratch[:0], v)
+		_, _ = bw.Write(scratch) // bufio.Writer errors are sticky and returned by Flush
+	}
+	putString := func(s string) {
+		putUvarint(uint64(len(s)))
+		_, _ = bw.WriteString(s)
+	}
+
+	_, _ = bw.WriteString(indexMagic)
+	putUvarint(uint64(x.Size))
+	if x.header {
+		putUvarint(1)
+	} else {
+		putUvarint(0)
+	}
+	putUvarint(uint64(len(x.keys)))
+	for _, key := range x.keys {
+		putString(string(key))
+	}
+	putUvarint(uint64(len(x.entries)))
+	var end int64
+	line := 1
+	for i, e := range x.entries {
+		// Records usually follow one another, so offsets and lines are stored as small deltas.
+		putUvarint(uint64(e.Offset - end))
+		putUvarint(uint64(e.Length))
+		putUvarint(uint64(e.Line - line))
+		putUvarint(uint64(e.Column))
+		end, line = e.Offset+e.Length, e.Line
+		for _, value := range x.values[i*len(x.keys) : (i+1)*len(x.keys)] {
+			putString(value)
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ReadFrom replaces the index with one read from r, which must hold the output of WriteTo.
+// It returns ErrMalformedIndex when r does not hold a valid index, in which case the index is unchanged.
+// Implements io.ReaderFrom.
+func (x *Index) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	n := int64(len(data))
+	if err != nil {
+		return n, err
+	}
+	d := indexDecoder{data: data}
+	if !strings.HasPrefix(string(data), indexMagic) {
+		return n, ErrMalformedIndex
+	}
+	d.data = d.data[len(indexMagic):]
+
+	var y Index
+	y.Size = int64(d.uvarint(1<<63 - 1))
+	y.header = d.uvarint(1) == 1
+	y.keys = make([]adifield.Field, d.uvarint(uint64(len(d.data))))
+	for k := range y.keys {
+		y.keys[k] = adifield.New(d.string())
+	}
+	// Each entry takes at least four bytes for its location and one for each key value.
+	count := d.uvarint(uint64(len(d.data) / (4 + len(y.keys))))
+	y.entries = make([]IndexEntry, 0, count)
+	y.values = make([]string, 0, count*uint64(len(y.keys)))
+	var end int64
+	line := 1
+	for range count {
+		var e IndexEntry
+		e.Offset = end + int64(d.uvarint(uint64(y.Size-end)))
+		e.Length = int64(d.uvarint(uint64(y.Size - e.Offset)))
+		e.Line = line + int(d.uvarint(uint64(y.Size)))
+		e.Column = int(d.uvarint(uint64(y.Size) + 1))
+		end, line = e.Offset+e.Length, e.Line
+		y.entries = append(y.entries, e)
+		for range y.keys {
+			y.values = append(y.values, d.string())
+		}
+	}
+	if d.bad || len(d.data) > 0 {
+		return n, ErrMalformedIndex
+	}
+	y.buildLookup()
+	*x = y
+	return n, nil
+}
+
+// indexDecoder reads the values written by Index.WriteTo from data.
+// After the first problem, bad is set and all further values are zero.
+type indexDecoder struct {
+	data []byte
+	bad  bool
+}
+
+// uvarint reads an unsigned integer no greater than limit.
+func (d *indexDecoder) uvarint(limit uint64) uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 || v > limit {
+		d.bad, d.data = true, nil
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// string reads a length-prefixed string.
+func (d *indexDecoder) string() string {
+	n := d.uvarint(uint64(len(d.data)))
+	if n > uint64(len(d.data)) {
+		d.bad, d.data = true, nil
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
@@ -0,0 +1,237 @@
+package adif
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestBuildIndex_TestFiles(t *testing.T) {
+	fs, err := testFileFS.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		t.Run(f.Name(), func(t *testing.T) {
+			data, err := testFileFS.ReadFile("testdata/" + f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := readSequential(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			x, err := BuildIndex(bytes.NewReader(data), adifield.CALL, "qso_date", adifield.BAND, adifield.CALL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x.Size != int64(len(data)) || x.Len() != len(want.Records) {
+				t.Fatalf("got Size %d, Len %d; want %d, %d", x.Size, x.Len(), len(data), len(want.Records))
+			}
+			if !slices.Equal(x.Keys(), []adifield.Field{adifield.CALL, adifield.QSO_DATE, adifield.BAND}) {
+				t.Errorf("Keys: got %v", x.Keys())
+			}
+			for i, r := range want.Records {
+				got, err := x.ReadRecordAt(bytes.NewReader(data), i)
+				if err != nil {
+					t.Fatalf("record %d: %v", i, err)
+				}
+				assertRecordEqual(t, got, r)
+				for _, key := range x.Keys() {
+					if x.Key(i, key) != r[key] {
+						t.Errorf("record %d: Key(%s) got %q, want %q", i, key, x.Key(i, key), r[key])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIndex_Entry(t *testing.T) {
+	adi := "preamble\n<PROGRAMID:4>Test<EOH>\n<CALL:5>K9CTS<EOR>\n<CALL:5>W9PVA\n<BAND:3>20m<EOR>\n"
+	x, err := BuildIndex(strings.NewReader(adi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IndexEntry{
+		{Offset: 31, Length: 19, Line: 2, Column: 23},
+		{Offset: 50, Length: 31, Line: 3, Column: 19},
+	}
+	if x.Len() != len(want) || x.Keys() != nil {
+		t.Fatalf("got Len %d, Keys %v", x.Len(), x.Keys())
+	}
+	for i := range want {
+		if x.Entry(i) != want[i] {
+			t.Errorf("Entry(%d): got %+v, want %+v", i, x.Entry(i), want[i])
+		}
+	}
+	if x.Key(0, adifield.CALL) != "" || x.Lookup(adifield.CALL, "K9CTS") != nil {
+		t.Error("expected no keys")
+	}
+}
+
+func TestIndex_Lookup(t *testing.T) {
+	adi := "<CALL:5>K9CTS<BAND:3>20m<EOR><CALL:5>W9PVA<BAND:3>40m<EOR><CALL:5>k9cts<BAND:3>40M<EOR><BAND:3>20m<EOR>"
+	x, err := BuildIndex(strings.NewReader(adi), adifield.CALL, adifield.BAND)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		field adifield.Field
+		value string
+		want  []int
+	}{
+		{adifield.CALL, "K9CTS", []int{0, 2}},
+		{"band", "40m", []int{1, 2}},
+		{adifield.CALL, "", []int{3}},
+		{adifield.CALL, "N0CALL", nil},
+		{adifield.MODE, "SSB", nil},
+	}
+	for _, tt := range tests {
+		if got := x.Lookup(tt.field, tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("Lookup(%s, %q): got %v, want %v", tt.field, tt.value, got, tt.want)
+		}
+	}
+
+	// The returned slice belongs to the caller.
+	x.Lookup(adifield.CALL, "K9CTS")[0] = 3
+	if got := x.Lookup(adifield.CALL, "K9CTS"); !slices.Equal(got, []int{0, 2}) {
+		t.Errorf("Lookup after modifying its result: got %v", got)
+	}
+}
+
+func TestBuildIndex_ParseError(t *testing.T) {
+	_, err := BuildIndex(strings.NewReader("<CALL:5>K9CTS<EOR>\n<CALL:X>W9PVA<EOR>"), adifield.CALL)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 2 || pe.Record != 1 {
+		t.Errorf("got %v, want a *ParseError on line 2", err)
+	}
+}
+
+func TestIndex_ReadRecordAt_Changed(t *testing.T) {
+	adi := "<PROGRAMID:4>Test<EOH>\n<CALL:5>K9CTS<EOR>\n<CALL:5>W9PVA<EOR>\n"
+	x, err := BuildIndex(strings.NewReader(adi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		changed string
+		offset  int64
+		line    int
+		column  int
+	}{
+		{"Bad length", "<PROGRAMID:4>Test<EOH>\n<CALL:5>K9CTS<EOR>\n<CALL:X>W9PVA<EOR>\n", 42, 3, 1},
+		{"No record", "<PROGRAMID:4>Test<EOH>\n<CALL:5>K9CTS<EOR>\n                   \n", 41, 2, 19},
+		{"Header", "<PROGRAMID:4>Test<EOH>\n<CALL:5>K9CTS<EOR>\n<PROGRAMID:5>W9PVA<EOH>", 41, 2, 19},
+	}
+	for _, tt := range tests {
+		_, err := x.ReadRecordAt(strings.NewReader(tt.changed), 1)
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrMalformedADI) {
+			t.Fatalf("%s: got %v, want a *ParseError", tt.name, err)
+		}
+		if pe.Offset != tt.offset || pe.Line != tt.line || pe.Column != tt.column || pe.Record != 2 {
+			t.Errorf("%s: got %+v", tt.name, pe)
+		}
+	}
+
+	r := &mockFailReaderAt{backingData: []byte(adi), failAt: 30}
+	if _, err := x.ReadRecordAt(r, 0); !errors.Is(err, errMockReadAt) {
+		t.Errorf("got %v, want errMockReadAt", err)
+	}
+}
+
+func TestIndex_WriteToReadFrom(t *testing.T) {
+	data, err := testFileFS.ReadFile("testdata/N3FJP-AClogAdif.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := BuildIndex(bytes.NewReader(data), adifield.CALL, adifield.QSO_DATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := x.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo: got %d, %v; wrote %d", n, err, buf.Len())
+	}
+
+	y := &Index{}
+	if n, err := y.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil || n != int64(buf.Len()) {
+		t.Fatalf("ReadFrom: got %d, %v", n, err)
+	}
+	if y.Size != x.Size || y.header != x.header || !slices.Equal(y.keys, x.keys) || !slices.Equal(y.entries, x.entries) || !slices.Equal(y.values, x.values) {
+		t.Error("index changed by WriteTo and ReadFrom")
+	}
+	call := strings.ToLower(x.Key(0, adifield.CALL))
+	if got, want := y.Lookup(adifield.CALL, call), x.Lookup(adifield.CALL, call); len(got) == 0 || !slices.Equal(got, want) {
+		t.Errorf("Lookup after ReadFrom: got %v, want %v", got, want)
+	}
+
+	// An index without a header or keys.
+	x, err = BuildIndex(strings.NewReader("<CALL:5>K9CTS<EOR>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if _, err := x.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := y.ReadFrom(&buf); err != nil || y.header || y.Len() != 1 || y.Entry(0) != x.Entry(0) {
+		t.Errorf("got %+v, %v", y, err)
+	}
+}
+
+func TestIndex_WriteTo_Error(t *testing.T) {
+	x, err := BuildIndex(strings.NewReader("<CALL:5>K9CTS<EOR>"), adifield.CALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.WriteTo(&mockFailWriter{}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestIndex_ReadFrom_Malformed(t *testing.T) {
+	x, err := BuildIndex(strings.NewReader("<PROGRAMID:4>Test<EOH><CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>"), adifield.CALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := x.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+
+	tests := map[string][]byte{
+		"Empty":             nil,
+		"Wrong magic":       append([]byte("ADIFIDX\x02"), good[len(indexMagic):]...),
+		"Truncated":         good[:len(good)-1],
+		"Trailing bytes":    append(slices.Clone(good), 0),
+		"Bad header flag":   slices.Concat([]byte(indexMagic), []byte{0, 2}),
+		"Varint overflow":   slices.Concat([]byte(indexMagic), bytes.Repeat([]byte{0xFF}, 11)),
+		"Offset past Size":  slices.Concat([]byte(indexMagic), []byte{10, 0, 0, 1, 11, 0, 0, 1}),
+		"Too many entries":  slices.Concat([]byte(indexMagic), []byte{10, 0, 0, 100}),
+		"String past input": slices.Concat([]byte(indexMagic), []byte{10, 0, 1, 50, 'C'}),
+	}
+	for name, data := range tests {
+		y := &Index{Size: 42}
+		if _, err := y.ReadFrom(bytes.NewReader(data)); err != ErrMalformedIndex {
+			t.Errorf("%s: got %v, want ErrMalformedIndex", name, err)
+		}
+		if y.Size != 42 {
+			t.Errorf("%s: index was modified", name)
+		}
+	}
+
+	r := &mockFailReader{backingData: good, maxBytes: 4}
+	if _, err := (&Index{}).ReadFrom(r); err == nil || err == ErrMalformedIndex {
+		t.Errorf("read failure: got %v", err)
+	}
+}
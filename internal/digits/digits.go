@@ -0,0 +1,15 @@
+// Package digits holds the ASCII digit check shared by the packages of this module.
+package digits
+
+// Only reports whether s is a non-empty sequence of ASCII digits.
+func Only(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
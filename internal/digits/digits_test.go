@@ -0,0 +1,12 @@
+package digits
+
+import "testing"
+
+func TestOnly(t *testing.T) {
+	tests := map[string]bool{"0": true, "0123456789": true, "": false, "-1": false, "1.5": false, "12a": false, "١": false}
+	for s, want := range tests {
+		if got := Only(s); got != want {
+			t.Errorf("Only(%q): got %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Package flush flushes the destinations of the writers in this module.
+package flush
+
+import "io"
+
+// Writer calls w.Flush() if w implements the Flush() error interface (e.g. bufio.Writer), otherwise it is a no-op.
+func Writer(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package flush
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var sb strings.Builder
+	bw := bufio.NewWriter(&sb)
+	_, _ = bw.WriteString("K9CTS")
+	if err := Writer(bw); err != nil || sb.String() != "K9CTS" {
+		t.Errorf("bufio.Writer: got %q, %v", sb.String(), err)
+	}
+	if err := Writer(&sb); err != nil {
+		t.Errorf("strings.Builder: got %v", err)
+	}
+}
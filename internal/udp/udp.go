@@ -0,0 +1,47 @@
+// Package udp holds the receive loop shared by the listeners for programs that broadcast QSOs over UDP.
+package udp
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// maxPacketSize is the largest UDP payload.
+const maxPacketSize = 65535
+
+// Serve reads packets from conn and calls handle with each one and the address it came from,
+// until ctx is done, reading fails, or handle returns an error.
+// packet is only valid until handle returns.
+//
+// Serve returns ctx.Err() when ctx is done, or the error of conn or handle.
+// It does not close conn, and leaves it without a read deadline.
+func Serve(ctx context.Context, conn net.PacketConn, handle func(addr net.Addr, packet []byte) error) error {
+	// A read deadline in the past unblocks ReadFrom when ctx is done.
+	// It is cleared on return, once the function setting it has finished, so that conn can be read again.
+	unblocked := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Unix(1, 0))
+		close(unblocked)
+	})
+	defer func() {
+		if !stop() {
+			<-unblocked
+		}
+		_ = conn.SetReadDeadline(time.Time{})
+	}()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+		if err := handle(addr, buf[:n]); err != nil {
+			return err
+		}
+	}
+}
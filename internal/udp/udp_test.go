@@ -0,0 +1,73 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback UDP: %v", err)
+	}
+	defer conn.Close()
+	sender, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	errStop := errors.New("stop")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	var packets []string
+	go func() {
+		done <- Serve(ctx, conn, func(addr net.Addr, packet []byte) error {
+			if addr.String() != sender.LocalAddr().String() {
+				t.Errorf("got address %v, want %v", addr, sender.LocalAddr())
+			}
+			packets = append(packets, string(packet))
+			if string(packet) == "stop" {
+				return errStop
+			}
+			return nil
+		})
+	}()
+	for _, p := range []string{"one", "two", "stop"} {
+		if _, err := sender.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-done; err != errStop {
+		t.Errorf("handler error: got %v, want errStop", err)
+	}
+	if len(packets) != 3 || packets[0] != "one" || packets[1] != "two" {
+		t.Errorf("got packets %q", packets)
+	}
+
+	// Once ctx is done, Serve returns its error rather than the read deadline error.
+	go func() {
+		done <- Serve(ctx, conn, func(net.Addr, []byte) error { return nil })
+	}()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("canceled: got %v, want context.Canceled", err)
+	}
+
+	// The socket can be read again once Serve has returned.
+	if _, err := sender.Write([]byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	if n, _, err := conn.ReadFrom(buf); err != nil || string(buf[:n]) != "after" {
+		t.Errorf("read after cancel: got %q, %v", buf[:n], err)
+	}
+
+	conn.Close()
+	if err := Serve(context.Background(), conn, nil); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("closed socket: got %v, want net.ErrClosed", err)
+	}
+}
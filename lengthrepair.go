@@ -0,0 +1,108 @@
+package adif
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// lengthRepairLookahead is the number of bytes beyond the declared value examined by SetLengthRepair,
+// enough to find the start of the next data specifier after a character-counted value.
+const lengthRepairLookahead = 64
+
+// adiWhitespace lists the characters commonly found between fields of an ADI file.
+const adiWhitespace = " \t\r\n"
+
+// SetLengthRepair enables or disables repair of data lengths that do not count the bytes of the value,
+// and returns the Scanner for chaining.
+//
+// Some loggers write the number of characters, rather than bytes, of a UTF-8 value such as NAME or QTH,
+// so that the declared length ends in the middle of the value; others write a byte count for a value
+// that was later stored in a single-byte encoding, so that the declared length runs into the next data specifier.
+// Either mistake corrupts the following fields. When repair is enabled, a length that splits a UTF-8 character
+// or leaves text before the next data specifier is re-counted in characters, and a length that overruns
+// into the next data specifier is cut short before it.
+// Lengths that end cleanly are used unchanged, so files written correctly read the same with repair enabled,
+// except for values that themselves contain text resembling a data specifier, such as "<CALL:5>".
+func (s *Scanner) SetLengthRepair(enabled bool) *Scanner {
+	s.repairLengths = enabled
+	return s
+}
+
+// repairLength returns the byte length of the field value at the read position, given its declared length.
+func (s *Scanner) repairLength(length int) int {
+	// A short window at EOF or an I/O error is handled by the read of the value that follows.
+	window, _ := s.r.Peek(min(s.r.Size(), length*utf8.UTFMax+lengthRepairLookahead))
+	return repairedLength(window, length)
+}
+
+// repairedLength returns the byte length of the value at the start of b, given its declared length.
+// b holds the value and the input that follows it, possibly cut short by the end of the input or the read buffer.
+func repairedLength(b []byte, length int) int {
+	if length > len(b) {
+		return length
+	}
+	// The declared length overran into the next data specifier.
+	if i := indexDataSpecifier(b, length); i >= 0 {
+		return len(bytes.TrimRight(b[:i], adiWhitespace))
+	}
+	if endsCleanly(b, length) {
+		return length
+	}
+	// The declared length counts characters rather than bytes.
+	if n := runeOffset(b, length); indexDataSpecifier(b, n) < 0 && endsCleanly(b, n) {
+		return n
+	}
+	return length
+}
+
+// indexDataSpecifier returns the index of the first data specifier in b that starts before limit, or -1.
+func indexDataSpecifier(b []byte, limit int) int {
+	for i := 0; i < limit; i++ {
+		if b[i] == '<' && isDataSpecifier(b[i+1:]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isDataSpecifier reports whether b, which follows a '<', starts with a field name and either '>' or a colon and a digit.
+func isDataSpecifier(b []byte) bool {
+	name := 0
+	for name < len(b) && (b[name] == '_' || '0' <= b[name] && b[name] <= '9' || 'A' <= b[name]&^0x20 && b[name]&^0x20 <= 'Z') {
+		name++
+	}
+	if name == 0 || name == len(b) {
+		return false
+	}
+	switch b[name] {
+	case '>':
+		return true
+	case ':':
+		return name+1 < len(b) && '0' <= b[name+1] && b[name+1] <= '9'
+	}
+	return false
+}
+
+// endsCleanly reports whether a value of n bytes at the start of b ends on a character boundary
+// and is followed by nothing but whitespace before the next '<' or the end of b.
+func endsCleanly(b []byte, n int) bool {
+	rest := b[n:]
+	if len(rest) > 0 && !utf8.RuneStart(rest[0]) {
+		return false
+	}
+	if i := bytes.IndexByte(rest, '<'); i >= 0 {
+		rest = rest[:i]
+	}
+	return len(bytes.TrimLeft(rest, adiWhitespace)) == 0
+}
+
+// runeOffset returns the byte offset in b after count UTF-8 characters, or len(b) when b holds fewer.
+// Invalid bytes count as one character each.
+func runeOffset(b []byte, count int) int {
+	n := 0
+	for ; count > 0 && n < len(b); count-- {
+		_, size := utf8.DecodeRune(b[n:])
+		n += size
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package adif
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestScannerSetLengthRepair(t *testing.T) {
+	tests := []struct {
+		name string
+		adi  string
+		want []Record
+	}{
+		{
+			"Character count splits a rune",
+			"<NAME:4>José<QTH:6>Zürich<CALL:5>K9CTS<EOR>",
+			[]Record{{adifield.NAME: "José", adifield.QTH: "Zürich", adifield.CALL: "K9CTS"}},
+		},
+		{
+			"Character count on a rune boundary",
+			"<NAME:13>Jürgen Müll€r\r\n<CALL:5>K9CTS<EOR>",
+			[]Record{{adifield.NAME: "Jürgen Müll€r", adifield.CALL: "K9CTS"}},
+		},
+		{
+			"Byte count for a single-byte value",
+			"<NAME:5>Jos\xe9<CALL:5>K9CTS<EOR>\n<NAME:7>M\xfcller<EOR>",
+			[]Record{{adifield.NAME: "Jos\xe9", adifield.CALL: "K9CTS"}, {adifield.NAME: "M\xfcller"}},
+		},
+		{
+			"Overrun into end of record",
+			"<NAME:9>Jos\xe9<EOR><CALL:5>K9CTS<EOR>",
+			[]Record{{adifield.NAME: "Jos\xe9"}, {adifield.CALL: "K9CTS"}},
+		},
+		{
+			"Correct lengths",
+			"<NAME:5>José <COMMENT:3>a<b  <NOTES:2>ab<EOR>\n<CALL:5>K9CTS junk<EOR>",
+			[]Record{{adifield.NAME: "José", adifield.COMMENT: "a<b", adifield.NOTES: "ab"}, {adifield.CALL: "K9CTS"}},
+		},
+		{
+			"Character count at end of input",
+			"<CALL:5>K9CTS<EOR><NAME:4>José<EOR>",
+			[]Record{{adifield.CALL: "K9CTS"}, {adifield.NAME: "José"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(tt.adi)).SetLengthRepair(true)
+			var got []Record
+			for s.Scan() {
+				got = append(got, s.Record())
+			}
+			if s.Err() != nil {
+				t.Fatal(s.Err())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d records %v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range tt.want {
+				assertRecordEqual(t, got[i], tt.want[i])
+			}
+
+			var v recordVisitor
+			if err := NewScanner(strings.NewReader(tt.adi)).SetLengthRepair(true).Visit(&v); err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.want {
+				assertRecordEqual(t, v.records[i], tt.want[i])
+			}
+		})
+	}
+}
+
+func TestScannerSetLengthRepair_Disabled(t *testing.T) {
+	s := NewScanner(strings.NewReader("<NAME:4>José<CALL:5>K9CTS<EOR>")).SetLengthRepair(true).SetLengthRepair(false)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	assertRecordEqual(t, s.Record(), Record{adifield.NAME: "Jos\xc3", adifield.CALL: "K9CTS"})
+}
+
+func TestScannerSetLengthRepair_LargeValue(t *testing.T) {
+	// Values larger than the read buffer cannot be examined and are read as declared.
+	big := strings.Repeat("é", 20)
+	adi := "<NOTES:20>" + big + "<CALL:5>K9CTS<EOR>"
+	s := NewScanner(bufio.NewReaderSize(strings.NewReader(adi), 16)).SetLengthRepair(true)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	if got := s.Record()[adifield.NOTES]; got != big[:20] {
+		t.Errorf("got %q, want %q", got, big[:20])
+	}
+}
+
+func TestIsDataSpecifier(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"EOR>", true},
+		{"eoh>", true},
+		{"CALL:5>", true},
+		{"APP_K9CTS_1:3:N>", true},
+		{"CALL:x>", false},
+		{"CALL:", false},
+		{"CALL", false},
+		{"", false},
+		{" CALL:5>", false},
+		{"CALL 5>", false},
+	}
+	for _, tt := range tests {
+		if got := isDataSpecifier([]byte(tt.in)); got != tt.want {
+			t.Errorf("isDataSpecifier(%q): got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package adif
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// structField describes how one struct field maps to a record field.
+type structField struct {
+	index     []int
+	field     adifield.Field
+	timeField adifield.Field // time of day for a time.Time, from the time= option
+	omitEmpty bool
+	enum      enumCheck // non-nil for spec enum types
+}
+
+// structCodec describes how a struct type maps to a Record.
+type structCodec struct {
+	fields []structField
+	mapped map[adifield.Field]bool
+	remain []int // index of the catch-all map, or nil
+}
+
+var (
+	structCodecs sync.Map // reflect.Type -> *structCodec
+
+	timeType  = reflect.TypeFor[time.Time]()
+	fieldType = reflect.TypeFor[adifield.Field]()
+)
+
+// Marshal returns the record encoded from v, which must be a struct or a non-nil pointer to a struct.
+//
+// Each exported struct field is stored in the record field named by its "adif" tag, or by its name in uppercase
+// when it has no tag. Fields of anonymous struct fields are promoted as in encoding/json. The tag "-" skips a field,
+// and the tag options are:
+//
+//   - omitempty: skip the field when it holds the zero value.
+//   - time=FIELD: for a time.Time, store the time of day in FIELD as well as the date in the tagged field,
+//     e.g. `adif:"QSO_DATE,time=TIME_ON"`. Without it, only the date is stored.
+//   - remain: on a map[adifield.Field]string or Record, hold every record field not mapped to another struct field,
+//     such as APP_ fields unknown to the program. Marshal copies its entries, with their keys normalized as
+//     adifield.New does, before encoding the other fields.
+//
+// Strings, booleans, integers, floats, time.Time and the spec enum types (e.g. band.Band, mode.Mode and
+// dxccentitycode.DXCCEntityCode) are supported, and are formatted as the Record setters format them.
+// Empty strings and zero times are never stored.
+// Marshal returns an error wrapping ErrUnsupportedType for any other type,
+// and an error wrapping ErrInvalidValue for a NaN or infinite float.
+func Marshal(v any) (Record, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: Marshal needs a struct or a non-nil pointer to a struct, got %T", ErrUnsupportedType, v)
+	}
+	codec, err := codecFor(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	r := make(Record, len(codec.fields))
+	if codec.remain != nil {
+		iter := rv.FieldByIndex(codec.remain).MapRange()
+		for iter.Next() {
+			r[adifield.New(iter.Key().String())] = iter.Value().String()
+		}
+	}
+	for i := range codec.fields {
+		f := &codec.fields[i]
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if err := f.encode(r, fv); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Unmarshal decodes r into v, which must be a non-nil pointer to a struct.
+// Struct fields map to record fields as described for Marshal.
+//
+// Struct fields whose record field is missing or empty are left unchanged.
+// A time.Time is set to midnight UTC when its time= field is missing or empty.
+// Values of spec enum types must be members of their enumeration, and are normalized to uppercase.
+// Unmarshal returns an error wrapping ErrUnsupportedType when v or one of its fields has an unsupported type,
+// and an error wrapping ErrInvalidValue for the first value that does not conform to its type.
+func Unmarshal(r Record, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: Unmarshal needs a non-nil pointer to a struct, got %T", ErrUnsupportedType, v)
+	}
+	rv = rv.Elem()
+	codec, err := codecFor(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for i := range codec.fields {
+		f := &codec.fields[i]
+		if r[f.field] == "" {
+			continue
+		}
+		if err := f.decode(r, rv.FieldByIndex(f.index)); err != nil {
+			return err
+		}
+	}
+	if codec.remain != nil {
+		m := rv.FieldByIndex(codec.remain)
+		for field, value := range r {
+			if value == "" || codec.mapped[field] {
+				continue
+			}
+			if m.IsNil() {
+				m.Set(reflect.MakeMap(m.Type()))
+			}
+			m.SetMapIndex(reflect.ValueOf(field), reflect.ValueOf(value).Convert(m.Type().Elem()))
+		}
+	}
+	return nil
+}
+
+// encode stores the value of fv in r.
+func (f *structField) encode(r Record, fv reflect.Value) error {
+	if fv.Type() == timeType {
+		t := fv.Interface().(time.Time)
+		switch {
+		case t.IsZero():
+		case f.timeField != "":
+			r.SetTime(f.field, f.timeField, t)
+		default:
+			r[f.field] = t.UTC().Format("20060102")
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		if s := fv.String(); s != "" {
+			r[f.field] = s
+		}
+	case reflect.Bool:
+		r.SetBool(f.field, fv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		r[f.field] = strconv.FormatInt(fv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		r[f.field] = strconv.FormatUint(fv.Uint(), 10)
+	default: // reflect.Float32, reflect.Float64
+		s := strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits())
+		if !isADIFNumber(s) {
+			return invalidValue(f.field, s, aditype.NUMBER)
+		}
+		r[f.field] = s
+	}
+	return nil
+}
+
+// decode parses the non-empty value of f.field in r into fv.
+func (f *structField) decode(r Record, fv reflect.Value) error {
+	value := r[f.field]
+	if fv.Type() == timeType {
+		if !isADIFDate(value) {
+			return invalidValue(f.field, value, aditype.DATE)
+		}
+		tod := r[f.timeField]
+		if tod != "" && !isADIFTime(tod) {
+			return invalidValue(f.timeField, tod, aditype.TIME)
+		}
+		fv.Set(reflect.ValueOf(adifTime(value, tod)))
+		return nil
+	}
+	if f.enum != nil {
+		if ok, _ := f.enum(value); !ok {
+			return invalidValue(f.field, value, f.field)
+		}
+		value = strings.ToUpper(value)
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		if !isADIFBoolean(value) {
+			return invalidValue(f.field, value, aditype.BOOLEAN)
+		}
+		fv.SetBool(value == "Y" || value == "y")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil || !isADIFInteger(value) {
+			return invalidValue(f.field, value, aditype.INTEGER)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return invalidValue(f.field, value, aditype.INTEGER)
+		}
+		fv.SetUint(n)
+	default: // reflect.Float32, reflect.Float64
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil || !isADIFNumber(value) {
+			return invalidValue(f.field, value, aditype.NUMBER)
+		}
+		fv.SetFloat(n)
+	}
+	return nil
+}
+
+// codecFor returns the cached structCodec for t, building it on first use.
+func codecFor(t reflect.Type) (*structCodec, error) {
+	if c, ok := structCodecs.Load(t); ok {
+		return c.(*structCodec), nil
+	}
+	c := &structCodec{mapped: make(map[adifield.Field]bool)}
+	if err := c.addFields(t, t, nil); err != nil {
+		return nil, err
+	}
+	structCodecs.Store(t, c)
+	return c, nil
+}
+
+// addFields adds the fields of t, found at index within root, to c.
+func (c *structCodec) addFields(root, t reflect.Type, index []int) error {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("adif")
+		if tag == "-" {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct && sf.Type != timeType {
+			if err := c.addFields(root, sf.Type, idx); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		f := structField{index: idx, field: adifield.New(strings.ToUpper(cmp.Or(name, sf.Name)))}
+		remain := false
+		for opt := range strings.SplitSeq(opts, ",") {
+			switch {
+			case opt == "omitempty":
+				f.omitEmpty = true
+			case opt == "remain":
+				remain = true
+			case strings.HasPrefix(opt, "time="):
+				f.timeField = adifield.New(strings.ToUpper(opt[len("time="):]))
+			}
+		}
+
+		switch {
+		case remain:
+			if c.remain != nil || sf.Type.Kind() != reflect.Map || sf.Type.Key() != fieldType || sf.Type.Elem().Kind() != reflect.String {
+				return fmt.Errorf("%w: %s.%s: remain needs a single map[adifield.Field]string, got %s", ErrUnsupportedType, root, sf.Name, sf.Type)
+			}
+			c.remain = idx
+			continue
+		case !isMarshalable(sf.Type):
+			return fmt.Errorf("%w: %s.%s has type %s", ErrUnsupportedType, root, sf.Name, sf.Type)
+		case f.timeField != "" && sf.Type != timeType:
+			return fmt.Errorf("%w: %s.%s: time= needs a time.Time, got %s", ErrUnsupportedType, root, sf.Name, sf.Type)
+		}
+		for _, field := range []adifield.Field{f.field, f.timeField} {
+			if field == "" {
+				continue
+			}
+			if c.mapped[field] {
+				return fmt.Errorf("%w: %s.%s maps %s, which another field already maps", ErrUnsupportedType, root, sf.Name, field)
+			}
+			c.mapped[field] = true
+		}
+		f.enum = enumTypes[sf.Type]
+		c.fields = append(c.fields, f)
+	}
+	return nil
+}
+
+// isMarshalable reports whether Marshal and Unmarshal support struct fields of type t.
+func isMarshalable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return t == timeType
+}
@@ -0,0 +1,264 @@
+package adif
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/dxccentitycode"
+	"github.com/farmergreg/spec/v6/enum/mode"
+	"github.com/farmergreg/spec/v6/enum/qslrcvd"
+)
+
+type testStation struct {
+	Operator string `adif:"OPERATOR,omitempty"`
+	MyGrid   string `adif:"MY_GRIDSQUARE"`
+	internal string
+}
+
+type testQSO struct {
+	testStation
+	Call     string
+	Time     time.Time                     `adif:"QSO_DATE,time=TIME_ON"`
+	Off      time.Time                     `adif:"qso_date_off,omitempty"`
+	Freq     float64                       `adif:"FREQ"`
+	RxPwr    float32                       `adif:"RX_PWR,omitempty"`
+	Band     band.Band                     `adif:"BAND"`
+	Mode     mode.Mode                     `adif:"MODE"`
+	DXCC     dxccentitycode.DXCCEntityCode `adif:"DXCC"`
+	QSLRcvd  qslrcvd.QSLRcvd               `adif:"QSL_RCVD,omitempty"`
+	CQZ      int8                          `adif:"CQZ,omitempty"`
+	K        uint16                        `adif:"K_INDEX,omitempty"`
+	SWL      bool                          `adif:"SWL"`
+	Notes    string                        `adif:"-"`
+	Extra    map[adifield.Field]string     `adif:",remain"`
+	unmapped int
+}
+
+func TestMarshal(t *testing.T) {
+	q := testQSO{
+		testStation: testStation{MyGrid: "EN34", internal: "x"},
+		Call:        "K9CTS",
+		Time:        time.Date(2024, 3, 5, 1, 2, 3, 0, time.FixedZone("CST", -6*3600)),
+		Freq:        14.074,
+		RxPwr:       0.1,
+		Band:        band.BAND_20M,
+		Mode:        mode.FT8,
+		DXCC:        291,
+		CQZ:         -4,
+		Notes:       "not stored",
+		Extra:       map[adifield.Field]string{"APP_TEST_ID": "7", adifield.CALL: "overwritten"},
+	}
+	want := Record{
+		adifield.MY_GRIDSQUARE: "EN34",
+		adifield.CALL:          "K9CTS",
+		adifield.QSO_DATE:      "20240305",
+		adifield.TIME_ON:       "070203",
+		adifield.FREQ:          "14.074",
+		adifield.RX_PWR:        "0.1",
+		adifield.BAND:          "20M",
+		adifield.MODE:          "FT8",
+		adifield.DXCC:          "291",
+		adifield.CQZ:           "-4",
+		adifield.SWL:           "N",
+		"APP_TEST_ID":          "7",
+	}
+	for _, v := range []any{q, &q} {
+		got, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertRecordEqual(t, got, want)
+	}
+
+	q.Time, q.Off, q.K = time.Time{}, time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC), 3
+	got, err := Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got[adifield.QSO_DATE]; ok {
+		t.Errorf("zero time: got QSO_DATE %q", got[adifield.QSO_DATE])
+	}
+	if got[adifield.QSO_DATE_OFF] != "20240306" || got[adifield.K_INDEX] != "3" {
+		t.Errorf("got QSO_DATE_OFF %q, K_INDEX %q", got[adifield.QSO_DATE_OFF], got[adifield.K_INDEX])
+	}
+}
+
+func TestMarshal_RemainNormalized(t *testing.T) {
+	v := struct {
+		Comment string
+		Extra   Record `adif:",remain"`
+	}{
+		Comment: "mapped",
+		Extra:   Record{"comment": "remain", "app_test_id": "7"},
+	}
+	r, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Record{adifield.COMMENT: "mapped", "APP_TEST_ID": "7"}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %v, want %v", r, want)
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		err  error
+	}{
+		{"nil", nil, ErrUnsupportedType},
+		{"nil pointer", (*testQSO)(nil), ErrUnsupportedType},
+		{"not a struct", "K9CTS", ErrUnsupportedType},
+		{"NaN", testQSO{Freq: math.NaN()}, ErrInvalidValue},
+		{"infinity", testQSO{Freq: math.Inf(1)}, ErrInvalidValue},
+		{"unsupported field", struct{ Call []byte }{}, ErrUnsupportedType},
+	}
+	for _, tt := range tests {
+		if r, err := Marshal(tt.v); r != nil || !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, r, err, tt.err)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	r := Record{
+		adifield.OPERATOR:      "W9PVA",
+		adifield.MY_GRIDSQUARE: "EN34",
+		adifield.CALL:          "K9CTS",
+		adifield.QSO_DATE:      "20240305",
+		adifield.TIME_ON:       "0702",
+		adifield.QSO_DATE_OFF:  "20240306",
+		adifield.FREQ:          "14.074",
+		adifield.RX_PWR:        ".5",
+		adifield.BAND:          "20m",
+		adifield.MODE:          "ft8",
+		adifield.DXCC:          "291",
+		adifield.QSL_RCVD:      "y",
+		adifield.CQZ:           "-04",
+		adifield.K_INDEX:       "3",
+		adifield.SWL:           "y",
+		adifield.COMMENT:       "",
+		"APP_TEST_ID":          "7",
+	}
+	q := testQSO{Notes: "kept", Extra: map[adifield.Field]string{"APP_OTHER": "kept"}}
+	if err := Unmarshal(r, &q); err != nil {
+		t.Fatal(err)
+	}
+	want := testQSO{
+		testStation: testStation{Operator: "W9PVA", MyGrid: "EN34"},
+		Call:        "K9CTS",
+		Time:        time.Date(2024, 3, 5, 7, 2, 0, 0, time.UTC),
+		Off:         time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC),
+		Freq:        14.074,
+		RxPwr:       0.5,
+		Band:        band.BAND_20M,
+		Mode:        mode.FT8,
+		DXCC:        291,
+		QSLRcvd:     qslrcvd.Y,
+		CQZ:         -4,
+		K:           3,
+		SWL:         true,
+		Notes:       "kept",
+		Extra:       map[adifield.Field]string{"APP_TEST_ID": "7", "APP_OTHER": "kept"},
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("got %+v\nwant %+v", q, want)
+	}
+
+	var rec struct {
+		Call  string
+		Extra Record `adif:",remain"`
+	}
+	if err := Unmarshal(r, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Call != "K9CTS" || len(rec.Extra) != len(r)-2 || rec.Extra[adifield.BAND] != "20m" {
+		t.Errorf("Record remain: got %+v", rec)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Record
+		err  error
+	}{
+		{"date", Record{adifield.QSO_DATE: "20230229"}, ErrInvalidValue},
+		{"time", Record{adifield.QSO_DATE: "20240101", adifield.TIME_ON: "2400"}, ErrInvalidValue},
+		{"float", Record{adifield.FREQ: "1e3"}, ErrInvalidValue},
+		{"float32 overflow", Record{adifield.RX_PWR: "1" + strings.Repeat("0", 40)}, ErrInvalidValue},
+		{"enum", Record{adifield.BAND: "20"}, ErrInvalidValue},
+		{"dxcc", Record{adifield.DXCC: "9999"}, ErrInvalidValue},
+		{"int sign", Record{adifield.CQZ: "+5"}, ErrInvalidValue},
+		{"int8 overflow", Record{adifield.CQZ: "128"}, ErrInvalidValue},
+		{"uint", Record{adifield.K_INDEX: "-1"}, ErrInvalidValue},
+		{"bool", Record{adifield.SWL: "yes"}, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		var q testQSO
+		if err := Unmarshal(tt.r, &q); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	var q testQSO
+	for _, v := range []any{nil, q, (*testQSO)(nil), new(string)} {
+		if err := Unmarshal(Record{}, v); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%T: got %v, want ErrUnsupportedType", v, err)
+		}
+	}
+
+	err := Unmarshal(Record{adifield.BAND: "20"}, &q)
+	if want := `invalid field value: BAND "20" is not a valid BAND`; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+func TestMarshal_UnsupportedStructs(t *testing.T) {
+	type badEmbedded struct{ Calls []string }
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"slice", &struct{ Call []string }{}},
+		{"pointer", &struct{ Call *string }{}},
+		{"remain type", &struct {
+			Extra map[string]string `adif:",remain"`
+		}{}},
+		{"second remain", &struct {
+			A Record `adif:",remain"`
+			B Record `adif:",remain"`
+		}{}},
+		{"time option", &struct {
+			Date string `adif:"QSO_DATE,time=TIME_ON"`
+		}{}},
+		{"duplicate", &struct {
+			Call string
+			C    string `adif:"call"`
+		}{}},
+		{"duplicate time", &struct {
+			On   time.Time `adif:"QSO_DATE,time=TIME_ON"`
+			Time string    `adif:"TIME_ON"`
+		}{}},
+		{"embedded type", &struct{ badEmbedded }{}},
+		{"embedded", &struct {
+			testStation
+			Op string `adif:"OPERATOR"`
+		}{}},
+	}
+	for _, tt := range tests {
+		if err := Unmarshal(Record{}, tt.v); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s Unmarshal: got %v, want ErrUnsupportedType", tt.name, err)
+		}
+		if _, err := Marshal(tt.v); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s Marshal: got %v, want ErrUnsupportedType", tt.name, err)
+		}
+	}
+}
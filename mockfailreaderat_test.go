@@ -0,0 +1,23 @@
+package adif
+
+import (
+	"errors"
+	"io"
+)
+
+var _ = (io.ReaderAt)(&mockFailReaderAt{})
+
+var errMockReadAt = errors.New("read failed: offset past failAt")
+
+// mockFailReaderAt serves backingData but fails any read that reaches failAt.
+type mockFailReaderAt struct {
+	backingData []byte
+	failAt      int64
+}
+
+func (fr *mockFailReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > fr.failAt {
+		return 0, errMockReadAt
+	}
+	return copy(p, fr.backingData[off:]), nil
+}
@@ -0,0 +1,40 @@
+package n1mm_test
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/farmergreg/adif/v5/n1mm"
+)
+
+// ExampleMirror demonstrates keeping a live copy of the N1MM Logger+ contest log and saving it when interrupted.
+func ExampleMirror() {
+	conn, err := net.ListenPacket("udp", ":12060")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	mirror := n1mm.NewMirror(nil)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = n1mm.NewListener(conn, func(addr net.Addr, e n1mm.Event) {
+		mirror.Apply(e)
+		log.Printf("%s %s: %d QSOs", e.Type, e.Record.String(), mirror.Len())
+	}).Serve(ctx)
+	if err != nil && err != context.Canceled {
+		log.Fatal(err)
+	}
+
+	f, err := os.Create("contest.adi")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := mirror.Document().WriteTo(f); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,40 @@
+package n1mm
+
+import (
+	"context"
+	"net"
+
+	"github.com/farmergreg/adif/v5/internal/udp"
+)
+
+// Listener receives contact broadcasts from a UDP socket and hands the Events they describe to a handler.
+// Obtain one with NewListener, then call Serve.
+type Listener struct {
+	conn    net.PacketConn
+	handler func(addr net.Addr, e Event)
+}
+
+// NewListener returns a Listener that reads packets from conn and calls handler with each Event and the address it came from.
+// handler is called from the goroutine running Serve; pass Mirror.Apply through it to keep a live copy of the log.
+// A nil handler discards every Event.
+//
+//	conn, err := net.ListenPacket("udp", ":12060")
+func NewListener(conn net.PacketConn, handler func(addr net.Addr, e Event)) *Listener {
+	if handler == nil {
+		handler = func(net.Addr, Event) {}
+	}
+	return &Listener{conn: conn, handler: handler}
+}
+
+// Serve reads and handles packets until ctx is done or reading from the socket fails.
+// Packets that Parse rejects, such as the <RadioInfo> and <spot> broadcasts of N1MM Logger+, are skipped.
+//
+// Serve returns ctx.Err() when ctx is done, or the error of the socket. It does not close the socket.
+func (l *Listener) Serve(ctx context.Context) error {
+	return udp.Serve(ctx, l.conn, func(addr net.Addr, packet []byte) error {
+		if e, err := Parse(packet); err == nil {
+			l.handler(addr, e)
+		}
+		return nil
+	})
+}
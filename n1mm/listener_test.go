@@ -0,0 +1,84 @@
+package n1mm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve starts a Listener on a loopback socket that reports events on the returned channel,
+// and returns the address to send to and a function that stops it and returns the error of Serve.
+func serve(t *testing.T) (*net.UDPConn, <-chan Event, func() error) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback UDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	sender, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sender.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	seen := make(chan Event, 16)
+	l := NewListener(conn, func(addr net.Addr, e Event) { seen <- e })
+	go func() { done <- l.Serve(ctx) }()
+	return sender, seen, func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func TestListener(t *testing.T) {
+	sender, seen, stop := serve(t)
+	m := NewMirror(nil)
+	packets := [][]byte{
+		[]byte("<RadioInfo><app>N1MM</app></RadioInfo>"),
+		[]byte("not N1MM"),
+		packet("contactinfo"),
+		packet("contactinfo", "f9ffac4fcd3e479ca86e137df1338531", "second", "dl1abc", "g4xyz"),
+		packet("contactreplace", "dl1abc", "dl2abc"),
+		packet("contactdelete", "f9ffac4fcd3e479ca86e137df1338531", "second"),
+	}
+	for _, p := range packets {
+		if _, err := sender.Write(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var types []EventType
+	timeout := time.After(5 * time.Second)
+	for len(types) < 4 {
+		select {
+		case e := <-seen:
+			types = append(types, e.Type)
+			m.Apply(e)
+		case <-timeout:
+			t.Fatalf("timed out with events %v", types)
+		}
+	}
+	if err := stop(); err != context.Canceled {
+		t.Errorf("Serve: got %v, want context.Canceled", err)
+	}
+	if types[0] != Add || types[1] != Add || types[2] != Replace || types[3] != Delete {
+		t.Errorf("got events %v", types)
+	}
+	if got := calls(m.Document()); len(got) != 1 || got[0] != "DL2ABC" {
+		t.Errorf("mirror: got %v", got)
+	}
+}
+
+func TestListener_Closed(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback UDP: %v", err)
+	}
+	conn.Close()
+	if err := NewListener(conn, nil).Serve(context.Background()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("closed socket: got %v, want net.ErrClosed", err)
+	}
+}
@@ -0,0 +1,79 @@
+package n1mm
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/farmergreg/adif/v5"
+)
+
+// Mirror keeps an adif.Document in step with the contest log of N1MM Logger+ by applying Events to it.
+// Records are matched by the FieldID they hold. It is safe for concurrent use.
+type Mirror struct {
+	mu   sync.Mutex
+	doc  *adif.Document
+	byID map[string]int // index into doc.Records
+}
+
+// NewMirror returns a Mirror that applies events to d, such as a Document read from an earlier export of the log.
+// Records of d that hold a FieldID are replaced and deleted by events with that ID.
+// When d is nil, a new Document is used. d must not be used directly while the Mirror is in use.
+func NewMirror(d *adif.Document) *Mirror {
+	if d == nil {
+		d = adif.NewDocument()
+	}
+	m := &Mirror{doc: d, byID: make(map[string]int, len(d.Records))}
+	for i, r := range d.Records {
+		if id := r[FieldID]; id != "" {
+			m.byID[id] = i
+		}
+	}
+	return m
+}
+
+// Apply applies e to the Document.
+// An Add or Replace event replaces the record with the same ID, keeping its position, or appends the record when there is none;
+// N1MM Logger+ sends both again when QSOs are logged on other computers of a multi-operator network.
+// A Delete event removes the record with the ID, if any.
+func (m *Mirror) Apply(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, found := m.byID[e.ID]
+	switch e.Type {
+	case Add, Replace:
+		if found {
+			m.doc.Records[i] = e.Record
+			return
+		}
+		m.byID[e.ID] = len(m.doc.Records)
+		m.doc.Records = append(m.doc.Records, e.Record)
+	case Delete:
+		if !found {
+			return
+		}
+		delete(m.byID, e.ID)
+		m.doc.Records = slices.Delete(m.doc.Records, i, i+1)
+		for id, j := range m.byID {
+			if j > i {
+				m.byID[id] = j - 1
+			}
+		}
+	}
+}
+
+// Document returns a copy of the Document as of the last applied event.
+// The records are shared with the Mirror, which replaces rather than modifies them, and must not be modified.
+func (m *Mirror) Document() *adif.Document {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := *m.doc
+	d.Records = slices.Clone(m.doc.Records)
+	return &d
+}
+
+// Len returns the number of records in the Document.
+func (m *Mirror) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.doc.Records)
+}
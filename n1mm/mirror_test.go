@@ -0,0 +1,87 @@
+package n1mm
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// event returns an event of type t for the QSO with id and call.
+func event(t EventType, id, call string) Event {
+	return Event{Type: t, ID: id, Record: adif.Record{adifield.CALL: call, FieldID: id}}
+}
+
+// calls returns the CALL of each record of d.
+func calls(d *adif.Document) []string {
+	var calls []string
+	for _, r := range d.Records {
+		calls = append(calls, r[adifield.CALL])
+	}
+	return calls
+}
+
+func TestMirror(t *testing.T) {
+	d := adif.NewDocument()
+	d.Records = []adif.Record{{adifield.CALL: "K9CTS"}, {adifield.CALL: "W9PVA", FieldID: "b"}}
+	m := NewMirror(d)
+
+	steps := []struct {
+		e    Event
+		want []string
+	}{
+		{event(Add, "a", "DL1ABC"), []string{"K9CTS", "W9PVA", "DL1ABC"}},
+		{event(Add, "c", "G4XYZ"), []string{"K9CTS", "W9PVA", "DL1ABC", "G4XYZ"}},
+		{event(Replace, "b", "W9PVB"), []string{"K9CTS", "W9PVB", "DL1ABC", "G4XYZ"}},
+		{event(Add, "a", "DL1ABD"), []string{"K9CTS", "W9PVB", "DL1ABD", "G4XYZ"}},
+		{event(Replace, "d", "JA1AAA"), []string{"K9CTS", "W9PVB", "DL1ABD", "G4XYZ", "JA1AAA"}},
+		{event(Delete, "b", "W9PVB"), []string{"K9CTS", "DL1ABD", "G4XYZ", "JA1AAA"}},
+		{event(Delete, "b", "W9PVB"), []string{"K9CTS", "DL1ABD", "G4XYZ", "JA1AAA"}},
+		{event(Replace, "c", "G4XYY"), []string{"K9CTS", "DL1ABD", "G4XYY", "JA1AAA"}},
+		{event(Delete, "d", "JA1AAA"), []string{"K9CTS", "DL1ABD", "G4XYY"}},
+		{event(0, "a", "DL1ABC"), []string{"K9CTS", "DL1ABD", "G4XYY"}},
+	}
+	for i, step := range steps {
+		m.Apply(step.e)
+		got := calls(m.Document())
+		if len(got) != len(step.want) || m.Len() != len(step.want) {
+			t.Fatalf("step %d: got %v, want %v", i, got, step.want)
+		}
+		for j := range got {
+			if got[j] != step.want[j] {
+				t.Fatalf("step %d: got %v, want %v", i, got, step.want)
+			}
+		}
+	}
+}
+
+func TestMirror_Document(t *testing.T) {
+	m := NewMirror(nil)
+	m.Apply(event(Add, "a", "K9CTS"))
+	d := m.Document()
+	m.Apply(event(Add, "b", "W9PVA"))
+	m.Apply(event(Replace, "a", "K9CTT"))
+	if got := calls(d); len(got) != 1 || got[0] != "K9CTS" {
+		t.Errorf("snapshot changed: got %v", got)
+	}
+}
+
+func TestMirror_Concurrent(t *testing.T) {
+	m := NewMirror(nil)
+	var wg sync.WaitGroup
+	for _, id := range []string{"a", "b", "c", "d"} {
+		wg.Go(func() {
+			for range 100 {
+				m.Apply(event(Add, id, "K9CTS"))
+				_ = m.Document()
+				m.Apply(event(Delete, id, "K9CTS"))
+			}
+			m.Apply(event(Add, id, "K9CTS"))
+		})
+	}
+	wg.Wait()
+	if m.Len() != 4 {
+		t.Errorf("got %d records, want 4", m.Len())
+	}
+}
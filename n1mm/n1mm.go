@@ -0,0 +1,275 @@
+// Package n1mm decodes the contact broadcasts that N1MM Logger+ sends over UDP, and turns them into ADIF records.
+//
+// N1MM Logger+ sends a <contactinfo> XML packet for each QSO it logs, a <contactreplace> packet when a QSO is edited,
+// and a <contactdelete> packet when one is deleted, as described in its "External UDP Broadcasts" documentation.
+// Parse turns a packet into an Event keyed by the unique ID that N1MM Logger+ gives each QSO;
+// Listener receives them from a UDP socket, and Mirror applies them to an adif.Document that follows the contest log.
+package n1mm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/contest"
+	"github.com/farmergreg/spec/v6/enum/mode"
+	"github.com/farmergreg/spec/v6/enum/submode"
+)
+
+// DefaultPort is the UDP port that N1MM Logger+ sends contact broadcasts to unless configured otherwise.
+const DefaultPort = 12060
+
+// Application-defined fields that hold the N1MM Logger+ values without an ADIF equivalent.
+const (
+	// FieldID holds the unique ID of the QSO, which Replace and Delete events refer to.
+	FieldID adifield.Field = "APP_N1MM_ID"
+
+	// FieldStationName holds the name of the computer that logged the QSO.
+	FieldStationName adifield.Field = "APP_N1MM_STATIONNAME"
+)
+
+// timestampLayout is the layout of the UTC <timestamp> element.
+const timestampLayout = "2006-01-02 15:04:05"
+
+var (
+	// ErrMalformedPacket is returned by Parse when its input is not a well-formed contact broadcast.
+	ErrMalformedPacket = errors.New("malformed N1MM packet")
+
+	// ErrUnsupportedPacket is returned by Parse for a well-formed packet other than a contact broadcast,
+	// such as <RadioInfo> or <spot>.
+	ErrUnsupportedPacket = errors.New("unsupported N1MM packet")
+)
+
+// EventType identifies the change to the contest log that an Event describes.
+type EventType int
+
+// Event types, one for each contact broadcast.
+const (
+	// Add is sent as <contactinfo> when a QSO is logged.
+	Add EventType = iota + 1
+
+	// Replace is sent as <contactreplace> when a logged QSO is edited.
+	Replace
+
+	// Delete is sent as <contactdelete> when a logged QSO is deleted.
+	Delete
+)
+
+// String returns the name of the XML element that carries events of type t.
+func (t EventType) String() string {
+	switch t {
+	case Add:
+		return "contactinfo"
+	case Replace:
+		return "contactreplace"
+	case Delete:
+		return "contactdelete"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a change to the contest log of N1MM Logger+.
+type Event struct {
+	// Type is the kind of change.
+	Type EventType
+
+	// ID is the unique ID of the QSO that was added, replaced or deleted.
+	ID string
+
+	// Record is the QSO as an ADIF record, as converted by Parse.
+	// For Delete events, it only holds the CALL, QSO_DATE, TIME_ON, CONTEST_ID and station fields that the packet carries.
+	Record adif.Record
+}
+
+// contact holds the elements of a contact broadcast that Parse uses.
+type contact struct {
+	XMLName     xml.Name
+	ContestName string `xml:"contestname"`
+	Timestamp   string `xml:"timestamp"`
+	MyCall      string `xml:"mycall"`
+	Band        string `xml:"band"`
+	RxFreq      string `xml:"rxfreq"` // tens of Hz
+	TxFreq      string `xml:"txfreq"` // tens of Hz
+	Operator    string `xml:"operator"`
+	Mode        string `xml:"mode"`
+	Call        string `xml:"call"`
+	Snt         string `xml:"snt"`
+	SntNr       string `xml:"sntnr"`
+	Rcv         string `xml:"rcv"`
+	RcvNr       string `xml:"rcvnr"`
+	GridSquare  string `xml:"gridsquare"`
+	Exchange1   string `xml:"exchange1"`
+	Section     string `xml:"section"`
+	Comment     string `xml:"comment"`
+	QTH         string `xml:"qth"`
+	Name        string `xml:"name"`
+	Prec        string `xml:"prec"`
+	Ck          string `xml:"ck"`
+	StationName string `xml:"StationName"`
+	ID          string `xml:"ID"`
+}
+
+// Parse decodes a contact broadcast into an Event.
+// A packet without an <ID>, or with a <timestamp> or frequency that cannot be parsed, is reported as ErrMalformedPacket.
+func Parse(data []byte) (Event, error) {
+	var c contact
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&c); err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrMalformedPacket, err)
+	}
+	var e Event
+	switch strings.ToLower(c.XMLName.Local) {
+	case "contactinfo":
+		e.Type = Add
+	case "contactreplace":
+		e.Type = Replace
+	case "contactdelete":
+		e.Type = Delete
+	default:
+		return Event{}, fmt.Errorf("%w: %s", ErrUnsupportedPacket, c.XMLName.Local)
+	}
+	e.ID = strings.TrimSpace(c.ID)
+	if e.ID == "" {
+		return Event{}, fmt.Errorf("%w: %s without an ID", ErrMalformedPacket, e.Type)
+	}
+	var err error
+	if e.Record, err = c.record(); err != nil {
+		return Event{}, err
+	}
+	e.Record[FieldID] = e.ID
+	return e, nil
+}
+
+// record returns the contact as an ADIF record. Empty values and zero serial numbers are omitted.
+func (c *contact) record() (adif.Record, error) {
+	r := adif.NewRecord()
+	set := func(field adifield.Field, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			r[field] = value
+		}
+	}
+	setNumber := func(field adifield.Field, value string) {
+		if value = strings.TrimSpace(value); value != "0" {
+			set(field, value)
+		}
+	}
+	set(adifield.CALL, strings.ToUpper(c.Call))
+	if ts := strings.TrimSpace(c.Timestamp); ts != "" {
+		t, err := time.Parse(timestampLayout, ts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: timestamp %q", ErrMalformedPacket, ts)
+		}
+		r.SetTime(adifield.QSO_DATE, adifield.TIME_ON, t)
+	}
+	if err := setFrequency(r, adifield.FREQ, adifield.BAND, c.TxFreq); err != nil {
+		return nil, err
+	}
+	if c.RxFreq != c.TxFreq {
+		if err := setFrequency(r, adifield.FREQ_RX, adifield.BAND_RX, c.RxFreq); err != nil {
+			return nil, err
+		}
+	}
+	if r[adifield.BAND] == "" {
+		// <band> is the lower edge of the band in MHz, e.g. 3.5 or 144.
+		if mhz, err := strconv.ParseFloat(strings.TrimSpace(c.Band), 64); err == nil {
+			if spec, ok := band.FindBandByMHz(mhz); ok {
+				r.SetBand(spec.Key)
+			}
+		}
+	}
+	setMode(r, strings.ToUpper(strings.TrimSpace(c.Mode)))
+	set(adifield.CONTEST_ID, contestID(c.ContestName))
+	set(adifield.STATION_CALLSIGN, strings.ToUpper(c.MyCall))
+	set(adifield.OPERATOR, strings.ToUpper(c.Operator))
+	set(adifield.RST_SENT, c.Snt)
+	set(adifield.RST_RCVD, c.Rcv)
+	setNumber(adifield.STX, c.SntNr)
+	setNumber(adifield.SRX, c.RcvNr)
+	set(adifield.SRX_STRING, c.Exchange1)
+	set(adifield.ARRL_SECT, c.Section)
+	set(adifield.PRECEDENCE, c.Prec)
+	setNumber(adifield.CHECK, c.Ck)
+	set(adifield.GRIDSQUARE, c.GridSquare)
+	set(adifield.NAME, c.Name)
+	set(adifield.QTH, c.QTH)
+	set(adifield.COMMENT, c.Comment)
+	set(FieldStationName, c.StationName)
+	return r, nil
+}
+
+// setFrequency stores a frequency given in tens of Hz as freqField in MHz, together with its band as bandField.
+// An empty or zero frequency is not stored.
+func setFrequency(r adif.Record, freqField, bandField adifield.Field, tensOfHz string) error {
+	tensOfHz = strings.TrimSpace(tensOfHz)
+	if tensOfHz == "" {
+		return nil
+	}
+	n, err := strconv.ParseFloat(tensOfHz, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%w: frequency %q", ErrMalformedPacket, tensOfHz)
+	}
+	if n == 0 {
+		return nil
+	}
+	mhz := n / 1e5
+	r.SetFloat(freqField, mhz)
+	if spec, ok := band.FindBandByMHz(mhz); ok {
+		r[bandField] = string(spec.Key)
+	}
+	return nil
+}
+
+// setMode stores an N1MM Logger+ mode, such as CW, USB or FT4, as MODE and, where ADIF defines it as a submode, SUBMODE.
+func setMode(r adif.Record, m string) {
+	switch {
+	case m == "":
+	case m == "USB" || m == "LSB":
+		r[adifield.MODE], r[adifield.SUBMODE] = "SSB", m
+	default:
+		if _, isMode := mode.Lookup(mode.New(m)); !isMode {
+			if spec, ok := submode.Lookup(submode.New(m)); ok {
+				r[adifield.MODE], r[adifield.SUBMODE] = strings.ToUpper(spec.Mode), string(spec.Key)
+				return
+			}
+		}
+		r[adifield.MODE] = m
+	}
+}
+
+// contestIDs maps ADIF contest IDs, stripped of everything but letters and digits, to the IDs themselves,
+// so that N1MM Logger+ contest names such as CQWWSSB find CQ-WW-SSB.
+var contestIDs = func() map[string]contest.Contest {
+	ids := make(map[string]contest.Contest)
+	for _, spec := range contest.List() {
+		ids[contestKey(string(spec.Key))] = spec.Key
+	}
+	return ids
+}()
+
+// contestKey returns name in upper case with everything but letters and digits removed.
+func contestKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return -1
+	}, name)
+}
+
+// contestID returns the ADIF contest ID matching an N1MM Logger+ contest name, or the name itself when there is none.
+func contestID(name string) string {
+	name = strings.TrimSpace(name)
+	if id, ok := contestIDs[contestKey(name)]; ok && name != "" {
+		return string(id)
+	}
+	return name
+}
@@ -0,0 +1,183 @@
+package n1mm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// testContactInfo is a <contactinfo> packet as sent by N1MM Logger+, with its unused elements trimmed.
+const testContactInfo = `<?xml version="1.0" encoding="utf-8"?>
+<contactinfo>
+	<app>N1MM</app>
+	<contestname>CQWWSSB</contestname>
+	<contestnr>73</contestnr>
+	<timestamp>2024-10-26 00:01:02</timestamp>
+	<mycall>w9pva</mycall>
+	<band>14</band>
+	<rxfreq>1425000</rxfreq>
+	<txfreq>1425000</txfreq>
+	<operator>k9cts</operator>
+	<mode>USB</mode>
+	<call>dl1abc</call>
+	<countryprefix>DL</countryprefix>
+	<snt>59</snt>
+	<sntnr>0</sntnr>
+	<rcv>59</rcv>
+	<rcvnr>0</rcvnr>
+	<gridsquare></gridsquare>
+	<exchange1>14</exchange1>
+	<section></section>
+	<comment></comment>
+	<prec></prec>
+	<zone>14</zone>
+	<ck>0</ck>
+	<IsOriginal>True</IsOriginal>
+	<StationName>RUN-PC</StationName>
+	<ID>f9ffac4fcd3e479ca86e137df1338531</ID>
+</contactinfo>`
+
+// packet returns testContactInfo as the element named name, with the replacements applied in pairs.
+func packet(name string, replacements ...string) []byte {
+	p := strings.NewReplacer(replacements...).Replace(testContactInfo)
+	return []byte(strings.ReplaceAll(p, "contactinfo>", name+">"))
+}
+
+func TestParse(t *testing.T) {
+	e, err := Parse([]byte(testContactInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != Add || e.ID != "f9ffac4fcd3e479ca86e137df1338531" {
+		t.Errorf("got %v %q", e.Type, e.ID)
+	}
+	want := adif.Record{
+		adifield.CALL:             "DL1ABC",
+		adifield.QSO_DATE:         "20241026",
+		adifield.TIME_ON:          "000102",
+		adifield.FREQ:             "14.25",
+		adifield.BAND:             "20M",
+		adifield.MODE:             "SSB",
+		adifield.SUBMODE:          "USB",
+		adifield.CONTEST_ID:       "CQ-WW-SSB",
+		adifield.STATION_CALLSIGN: "W9PVA",
+		adifield.OPERATOR:         "K9CTS",
+		adifield.RST_SENT:         "59",
+		adifield.RST_RCVD:         "59",
+		adifield.SRX_STRING:       "14",
+		FieldStationName:          "RUN-PC",
+		FieldID:                   "f9ffac4fcd3e479ca86e137df1338531",
+	}
+	if got := e.Record.String(); got != want.String() {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestParse_Fields(t *testing.T) {
+	tests := []struct {
+		name         string
+		replacements []string
+		want         adif.Record
+	}{
+		{
+			"split and serials",
+			[]string{"<rxfreq>1425000", "<rxfreq>1415000", "<sntnr>0", "<sntnr>12", "<rcvnr>0", "<rcvnr>345"},
+			adif.Record{adifield.FREQ_RX: "14.15", adifield.BAND_RX: "20M", adifield.STX: "12", adifield.SRX: "345"},
+		},
+		{
+			"band without frequency",
+			[]string{"<rxfreq>1425000", "<rxfreq>", "<txfreq>1425000", "<txfreq>0", "<band>14", "<band>3.5"},
+			adif.Record{adifield.BAND: "80M"},
+		},
+		{
+			"sweepstakes",
+			[]string{"<section>", "<section>IL", "<prec>", "<prec>A", "<ck>0", "<ck>72", "<contestname>CQWWSSB", "<contestname>ARRLSS"},
+			adif.Record{adifield.ARRL_SECT: "IL", adifield.PRECEDENCE: "A", adifield.CHECK: "72", adifield.CONTEST_ID: "ARRLSS"},
+		},
+		{"submode", []string{"<mode>USB", "<mode>FT4"}, adif.Record{adifield.MODE: "MFSK", adifield.SUBMODE: "FT4"}},
+		{"mode", []string{"<mode>USB", "<mode>cw"}, adif.Record{adifield.MODE: "CW"}},
+		{"unknown mode", []string{"<mode>USB", "<mode>DIGI"}, adif.Record{adifield.MODE: "DIGI"}},
+		{"no mode", []string{"<mode>USB", "<mode>"}, adif.Record{adifield.MODE: ""}},
+		{"contest", []string{"<contestname>CQWWSSB", "<contestname>naqp-cw"}, adif.Record{adifield.CONTEST_ID: "NAQP-CW"}},
+		{"no contest", []string{"<contestname>CQWWSSB", "<contestname>"}, adif.Record{adifield.CONTEST_ID: ""}},
+		{
+			"no timestamp or band",
+			[]string{"<timestamp>2024-10-26 00:01:02", "<timestamp>", "<txfreq>1425000", "<txfreq>", "<band>14", "<band>"},
+			adif.Record{adifield.QSO_DATE: "", adifield.BAND: "", adifield.FREQ: ""},
+		},
+	}
+	for _, tt := range tests {
+		e, err := Parse(packet("contactinfo", tt.replacements...))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for field, want := range tt.want {
+			if got := e.Record[field]; got != want {
+				t.Errorf("%s: %s got %q, want %q", tt.name, field, got, want)
+			}
+		}
+	}
+}
+
+func TestParse_Events(t *testing.T) {
+	e, err := Parse(packet("contactreplace", "<call>dl1abc", "<call>DL2XYZ"))
+	if err != nil || e.Type != Replace || e.Record[adifield.CALL] != "DL2XYZ" {
+		t.Errorf("contactreplace: got %v %v, %v", e.Type, e.Record, err)
+	}
+
+	del := `<?xml version="1.0" encoding="utf-8"?>
+<contactdelete>
+	<app>N1MM</app>
+	<timestamp>2024-10-26 00:01:02</timestamp>
+	<call>DL1ABC</call>
+	<contestnr>73</contestnr>
+	<StationName>RUN-PC</StationName>
+	<ID>f9ffac4fcd3e479ca86e137df1338531</ID>
+</contactdelete>`
+	e, err = Parse([]byte(del))
+	want := adif.Record{
+		adifield.CALL:     "DL1ABC",
+		adifield.QSO_DATE: "20241026",
+		adifield.TIME_ON:  "000102",
+		FieldStationName:  "RUN-PC",
+		FieldID:           "f9ffac4fcd3e479ca86e137df1338531",
+	}
+	if err != nil || e.Type != Delete || e.ID != want[FieldID] || e.Record.String() != want.String() {
+		t.Errorf("contactdelete: got %v %q %v, %v", e.Type, e.ID, e.Record, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		err    error
+	}{
+		{"not XML", []byte("CQ TEST"), ErrMalformedPacket},
+		{"truncated", []byte(testContactInfo[:200]), ErrMalformedPacket},
+		{"radio info", []byte("<RadioInfo><app>N1MM</app></RadioInfo>"), ErrUnsupportedPacket},
+		{"no ID", packet("contactinfo", "<ID>f9ffac4fcd3e479ca86e137df1338531", "<ID>"), ErrMalformedPacket},
+		{"timestamp", packet("contactinfo", "2024-10-26 00:01:02", "26/10/2024"), ErrMalformedPacket},
+		{"frequency", packet("contactinfo", "<txfreq>1425000", "<txfreq>14.250 MHz"), ErrMalformedPacket},
+		{"negative frequency", packet("contactinfo", "<txfreq>1425000", "<txfreq>-1"), ErrMalformedPacket},
+		{"rx frequency", packet("contactinfo", "<rxfreq>1425000", "<rxfreq>x"), ErrMalformedPacket},
+	}
+	for _, tt := range tests {
+		if e, err := Parse(tt.packet); !errors.Is(err, tt.err) || e.Record != nil {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, e, err, tt.err)
+		}
+	}
+}
+
+func TestEventType_String(t *testing.T) {
+	tests := map[EventType]string{Add: "contactinfo", Replace: "contactreplace", Delete: "contactdelete", 0: "EventType(0)"}
+	for et, want := range tests {
+		if got := et.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package adif
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"unicode/utf8"
+
+	"github.com/farmergreg/adif/v5/internal/flush"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// ndjsonHeaderKey is the key of the only member of the object that holds the header record on the first NDJSON line,
+// matching the JSON encoding of Document.Header.
+const ndjsonHeaderKey = "HEADER"
+
+// NDJSONWriter writes ADIF records to an underlying io.Writer as newline delimited JSON (NDJSON),
+// one JSON object per line with the non-empty fields of the record in the pretty order of WriteModePretty.
+// The header is written as {"HEADER":{...}} so that it can be told apart from QSO records.
+// Obtain one with NewNDJSONWriter, write records with WriteHeader and Write,
+// then call Flush once to ensure any buffered data in the underlying writer is flushed.
+//
+// NDJSONWriter does not add its own buffering. Wrap the destination with bufio.NewWriter
+// for buffered output, and pass it to both NewNDJSONWriter and Flush.
+type NDJSONWriter struct {
+	w         io.Writer
+	wroteData bool
+}
+
+// NewNDJSONWriter returns an NDJSONWriter that writes NDJSON records to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{w: w}
+}
+
+// WriteHeader writes the header record on its own line.
+// The header must be written before any QSO records and may only be written once.
+func (w *NDJSONWriter) WriteHeader(r Record) error {
+	if w.wroteData {
+		return ErrHeaderAlreadyWritten
+	}
+	w.wroteData = true
+	return w.writeLine(r, true)
+}
+
+// Write appends a QSO record to the output on its own line.
+func (w *NDJSONWriter) Write(r Record) error {
+	w.wroteData = true
+	return w.writeLine(r, false)
+}
+
+// WriteContext is like Write, but returns ctx.Err() without writing once ctx is done.
+func (w *NDJSONWriter) WriteContext(ctx context.Context, r Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return w.Write(r)
+}
+
+// Flush flushes the underlying io.Writer if it implements Flush() error (e.g. bufio.Writer).
+// It is a no-op for writers that do not buffer.
+func (w *NDJSONWriter) Flush() error {
+	return flush.Writer(w.w)
+}
+
+func (w *NDJSONWriter) writeLine(r Record, isHeader bool) error {
+	bufPtr := writerBufPool.Get().(*[]byte)
+	buf := (*bufPtr)[:0]
+	if isHeader {
+		buf = append(buf, `{"`+ndjsonHeaderKey+`":`...)
+	}
+	buf = appendRecordJSON(buf, r, true)
+	if isHeader {
+		buf = append(buf, '}')
+	}
+	buf = append(buf, '\n')
+	_, err := w.w.Write(buf)
+
+	*bufPtr = buf
+	writerBufPool.Put(bufPtr)
+	return err
+}
+
+// appendRecordJSON appends r to buf as a JSON object with the fields in pretty order,
+// leaving out fields with empty values when omitEmpty is set.
+func appendRecordJSON(buf []byte, r Record, omitEmpty bool) []byte {
+	scratchPtr := writerFieldScratchPool.Get().(*[]adifield.Field)
+	scratch := appendFieldOrderPretty((*scratchPtr)[:0], r)
+	buf = append(buf, '{')
+	start := len(buf)
+	for _, field := range scratch {
+		if omitEmpty && r[field] == "" {
+			continue
+		}
+		if len(buf) > start {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, string(field))
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, r[field])
+	}
+	*scratchPtr = scratch
+	writerFieldScratchPool.Put(scratchPtr)
+	return append(buf, '}')
+}
+
+// appendJSONString appends s to buf as a JSON string.
+// As with encoding/json, invalid UTF-8 is replaced with U+FFFD, and U+2028 and U+2029 are escaped.
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == utf8.RuneError && size == 1:
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+		case c == '\u2028' || c == '\u2029':
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[c&0xF])
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// NDJSONScanner reads ADIF records sequentially from newline delimited JSON (NDJSON), such as the output of NDJSONWriter.
+// Each non-blank line holds one record as a JSON object with string values;
+// a line holding an object whose only member is "HEADER" holds the header record.
+// It yields the same Record type and header semantics as Scanner.
+// Use NewNDJSONScanner to create one, then call Scan in a loop.
+type NDJSONScanner struct {
+	r         *bufio.Reader
+	line      []byte // holds lines longer than the buffer of r
+	current   Record
+	isHeader  bool
+	err       error
+	offset    int64 // offset of the next line
+	lineStart int64 // offset of the most recently read line
+	lines     int
+	records   int
+}
+
+// NewNDJSONScanner returns an NDJSONScanner that reads NDJSON records from r.
+func NewNDJSONScanner(r io.Reader) *NDJSONScanner {
+	return &NDJSONScanner{r: bufio.NewReader(r)}
+}
+
+// Scan advances to the next record and returns true if one was found.
+// Call Record to retrieve it and IsHeader to determine its type.
+// Returns false when no more records exist or an error occurred.
+// After Scan returns false, call Err to check for any non-EOF error.
+func (s *NDJSONScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	s.current, s.isHeader, s.err = s.next()
+	if s.err != nil {
+		s.current, s.isHeader = nil, false
+		return false
+	}
+	s.records++
+	return true
+}
+
+// ScanContext is like Scan, but returns false once ctx is done; Err then returns ctx.Err().
+func (s *NDJSONScanner) ScanContext(ctx context.Context) bool {
+	if err := ctx.Err(); err != nil {
+		s.current, s.isHeader, s.err = nil, false, err
+		return false
+	}
+	return s.Scan()
+}
+
+// Record returns the record from the most recent successful Scan call.
+func (s *NDJSONScanner) Record() Record { return s.current }
+
+// IsHeader reports whether the record from the most recent Scan call is a header record.
+func (s *NDJSONScanner) IsHeader() bool { return s.isHeader }
+
+// Err returns the first non-EOF error encountered by the NDJSONScanner.
+// Returns nil when Scan stopped due to io.EOF.
+// Malformed input is reported as a *ParseError wrapping ErrMalformedNDJSON.
+func (s *NDJSONScanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// All returns an iterator over the remaining records, including the header, and the error that ends the scan.
+func (s *NDJSONScanner) All() iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for s.Scan() {
+			if !yield(s.current, nil) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// QSOs returns an iterator over the remaining QSO records, skipping the header; call Err after the loop.
+func (s *NDJSONScanner) QSOs() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		for s.Scan() {
+			if !s.isHeader && !yield(s.current) {
+				return
+			}
+		}
+	}
+}
+
+// Decode unmarshals the next QSO record into v as Unmarshal does, and returns io.EOF after the last one.
+func (s *NDJSONScanner) Decode(v any) error {
+	for s.Scan() {
+		if !s.isHeader {
+			return Unmarshal(s.current, v)
+		}
+	}
+	return s.err
+}
+
+// ndjsonRecordScanner adapts NDJSONScanner to recordScanner. NDJSON has no data type indicators.
+type ndjsonRecordScanner struct{ *NDJSONScanner }
+
+func (ndjsonRecordScanner) DataTypeIndicators() map[adifield.Field]aditype.DataTypeIndicator {
+	return nil
+}
+
+// next reads lines until one holds a record.
+func (s *NDJSONScanner) next() (Record, bool, error) {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var r Record
+		err = r.UnmarshalJSON(line)
+		if err == nil {
+			return r, false, nil
+		}
+		// The header is decoded without Record.UnmarshalJSON so that error offsets are relative to the line.
+		var header map[string]map[string]string
+		headerErr := json.Unmarshal(line, &header)
+		if h, ok := header[ndjsonHeaderKey]; ok && headerErr == nil && len(header) == 1 {
+			r := make(Record, len(h))
+			for k, v := range h {
+				r[adifield.New(k)] = v
+			}
+			return r, true, nil
+		}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == ndjsonHeaderKey && headerErr != nil {
+			err = headerErr
+		}
+		return nil, false, s.newParseError(line, err)
+	}
+}
+
+// readLine returns the next line without its line ending, or io.EOF at the end of the input.
+// The returned slice is only valid until the next call.
+func (s *NDJSONScanner) readLine() ([]byte, error) {
+	line, err := s.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		s.line = append(s.line[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = s.r.ReadSlice('\n')
+			s.line = append(s.line, line...)
+		}
+		line = s.line
+	}
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	s.lines++
+	s.lineStart = s.offset
+	s.offset += int64(len(line))
+	if s.lines == 1 && bytes.HasPrefix(line, utf8BOM) {
+		line = line[len(utf8BOM):]
+		s.lineStart += int64(len(utf8BOM))
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// newParseError wraps err, returned for the most recently read line, in a *ParseError wrapping ErrMalformedNDJSON.
+// It is positioned at the offending byte when err reports one.
+func (s *NDJSONScanner) newParseError(line []byte, err error) error {
+	column := 1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		column = int(max(syntaxErr.Offset, 1))
+	} else if errors.As(err, &typeErr) {
+		column = int(max(typeErr.Offset, 1))
+	}
+	return &ParseError{
+		Offset: s.lineStart + int64(column) - 1,
+		Line:   s.lines,
+		Column: column,
+		Record: s.records,
+		Err:    fmt.Errorf("%w: %w", ErrMalformedNDJSON, err),
+	}
+}
@@ -0,0 +1,234 @@
+package adif
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+const testNDJSON = `{"HEADER":{"ADIF_VER":"3.1.5","PROGRAMID":"MonoLog"}}
+{"QSO_DATE":"20240101","TIME_ON":"1200","BAND":"20M","CALL":"K9CTS","COMMENT":"tab\there \"quoted\"\r\nnext line"}
+{"CALL":"W9PVA","APP_TEST":"\u0001\\"}
+`
+
+func TestNDJSONWriter(t *testing.T) {
+	var sb strings.Builder
+	bw := bufio.NewWriter(&sb)
+	w := NewNDJSONWriter(bw)
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "MonoLog", adifield.ADIF_VER: "3.1.5"}); err != nil {
+		t.Fatal(err)
+	}
+	records := []Record{
+		{adifield.CALL: "K9CTS", adifield.COMMENT: "tab\there \"quoted\"\r\nnext line", adifield.BAND: "20M", adifield.QSO_DATE: "20240101", adifield.TIME_ON: "1200"},
+		// The empty NAME is left out, as Writer and ADXWriter do.
+		{"APP_TEST": "\x01\\", adifield.CALL: "W9PVA", adifield.NAME: ""},
+	}
+	for _, r := range records {
+		if err := w.WriteContext(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != testNDJSON {
+		t.Errorf("got\n%s\nwant\n%s", sb.String(), testNDJSON)
+	}
+
+	if err := w.WriteHeader(Record{}); err != ErrHeaderAlreadyWritten {
+		t.Errorf("WriteHeader after Write: got %v, want ErrHeaderAlreadyWritten", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.WriteContext(ctx, Record{}); err != context.Canceled {
+		t.Errorf("WriteContext: got %v, want context.Canceled", err)
+	}
+	if err := NewNDJSONWriter(&mockAlwaysErrorWriter{}).Write(Record{}); err != errMockWrite {
+		t.Errorf("Write: got %v, want errMockWrite", err)
+	}
+}
+
+func TestAppendJSONString(t *testing.T) {
+	for _, s := range []string{"", "K9CTS", "\"\\/\b\f\n\r\t\x00\x1f\x7f", "Jürgen €", "bad \xff utf-8 \xe2\x82", "  ", "<&>"} {
+		want, _ := json.Marshal(s)
+		got := appendJSONString(nil, s)
+		var decoded, wantDecoded string
+		if err := json.Unmarshal(got, &decoded); err != nil {
+			t.Errorf("%q: %s is not valid JSON: %v", s, got, err)
+			continue
+		}
+		_ = json.Unmarshal(want, &wantDecoded)
+		if decoded != wantDecoded {
+			t.Errorf("%q: got %s decoding to %q, want %q", s, got, decoded, wantDecoded)
+		}
+	}
+}
+
+func TestNDJSONScanner(t *testing.T) {
+	s := NewNDJSONScanner(strings.NewReader("\xEF\xBB\xBF" + strings.ReplaceAll(testNDJSON, "\n", "\r\n\r\n")))
+	var headers, records int
+	for r, err := range s.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.IsHeader() {
+			headers++
+			if r[adifield.PROGRAMID] != "MonoLog" {
+				t.Errorf("header: got %v", r)
+			}
+			continue
+		}
+		records++
+		if records == 2 && r["APP_TEST"] != "\x01\\" {
+			t.Errorf("APP_TEST: got %q", r["APP_TEST"])
+		}
+	}
+	if headers != 1 || records != 2 {
+		t.Errorf("got %d headers and %d records, want 1 and 2", headers, records)
+	}
+}
+
+func TestNDJSONScanner_LongLine(t *testing.T) {
+	long := strings.Repeat("x", 10000) // longer than the bufio.Reader buffer
+	data := `{"NOTES":"` + long + `"}` + "\n" + `{"CALL":"K9CTS"}`
+	var calls []string
+	s := NewNDJSONScanner(strings.NewReader(data))
+	for r := range s.QSOs() {
+		calls = append(calls, r[adifield.CALL])
+		if len(calls) == 1 && r[adifield.NOTES] != long {
+			t.Errorf("NOTES: got %d bytes", len(r[adifield.NOTES]))
+		}
+	}
+	if s.Err() != nil || len(calls) != 2 || calls[1] != "K9CTS" {
+		t.Errorf("got %q, %v", calls, s.Err())
+	}
+}
+
+func TestNDJSONScanner_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name                 string
+		data                 string
+		offset, line, column int
+		record               int
+	}{
+		{"syntax", `{"CALL":"K9CTS"}` + "\n" + `{"CALL":"W9PVA",}`, 33, 2, 17, 1},
+		{"number", `{"CALL":42}`, 9, 1, 10, 0},
+		{"array", `["CALL"]`, 0, 1, 1, 0},
+		{"trailing data", `{"CALL":"K9CTS"} x`, 17, 1, 18, 0},
+		{"header with fields", `{"HEADER":{},"CALL":"K9CTS"}`, 26, 1, 27, 0},
+		{"header after BOM", "\xEF\xBB\xBF{\"HEADER\":{\"ADIF_VER\":3}}", 25, 1, 23, 0},
+	}
+	for _, tt := range tests {
+		s := NewNDJSONScanner(strings.NewReader(tt.data))
+		for s.Scan() {
+		}
+		var pe *ParseError
+		if !errors.As(s.Err(), &pe) || !errors.Is(pe, ErrMalformedNDJSON) {
+			t.Errorf("%s: got %v, want a *ParseError", tt.name, s.Err())
+			continue
+		}
+		if pe.Offset != int64(tt.offset) || pe.Line != tt.line || pe.Column != tt.column || pe.Record != tt.record {
+			t.Errorf("%s: got %v, want offset %d, line %d, column %d, record %d", tt.name, pe, tt.offset, tt.line, tt.column, tt.record)
+		}
+		if s.Scan() || s.Record() != nil {
+			t.Errorf("%s: Scan continued after an error", tt.name)
+		}
+	}
+}
+
+func TestNDJSONScanner_Decode(t *testing.T) {
+	s := NewNDJSONScanner(strings.NewReader(testNDJSON))
+	var calls []string
+	for {
+		var q struct{ Call string }
+		err := s.Decode(&q)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, q.Call)
+	}
+	if len(calls) != 2 || calls[0] != "K9CTS" || calls[1] != "W9PVA" {
+		t.Errorf("got %q", calls)
+	}
+}
+
+func TestNDJSONScanner_StopAndErrors(t *testing.T) {
+	s := NewNDJSONScanner(strings.NewReader(testNDJSON))
+	for range s.All() {
+		break
+	}
+	for range s.QSOs() {
+		break
+	}
+	if s.Record()[adifield.CALL] != "K9CTS" {
+		t.Errorf("got %v after stopping the iterators", s.Record())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if s.ScanContext(ctx) || s.Err() != context.Canceled {
+		t.Errorf("ScanContext: got %v, want context.Canceled", s.Err())
+	}
+	s = NewNDJSONScanner(strings.NewReader(testNDJSON))
+	if !s.ScanContext(context.Background()) || !s.IsHeader() {
+		t.Errorf("ScanContext: got %v", s.Err())
+	}
+
+	r := &mockFailReader{backingData: []byte(testNDJSON), maxBytes: 70}
+	s = NewNDJSONScanner(r)
+	for _, err := range s.All() {
+		if err != nil && strings.Contains(err.Error(), "max bytes") {
+			return
+		}
+	}
+	t.Errorf("got %v, want the read error", s.Err())
+}
+
+func TestDocument_NDJSON_RoundTrip(t *testing.T) {
+	fs, err := testFileFS.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		t.Run(f.Name(), func(t *testing.T) {
+			data, err := testFileFS.ReadFile("testdata/" + f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := readSequential(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want.Format = FormatNDJSON
+
+			got := NewDocument()
+			if _, err := got.ReadFrom(strings.NewReader(want.String())); err != nil {
+				t.Fatal(err)
+			}
+			if got.Format != FormatNDJSON {
+				t.Errorf("Format: got %v, want FormatNDJSON", got.Format)
+			}
+			assertRecordEqual(t, got.Header, want.Header)
+			if len(got.Records) != len(want.Records) {
+				t.Fatalf("Records: got %d, want %d", len(got.Records), len(want.Records))
+			}
+			for i := range want.Records {
+				assertRecordEqual(t, got.Records[i], want.Records[i])
+			}
+		})
+	}
+
+	d := NewDocument()
+	if _, err := d.ReadFrom(strings.NewReader(`{"CALL":"K9CTS"}` + "\n" + `{"HEADER":{}}`)); err != ErrUnexpectedHeader {
+		t.Errorf("late header: got %v, want ErrUnexpectedHeader", err)
+	}
+}
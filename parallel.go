@@ -0,0 +1,171 @@
+package adif
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+)
+
+// parallelChunkSize is the approximate number of input bytes parsed by each ReadParallel task.
+// Chunks end at the first record boundary after this many bytes.
+var parallelChunkSize int64 = 1 << 20
+
+// parallelBufferSize is the bufio.Reader size used for each section of the input.
+const parallelBufferSize = 64 * 1024
+
+// parallelChunk is a run of whole records parsed by one ReadParallel task.
+type parallelChunk struct {
+	start, end int64
+	line       int   // one-based line number at start
+	lineStart  int64 // offset of the first byte of the line containing start
+
+	header   Record
+	preamble string
+	records  []Record
+	types    map[adifield.Field]aditype.DataTypeIndicator
+	err      error
+}
+
+// ParseParallel parses the ADI document in data using up to workers goroutines.
+// It is equivalent to ReadParallel(bytes.NewReader(data), int64(len(data)), workers).
+func ParseParallel(data []byte, workers int) (*Document, error) {
+	return ReadParallel(bytes.NewReader(data), int64(len(data)), workers)
+}
+
+// ReadParallel parses the first size bytes of the ADI document in r using up to workers goroutines,
+// and returns a Document holding its preamble, header, data type indicators and QSO records in their original order.
+// When workers is less than 1, runtime.GOMAXPROCS(0) goroutines are used.
+//
+// The input is split into chunks of whole records by a sequential pass that reads each data specifier
+// and skips its value by length, so an <EOR> tag inside a field value never splits a record.
+// Each chunk is then parsed by its own Scanner while the split continues.
+// ADX input is not supported; use Document.ReadFrom instead.
+//
+// On failure, ReadParallel returns the error that a Scanner reading the whole input would have returned first:
+// a *ParseError positioned within the whole input, ErrUnexpectedHeader, or the I/O error of r.
+func ReadParallel(r io.ReaderAt, size int64, workers int) (*Document, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	work := make(chan *parallelChunk, workers)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for c := range work {
+				c.parse(r)
+			}
+		})
+	}
+
+	var chunks []*parallelChunk
+	walker := NewScanner(bufio.NewReaderSize(io.NewSectionReader(r, 0, size), parallelBufferSize))
+	for {
+		c := &parallelChunk{start: walker.offset, line: walker.line, lineStart: walker.lineStart}
+		var err error
+		for err == nil && walker.offset-c.start < parallelChunkSize {
+			err = walker.skipRecord()
+		}
+		// On any error, including io.EOF, the chunk's Scanner reads to the end and reports it in context.
+		c.end = walker.offset
+		if err != nil {
+			c.end = size
+		}
+		chunks = append(chunks, c)
+		work <- c
+		if c.end == size {
+			break
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	d := NewDocument()
+	d.Format = FormatADI
+	records := 0
+	for _, c := range chunks {
+		if c.err != nil {
+			return nil, c.locate(records)
+		}
+		if c.header != nil {
+			d.Preamble, d.Header = c.preamble, c.header
+			records++
+		}
+		records += len(c.records)
+		d.Records = append(d.Records, c.records...)
+		for field, dataType := range c.types {
+			if d.DataTypes == nil {
+				d.DataTypes = make(map[adifield.Field]aditype.DataTypeIndicator)
+			}
+			d.DataTypes[field] = dataType
+		}
+	}
+	return d, nil
+}
+
+// parse reads the records of c from r.
+// Only the first chunk may contain the header, and only before its first QSO record.
+func (c *parallelChunk) parse(r io.ReaderAt) {
+	s := NewScanner(bufio.NewReaderSize(io.NewSectionReader(r, c.start, c.end-c.start), parallelBufferSize))
+	for s.Scan() {
+		for field, dataType := range s.DataTypeIndicators() {
+			if c.types == nil {
+				c.types = make(map[adifield.Field]aditype.DataTypeIndicator)
+			}
+			c.types[field] = dataType
+		}
+		if s.IsHeader() {
+			if c.start != 0 || c.header != nil || len(c.records) > 0 {
+				c.err = ErrUnexpectedHeader
+				return
+			}
+			c.header, c.preamble = s.Record(), s.Preamble()
+			continue
+		}
+		c.records = append(c.records, s.Record())
+	}
+	c.err = s.Err()
+}
+
+// locate returns the error of c, with a *ParseError moved from the chunk to the whole input.
+// recordsBefore is the number of records, including the header, that precede the chunk.
+func (c *parallelChunk) locate(recordsBefore int) error {
+	pe, ok := c.err.(*ParseError)
+	if !ok {
+		return c.err
+	}
+	return pe.moved(c.start, c.line, int(c.start-c.lineStart)+1, recordsBefore)
+}
+
+// skipRecord advances past the next <EOR> or <EOH> tag without storing any field values.
+// Values are skipped by their data length, so a tag that appears within a value is not mistaken for the end of the record.
+// Position tracking continues to work, but the preamble is not read and field names are not interned.
+func (s *Scanner) skipRecord() error {
+	for {
+		if err := s.discardUntilLessThan(); err != nil {
+			return err
+		}
+		volatileSpecifier, err := s.readDataSpecifierVolatile()
+		if err != nil {
+			return err
+		}
+		volatileField, volatileLength, foundFirstColon := bytes.Cut(volatileSpecifier, []byte(":"))
+		if !foundFirstColon {
+			if bytes.EqualFold(volatileField, []byte("EOR")) || bytes.EqualFold(volatileField, []byte("EOH")) {
+				return nil
+			}
+			continue
+		}
+		length, _, err := parseDataLengthAndType(volatileLength)
+		if err != nil {
+			return err
+		}
+		if err := s.skipValue(length); err != nil {
+			return err
+		}
+	}
+}
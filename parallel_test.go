@@ -0,0 +1,161 @@
+package adif
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// setParallelChunkSize overrides parallelChunkSize for the duration of a test.
+func setParallelChunkSize(t *testing.T, size int64) {
+	t.Helper()
+	old := parallelChunkSize
+	parallelChunkSize = size
+	t.Cleanup(func() { parallelChunkSize = old })
+}
+
+// assertDocumentEqual fails the test when got and want hold different ADI content.
+func assertDocumentEqual(t *testing.T, got, want *Document) {
+	t.Helper()
+	if got.Preamble != want.Preamble {
+		t.Errorf("Preamble: got %q, want %q", got.Preamble, want.Preamble)
+	}
+	if (got.Header == nil) != (want.Header == nil) {
+		t.Errorf("Header: got %v, want %v", got.Header, want.Header)
+	}
+	assertRecordEqual(t, got.Header, want.Header)
+	if !reflect.DeepEqual(got.DataTypes, want.DataTypes) {
+		t.Errorf("DataTypes: got %v, want %v", got.DataTypes, want.DataTypes)
+	}
+	if len(got.Records) != len(want.Records) {
+		t.Fatalf("Records: got %d, want %d", len(got.Records), len(want.Records))
+	}
+	for i := range want.Records {
+		assertRecordEqual(t, got.Records[i], want.Records[i])
+	}
+}
+
+// readSequential reads data with Document.ReadFrom for comparison with ReadParallel.
+func readSequential(data []byte) (*Document, error) {
+	d := NewDocument()
+	_, err := d.ReadFrom(bytes.NewReader(data))
+	return d, err
+}
+
+func TestReadParallel_TestFiles(t *testing.T) {
+	setParallelChunkSize(t, 16*1024)
+	fs, err := testFileFS.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		t.Run(f.Name(), func(t *testing.T) {
+			data, err := testFileFS.ReadFile("testdata/" + f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := readSequential(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, workers := range []int{0, 1, 3} {
+				got, err := ParseParallel(data, workers)
+				if err != nil {
+					t.Fatalf("workers %d: %v", workers, err)
+				}
+				if got.Format != FormatADI {
+					t.Errorf("Format: got %v, want %v", got.Format, FormatADI)
+				}
+				assertDocumentEqual(t, got, want)
+			}
+		})
+	}
+}
+
+func TestReadParallel_TagsWithinValues(t *testing.T) {
+	setParallelChunkSize(t, 1)
+	big := strings.Repeat("<EOR>\r\n", 20000) // longer than the walker's buffer
+	adi := "Preamble text\n<PROGRAMID:4>Test<EOH>\n" +
+		"<CALL:5>K9CTS<COMMENT:5><EOR><EOR>\n" +
+		"<CALL:5>W9PVA<NOTES:11>x\n<eoh>\n<eor><APP_LOTW_EOF><EOR>\n" +
+		"<CALL:5>N9XYZ<NOTES:" + strconv.Itoa(len(big)) + ">" + big + "<EOR>\n"
+	want, err := readSequential([]byte(adi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseParallel([]byte(adi), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Records) != 3 {
+		t.Fatalf("got %d records, want 3", len(got.Records))
+	}
+	assertDocumentEqual(t, got, want)
+}
+
+func TestReadParallel_Empty(t *testing.T) {
+	d, err := ParseParallel(nil, 2)
+	if err != nil || d.Header != nil || len(d.Records) != 0 {
+		t.Errorf("got %+v, %v", d, err)
+	}
+}
+
+func TestReadParallel_ParseErrorPosition(t *testing.T) {
+	tests := map[string]string{
+		"first line of chunk": "<EOH>\n<CALL:5>K9CTS<EOR>\n<CALL:5>W9PVA<EOR>   <CALL:X>N9XYZ<EOR>\n",
+		"later line of chunk": "<EOH>\n<CALL:5>K9CTS<EOR>\n<CALL:5>W9PVA\n<CALL:X>N9XYZ<EOR>\n",
+		"truncated value":     "<PROGRAMID:4>Test<EOH>\n<CALL:5>K9CTS<EOR>\n<CALL:5>W9PVA<EOR>\n<CALL:10>N9XYZ",
+		"truncated specifier": "<CALL:5>K9CTS<EOR>\n<CALL:5>W9PVA<EOR>\n<CALL",
+		"empty field name":    "<CALL:5>K9CTS<EOR>\n<:5>W9PVA<EOR>\n<CALL:5>N9XYZ<EOR>\n",
+	}
+	for name, adi := range tests {
+		t.Run(name, func(t *testing.T) {
+			setParallelChunkSize(t, 1)
+			_, want := readSequential([]byte(adi))
+			var wantPE *ParseError
+			if !errors.As(want, &wantPE) {
+				t.Fatalf("sequential: got %v, want a *ParseError", want)
+			}
+			d, err := ParseParallel([]byte(adi), 2)
+			var pe *ParseError
+			if d != nil || !errors.As(err, &pe) {
+				t.Fatalf("got %v, %v; want a *ParseError", d, err)
+			}
+			if *pe != *wantPE {
+				t.Errorf("got %+v, want %+v", *pe, *wantPE)
+			}
+		})
+	}
+}
+
+func TestReadParallel_UnexpectedHeader(t *testing.T) {
+	tests := map[string]int64{
+		"<CALL:5>K9CTS<EOR>\n<PROGRAMID:4>Test<EOH>\n":   1,
+		"<CALL:5>K9CTS<EOR>\n<PROGRAMID:4>Test<EOH>\n ":  1 << 20,
+		"<PROGRAMID:4>Test<EOH>\n<ADIF_VER:5>3.1.6<EOH>": 1 << 20,
+	}
+	for adi, chunkSize := range tests {
+		setParallelChunkSize(t, chunkSize)
+		if _, err := readSequential([]byte(adi)); err != ErrUnexpectedHeader {
+			t.Fatalf("%q sequential: got %v, want ErrUnexpectedHeader", adi, err)
+		}
+		if d, err := ParseParallel([]byte(adi), 2); d != nil || err != ErrUnexpectedHeader {
+			t.Errorf("%q: got %v, %v; want ErrUnexpectedHeader", adi, d, err)
+		}
+	}
+}
+
+func TestReadParallel_ReadError(t *testing.T) {
+	setParallelChunkSize(t, 16*1024)
+	data, err := testFileFS.ReadFile("testdata/N3FJP-AClogAdif.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &mockFailReaderAt{backingData: data, failAt: int64(len(data) / 2)}
+	if d, err := ReadParallel(r, int64(len(data)), 4); d != nil || !errors.Is(err, errMockReadAt) {
+		t.Errorf("got %v, %v; want errMockReadAt", d, err)
+	}
+}
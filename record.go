@@ -2,10 +2,16 @@ package adif
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/mode"
 )
 
 // Record is a map of ADIF fields to their values, representing either a header or QSO record.
@@ -43,13 +49,22 @@ func (r Record) WriteTo(w io.Writer) (int64, error) {
 // WriteToMode writes the record's fields in ADI format to w using the given WriteMode, without an EOR or EOH tag.
 func (r Record) WriteToMode(w io.Writer, mode WriteMode) (int64, error) {
 	bufPtr := writerBufPool.Get().(*[]byte)
-	buf := appendFieldsADI(r, (*bufPtr)[:0], mode)
+	buf := appendFieldsADI(r, (*bufPtr)[:0], mode, nil, false)
 	n, err := w.Write(buf)
 	*bufPtr = buf
 	writerBufPool.Put(bufPtr)
 	return int64(n), err
 }
 
+// MarshalJSON implements json.Marshaler, encoding the Record as a JSON object of string values.
+// Fields are written in the pretty order of WriteModePretty, so the output is deterministic. A nil Record is encoded as null.
+func (r Record) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("null"), nil
+	}
+	return appendRecordJSON(nil, r, false), nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler, allowing a Record to be unmarshaled from a JSON object with string keys and values.
 // It ensures that field keys are normalized to uppercase and converted to adifield.Field types.
 func (r *Record) UnmarshalJSON(data []byte) error {
@@ -63,3 +78,141 @@ func (r *Record) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// Time combines the DATE in dateField and the TIME in timeField into a UTC time.Time,
+// e.g. r.Time(adifield.QSO_DATE, adifield.TIME_ON).
+// Times in HHMM format are treated as having zero seconds.
+// The error wraps ErrFieldNotFound if either field is empty, or ErrInvalidValue if either value is malformed.
+func (r Record) Time(dateField, timeField adifield.Field) (time.Time, error) {
+	d, err := r.value(dateField, aditype.DATE, isADIFDate)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := r.value(timeField, aditype.TIME, isADIFTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return adifTime(d, t), nil
+}
+
+// adifTime combines a valid DATE and a valid TIME into a UTC time.Time.
+// An empty tod means midnight.
+func adifTime(date, tod string) time.Time {
+	tod += "000000"[len(tod):]
+	return time.Date(atoi(date[:4]), time.Month(atoi(date[4:6])), atoi(date[6:]),
+		atoi(tod[:2]), atoi(tod[2:4]), atoi(tod[4:6]), 0, time.UTC)
+}
+
+// Float returns the NUMBER in field as a float64.
+// The error wraps ErrFieldNotFound if the field is empty, or ErrInvalidValue if the value is malformed.
+func (r Record) Float(field adifield.Field) (float64, error) {
+	v, err := r.value(field, aditype.NUMBER, isADIFNumber)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, invalidValue(field, v, aditype.NUMBER)
+	}
+	return f, nil
+}
+
+// Int returns the INTEGER in field as an int.
+// The error wraps ErrFieldNotFound if the field is empty, or ErrInvalidValue if the value is malformed or overflows an int.
+func (r Record) Int(field adifield.Field) (int, error) {
+	v, err := r.value(field, aditype.INTEGER, isADIFInteger)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, invalidValue(field, v, aditype.INTEGER)
+	}
+	return n, nil
+}
+
+// Bool returns the BOOLEAN in field; Y and y are true, N and n are false.
+// The error wraps ErrFieldNotFound if the field is empty, or ErrInvalidValue if the value is malformed.
+func (r Record) Bool(field adifield.Field) (bool, error) {
+	v, err := r.value(field, aditype.BOOLEAN, isADIFBoolean)
+	if err != nil {
+		return false, err
+	}
+	return v == "Y" || v == "y", nil
+}
+
+// Band returns the BAND of the record, normalized to uppercase.
+// The error wraps ErrFieldNotFound if BAND is empty, or ErrInvalidValue if it is not a member of the Band enumeration.
+func (r Record) Band() (band.Band, error) {
+	v, err := r.value(adifield.BAND, adifield.BAND, func(s string) bool {
+		_, ok := band.Lookup(band.New(s))
+		return ok
+	})
+	return band.New(v), err
+}
+
+// Mode returns the MODE of the record, normalized to uppercase.
+// The error wraps ErrFieldNotFound if MODE is empty, or ErrInvalidValue if it is not a member of the Mode enumeration.
+func (r Record) Mode() (mode.Mode, error) {
+	v, err := r.value(adifield.MODE, adifield.MODE, func(s string) bool {
+		_, ok := mode.Lookup(mode.New(s))
+		return ok
+	})
+	return mode.New(v), err
+}
+
+// SetTime stores t, converted to UTC, in dateField as YYYYMMDD and in timeField as HHMMSS,
+// e.g. r.SetTime(adifield.QSO_DATE, adifield.TIME_ON, t).
+func (r Record) SetTime(dateField, timeField adifield.Field, t time.Time) {
+	t = t.UTC()
+	r[dateField] = t.Format("20060102")
+	r[timeField] = t.Format("150405")
+}
+
+// SetFloat stores f in field as a NUMBER, using the fewest digits that represent it exactly and never exponent notation.
+// f must be finite; NaN and infinities have no ADIF representation.
+func (r Record) SetFloat(field adifield.Field, f float64) {
+	r[field] = strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// SetInt stores n in field as an INTEGER.
+func (r Record) SetInt(field adifield.Field, n int) {
+	r[field] = strconv.Itoa(n)
+}
+
+// SetBool stores b in field as a BOOLEAN, Y or N.
+func (r Record) SetBool(field adifield.Field, b bool) {
+	if b {
+		r[field] = "Y"
+	} else {
+		r[field] = "N"
+	}
+}
+
+// SetBand stores b in the BAND field.
+func (r Record) SetBand(b band.Band) {
+	r[adifield.BAND] = string(b)
+}
+
+// SetMode stores m in the MODE field.
+func (r Record) SetMode(m mode.Mode) {
+	r[adifield.MODE] = string(m)
+}
+
+// value returns the value of field after checking it with valid.
+// kind names the expected data type or enumeration in the error.
+func (r Record) value(field adifield.Field, kind fmt.Stringer, valid func(string) bool) (string, error) {
+	v := r[field]
+	if v == "" {
+		return "", fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+	}
+	if !valid(v) {
+		return "", invalidValue(field, v, kind)
+	}
+	return v, nil
+}
+
+// invalidValue returns an error wrapping ErrInvalidValue for value in field.
+func invalidValue(field adifield.Field, value string, kind fmt.Stringer) error {
+	return fmt.Errorf("%w: %s %q is not a valid %s", ErrInvalidValue, field, value, kind)
+}
@@ -2,11 +2,14 @@ package adif
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/farmergreg/spec/v6/adifield"
 	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/mode"
 )
 
 func TestNewRecord(t *testing.T) {
@@ -112,6 +115,24 @@ func TestRecord_WriteTo_Empty(t *testing.T) {
 	}
 }
 
+func TestRecord_MarshalJSON(t *testing.T) {
+	r := Record{"APP_B": "2", adifield.CALL: "K9CTS", "APP_A": "", adifield.QSO_DATE: "20240101", adifield.COMMENT: "\"hi\""}
+	for range 10 {
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"QSO_DATE":"20240101","CALL":"K9CTS","APP_A":"","APP_B":"2","COMMENT":"\"hi\""}`; string(data) != want {
+			t.Fatalf("got %s, want %s", data, want)
+		}
+	}
+
+	data, err := json.Marshal(map[string]Record{"nil": nil, "empty": {}})
+	if want := `{"empty":{},"nil":null}`; err != nil || string(data) != want {
+		t.Errorf("got %s, %v; want %s", data, err, want)
+	}
+}
+
 func TestRecord_UnmarshalJSON_Valid(t *testing.T) {
 	var r Record
 	if err := json.Unmarshal([]byte(`{"CALL":"K9CTS","BAND":"20M"}`), &r); err != nil {
@@ -161,3 +182,160 @@ func TestRecord_UnmarshalJSON_NonStringValue(t *testing.T) {
 		t.Error("expected error for non-string value, got nil")
 	}
 }
+
+func TestRecord_Time(t *testing.T) {
+	tests := []struct {
+		date, time string
+		want       time.Time
+		err        error
+	}{
+		{"20240229", "235959", time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC), nil},
+		{"19300101", "0000", time.Date(1930, 1, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"", "1200", time.Time{}, ErrFieldNotFound},
+		{"20240101", "", time.Time{}, ErrFieldNotFound},
+		{"20230229", "1200", time.Time{}, ErrInvalidValue},
+		{"20240101", "2400", time.Time{}, ErrInvalidValue},
+		{"20240101", "12000", time.Time{}, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		r := Record{adifield.QSO_DATE: tt.date, adifield.TIME_ON: tt.time}
+		got, err := r.Time(adifield.QSO_DATE, adifield.TIME_ON)
+		if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("%s %s: got error %v, want %v", tt.date, tt.time, err, tt.err)
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("%s %s: got %v, want %v", tt.date, tt.time, got, tt.want)
+		}
+	}
+}
+
+func TestRecord_Float(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		err   error
+	}{
+		{"14.074", 14.074, nil},
+		{"-.5", -0.5, nil},
+		{"7", 7, nil},
+		{"", 0, ErrFieldNotFound},
+		{"1e3", 0, ErrInvalidValue},
+		{"14,074", 0, ErrInvalidValue},
+		{strings.Repeat("9", 400), 0, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		got, err := Record{adifield.FREQ: tt.value}.Float(adifield.FREQ)
+		if got != tt.want || !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("%q: got %v, %v; want %v, %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRecord_Int(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+		err   error
+	}{
+		{"05", 5, nil},
+		{"-12", -12, nil},
+		{"", 0, ErrFieldNotFound},
+		{"+5", 0, ErrInvalidValue},
+		{"5.0", 0, ErrInvalidValue},
+		{strings.Repeat("9", 20), 0, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		got, err := Record{adifield.CQZ: tt.value}.Int(adifield.CQZ)
+		if got != tt.want || !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("%q: got %v, %v; want %v, %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRecord_Bool(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+		err   error
+	}{
+		{"Y", true, nil},
+		{"y", true, nil},
+		{"N", false, nil},
+		{"n", false, nil},
+		{"", false, ErrFieldNotFound},
+		{"yes", false, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		got, err := Record{adifield.FORCE_INIT: tt.value}.Bool(adifield.FORCE_INIT)
+		if got != tt.want || !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("%q: got %v, %v; want %v, %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRecord_BandMode(t *testing.T) {
+	r := Record{adifield.BAND: "20m", adifield.MODE: "ft8"}
+	if b, err := r.Band(); b != band.BAND_20M || err != nil {
+		t.Errorf("Band: got %q, %v", b, err)
+	}
+	if m, err := r.Mode(); m != mode.FT8 || err != nil {
+		t.Errorf("Mode: got %q, %v", m, err)
+	}
+
+	r = Record{adifield.BAND: "20"}
+	if b, err := r.Band(); b != "" || !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("invalid Band: got %q, %v", b, err)
+	}
+	if m, err := r.Mode(); m != "" || !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("missing Mode: got %q, %v", m, err)
+	}
+}
+
+func TestRecord_TypedAccessors_ErrorMessages(t *testing.T) {
+	r := Record{adifield.QSO_DATE: "20230230", adifield.BAND: "20"}
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{func() error { _, err := r.Time(adifield.QSO_DATE, adifield.TIME_ON); return err }(), `invalid field value: QSO_DATE "20230230" is not a valid DATE`},
+		{func() error { _, err := r.Band(); return err }(), `invalid field value: BAND "20" is not a valid BAND`},
+		{func() error { _, err := r.Float(adifield.FREQ); return err }(), "field not found: FREQ"},
+	}
+	for _, tt := range tests {
+		if tt.err == nil || tt.err.Error() != tt.want {
+			t.Errorf("got %v, want %q", tt.err, tt.want)
+		}
+	}
+}
+
+func TestRecord_Setters(t *testing.T) {
+	r := NewRecord()
+	r.SetTime(adifield.QSO_DATE, adifield.TIME_ON, time.Date(2024, 3, 5, 1, 2, 3, 999, time.FixedZone("CST", -6*3600)))
+	r.SetFloat(adifield.FREQ, 14.074)
+	r.SetFloat(adifield.FREQ_RX, 1e21)
+	r.SetFloat(adifield.RX_PWR, 0.000001)
+	r.SetInt(adifield.CQZ, -4)
+	r.SetBool(adifield.FORCE_INIT, true)
+	r.SetBool(adifield.SWL, false)
+	r.SetBand(band.BAND_40M)
+	r.SetMode(mode.CW)
+
+	want := Record{
+		adifield.QSO_DATE:   "20240305",
+		adifield.TIME_ON:    "070203",
+		adifield.FREQ:       "14.074",
+		adifield.FREQ_RX:    "1000000000000000000000",
+		adifield.RX_PWR:     "0.000001",
+		adifield.CQZ:        "-4",
+		adifield.FORCE_INIT: "Y",
+		adifield.SWL:        "N",
+		adifield.BAND:       "40M",
+		adifield.MODE:       "CW",
+	}
+	assertRecordEqual(t, r, want)
+
+	got, err := r.Time(adifield.QSO_DATE, adifield.TIME_ON)
+	if err != nil || !got.Equal(time.Date(2024, 3, 5, 7, 2, 3, 0, time.UTC)) {
+		t.Errorf("round trip: got %v, %v", got, err)
+	}
+}
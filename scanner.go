@@ -3,11 +3,15 @@ package adif
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
+	"iter"
+	"slices"
 	"strings"
 	"unsafe"
 
 	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
 )
 
 // scannerArenaChunkSize is the size of each value arena chunk.
@@ -15,6 +19,9 @@ import (
 // A new chunk is allocated only when the next value does not fit, so committed bytes are never moved or mutated.
 const scannerArenaChunkSize = 16384
 
+// defaultMaxUniqueFields is the number of distinct field names, including EOR and EOH, that a Scanner accepts by default.
+const defaultMaxUniqueFields = 1025
+
 // Scanner reads ADIF *.adi records sequentially from an io.Reader.
 // Use NewScanner to create one, then call Scan in a loop.
 // It follows the same pattern as bufio.Scanner.
@@ -32,23 +39,87 @@ type Scanner struct {
 	r                 *bufio.Reader
 	appFieldMap       map[string]adifield.Field
 	arena             []byte
+	scratch           []byte // value buffer for Visit when a value exceeds the bufio.Reader size
 	preAllocateFields int
 	current           Record
+	types             map[adifield.Field]aditype.DataTypeIndicator
+	preamble          string
 	isHeader          bool
 	err               error
+	onMalformed       func(*ParseError)
+	keep              map[adifield.Field]bool // fields retained by SetProjection, or nil for all
+	limits            ScannerOptions
+	repairLengths     bool // set by SetLengthRepair
+	// specifierEndedRecord is set when a malformed data specifier ran on into an <EOR> or <EOH> tag,
+	// so that lenient scanning resumes right after it instead of skipping the following record.
+	specifierEndedRecord bool
+	charset              *charset // set by SetEncoding, or nil for UTF-8
+	decoded              []byte   // reusable buffer for values decoded by Visit
+
+	// Resource use counted against limits.
+	qsoRecords   int   // QSO records read so far
+	recordStart  int64 // offset at which the current record began
+	recordFields int   // data specifiers read in the current record
+
+	// Position tracking for ParseError.
+	offset         int64 // bytes consumed from r
+	line           int   // one-based line number at offset
+	lineStart      int64 // offset of the first byte of the current line
+	records        int   // records read so far, including the header and skipped records
+	fieldOffset    int64 // offset of the '<' that started the current field
+	fieldLine      int   // line number at fieldOffset
+	fieldLineStart int64 // lineStart at fieldOffset
+}
+
+// ScannerOptions limits the resources a Scanner may use, for reading input from untrusted sources.
+// A zero field leaves that limit at the default of NewScanner, which is unlimited except for MaxUniqueFields.
+// Exceeding a limit stops the scan with a *ParseError wrapping the sentinel error named below;
+// such errors are never recovered from by SetRecoveryHandler.
+type ScannerOptions struct {
+	// MaxFieldLength is the largest data length, in bytes, accepted for a single field value (ErrFieldTooLong).
+	MaxFieldLength int
+
+	// MaxFieldsPerRecord is the largest number of fields accepted in a single record, excluding EOR and EOH (ErrTooManyFields).
+	MaxFieldsPerRecord int
+
+	// MaxRecordBytes is the largest number of input bytes accepted for a single record (ErrRecordTooLarge).
+	// It includes the text between records and, for the header, its preamble.
+	MaxRecordBytes int64
+
+	// MaxRecords is the largest number of QSO records accepted from the input, excluding the header (ErrTooManyRecords).
+	MaxRecords int
+
+	// MaxUniqueFields is the largest number of distinct field names accepted from the input,
+	// including EOR and EOH (ErrTooManyUniqueFields). The default is 1025.
+	MaxUniqueFields int
 }
 
 // NewScanner returns a Scanner that reads ADI records from r.
 func NewScanner(r io.Reader) *Scanner {
+	return NewScannerWithOptions(r, ScannerOptions{})
+}
+
+// NewScannerWithOptions returns a Scanner that reads ADI records from r within the limits set by opts.
+//
+//	s := adif.NewScannerWithOptions(req.Body, adif.ScannerOptions{
+//	    MaxFieldLength: 64 * 1024,
+//	    MaxRecords:     100_000,
+//	})
+func NewScannerWithOptions(r io.Reader, opts ScannerOptions) *Scanner {
 	br, ok := r.(*bufio.Reader)
 	if !ok {
 		br = bufio.NewReader(r)
 	}
+	if opts.MaxUniqueFields <= 0 {
+		opts.MaxUniqueFields = defaultMaxUniqueFields
+	}
 	return &Scanner{
 		r:                 br,
 		preAllocateFields: 7,
 		appFieldMap:       make(map[string]adifield.Field, 128),
 		arena:             make([]byte, 0, scannerArenaChunkSize),
+		line:              1,
+		limits:            opts,
 	}
 }
 
@@ -58,7 +129,48 @@ func NewScanner(r io.Reader) *Scanner {
 // After Scan returns false, call Err to check for any non-EOF error.
 func (s *Scanner) Scan() bool {
 	s.current, s.isHeader, s.err = s.next()
-	return s.err == nil
+	if s.err != nil {
+		return false
+	}
+	s.records++
+	return true
+}
+
+// ScanContext is like Scan, but returns false once ctx is done; Err then returns ctx.Err().
+func (s *Scanner) ScanContext(ctx context.Context) bool {
+	if err := ctx.Err(); err != nil {
+		s.current, s.isHeader, s.err = nil, false, err
+		return false
+	}
+	return s.Scan()
+}
+
+// SetRecoveryHandler enables lenient scanning and returns the Scanner for chaining.
+// When fn is non-nil, a record containing malformed ADI is skipped instead of stopping the scan:
+// fn is called with the *ParseError describing the problem, input is discarded up to and including
+// the next <EOR> or <EOH> tag, and scanning resumes with the following record.
+// Errors for exceeded ScannerOptions limits, ErrTooManyUniqueFields and I/O errors are never recovered from.
+// Pass nil to restore the default behavior of stopping at the first error.
+func (s *Scanner) SetRecoveryHandler(fn func(*ParseError)) *Scanner {
+	s.onMalformed = fn
+	return s
+}
+
+// SetProjection limits the records read by Scan to the given fields and returns the Scanner for chaining.
+// The values of all other fields are skipped in the input without being copied, which reduces memory use and CPU time
+// when only a few fields are needed. Visit does not report them either.
+// The projection applies to the header as well; include header fields such as PROGRAMID to retain them.
+// Call with no fields to read complete records again.
+func (s *Scanner) SetProjection(fields ...adifield.Field) *Scanner {
+	if len(fields) == 0 {
+		s.keep = nil
+		return s
+	}
+	s.keep = make(map[adifield.Field]bool, len(fields))
+	for _, field := range fields {
+		s.keep[adifield.New(string(field))] = true
+	}
+	return s
 }
 
 // Record returns the record from the most recent successful Scan call.
@@ -67,8 +179,29 @@ func (s *Scanner) Record() Record { return s.current }
 // IsHeader reports whether the record from the most recent Scan call is a header record.
 func (s *Scanner) IsHeader() bool { return s.isHeader }
 
+// DataTypeIndicator returns the data type indicator given for field in the record from the most recent Scan call
+// (e.g. 'N' for <EPC:5:N>), or aditype.DATATYPEINDICATOR_NONE when the data specifier had none.
+func (s *Scanner) DataTypeIndicator(field adifield.Field) aditype.DataTypeIndicator {
+	return s.types[field]
+}
+
+// DataTypeIndicators returns the data type indicators given in the record from the most recent Scan call,
+// keyed by field, or nil when none of its data specifiers had one.
+// Indicators are normalized to uppercase.
+func (s *Scanner) DataTypeIndicators() map[adifield.Field]aditype.DataTypeIndicator { return s.types }
+
+// Preamble returns the free-form text that precedes the first data specifier of the input,
+// such as the comments loggers write before the header fields (e.g. "Generated by MonoLog\n").
+// Per the ADIF specification, input that does not begin with '<' has a header, and this text is its preamble.
+// Returns an empty string when the input begins with '<' or before the first call to Scan.
+// A UTF-8 byte order mark at the start of the input is not part of the preamble.
+// Text between fields is not retained.
+func (s *Scanner) Preamble() string { return s.preamble }
+
 // Err returns the first non-EOF error encountered by the Scanner.
 // Returns nil when Scan stopped due to io.EOF.
+// Parse failures are reported as a *ParseError wrapping ErrMalformedADI, ErrTooManyUniqueFields,
+// or the error of an exceeded ScannerOptions limit.
 func (s *Scanner) Err() error {
 	if s.err == io.EOF {
 		return nil
@@ -76,23 +209,84 @@ func (s *Scanner) Err() error {
 	return s.err
 }
 
+// All returns an iterator over the remaining records, including the header, and the error that ends the scan.
+func (s *Scanner) All() iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for s.Scan() {
+			if !yield(s.current, nil) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// QSOs returns an iterator over the remaining QSO records, skipping the header; call Err after the loop.
+func (s *Scanner) QSOs() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		for s.Scan() {
+			if !s.isHeader && !yield(s.current) {
+				return
+			}
+		}
+	}
+}
+
+// Decode unmarshals the next QSO record into v as Unmarshal does, and returns io.EOF after the last one.
+func (s *Scanner) Decode(v any) error {
+	for s.Scan() {
+		if !s.isHeader {
+			return Unmarshal(s.current, v)
+		}
+	}
+	return s.err
+}
+
 // next reads the next record from the underlying reader.
 // It returns the record along with a boolean indicating whether it's a header record.
 // It returns an error if the ADI is malformed or an I/O error occurs.
 func (s *Scanner) next() (Record, bool, error) {
+	if s.offset == 0 {
+		if err := s.readPreamble(); err != nil {
+			return nil, false, err
+		}
+	}
+
 	result := make(Record, s.preAllocateFields)
+	s.types = nil
+	s.startRecord()
 	for {
 		if err := s.discardUntilLessThan(); err != nil {
 			return nil, false, err
 		}
+		s.fieldOffset, s.fieldLine, s.fieldLineStart = s.offset-1, s.line, s.lineStart
 
-		field, value, err := s.parseOneField()
+		field, dataType, value, err := s.parseOneField()
 		if err != nil {
+			err = s.newParseError(field, err)
+			if pe, ok := err.(*ParseError); ok && pe.Err == ErrMalformedADI && s.onMalformed != nil {
+				s.onMalformed(pe)
+				s.records++
+				if s.specifierEndedRecord {
+					s.specifierEndedRecord = false
+				} else if err := s.resync(); err != nil {
+					return nil, false, err
+				}
+				s.startRecord()
+				clear(result)
+				s.types = nil
+				continue
+			}
 			return nil, false, err
 		}
 
 		switch field {
 		case adifield.EOR:
+			if err := s.countQSORecord(); err != nil {
+				return nil, false, err
+			}
 			s.preAllocateFields = len(result)
 			return result, false, nil
 		case adifield.EOH:
@@ -100,32 +294,82 @@ func (s *Scanner) next() (Record, bool, error) {
 		}
 
 		if value != "" {
-			result[field] = value
+			result[field] = s.decodeString(value)
+			if dataType != aditype.DATATYPEINDICATOR_NONE {
+				if s.types == nil {
+					s.types = make(map[adifield.Field]aditype.DataTypeIndicator)
+				}
+				s.types[field] = dataType
+			}
 		}
 	}
 }
 
 // parseOneField reads the next field specifier and value from the underlying reader.
 // It is heavily optimized for speed and memory use.
-func (s *Scanner) parseOneField() (field adifield.Field, value string, err error) {
+// dataType is aditype.DATATYPEINDICATOR_NONE when the data specifier has no data type indicator.
+// On error, field is returned when it is known so that the error can be reported in context.
+func (s *Scanner) parseOneField() (field adifield.Field, dataType aditype.DataTypeIndicator, value string, err error) {
+	field, dataType, length, err := s.readFieldSpecifier()
+	if err != nil || length < 1 {
+		return field, dataType, "", err
+	}
+	if s.keep != nil && !s.keep[field] {
+		if s.skipValue(length) != nil {
+			return field, dataType, "", ErrMalformedADI
+		}
+		return field, dataType, "", nil
+	}
+
+	// Values larger than an arena chunk get their own buffer, grown only as the input delivers data
+	// so that a declared length far beyond the actual input cannot force a huge allocation.
+	if length > scannerArenaChunkSize {
+		buf, err := s.readValueGrowing(nil, length)
+		if err != nil {
+			return field, dataType, "", err
+		}
+		return field, dataType, unsafe.String(&buf[0], length), nil
+	}
+
+	// Read exactly length bytes of field value into the arena.
+	// Values are referenced as strings pointing into the arena, avoiding a per-value allocation.
+	// A fresh chunk is allocated only when the value does not fit, so previously committed bytes never move.
+	if cap(s.arena)-len(s.arena) < length {
+		s.arena = make([]byte, 0, scannerArenaChunkSize)
+	}
+	start := len(s.arena)
+
+	c, err := io.ReadFull(s.r, s.arena[start:start+length])
+	s.advance(s.arena[start : start+c])
+	if c != length {
+		return field, dataType, "", ErrMalformedADI
+	}
+	s.arena = s.arena[:start+length]
+	return field, dataType, unsafe.String(&s.arena[start], length), err
+}
+
+// readFieldSpecifier reads the next data specifier, leaving the reader at the first byte of the field value.
+// The field name is interned in appFieldMap. length is 0 for specifiers without a length, such as <EOR>.
+// On error, field is returned when it is known so that the error can be reported in context.
+func (s *Scanner) readFieldSpecifier() (field adifield.Field, dataType aditype.DataTypeIndicator, length int, err error) {
 	// Step 1: Read "<fieldname:length:type>" removing the trailing '>'.
 	volatileSpecifier, err := s.readDataSpecifierVolatile()
 	if err != nil {
-		return "", "", err
+		return "", 0, 0, err
 	}
 
 	// Step 2: Split on the first colon to get field name and length.
 	volatileField, volatileLength, foundFirstColon := bytes.Cut(volatileSpecifier, []byte(":"))
 	if len(volatileField) == 0 {
-		return "", "", ErrMalformedADI
+		return "", 0, 0, s.malformedSpecifier(volatileSpecifier)
 	}
 
 	// Step 2.1: Intern the field name string to avoid repeated allocations.
 	var ok bool
 	fieldStringUnsafe := unsafe.String(&volatileField[0], len(volatileField))
 	if field, ok = s.appFieldMap[fieldStringUnsafe]; !ok {
-		if len(s.appFieldMap) > 1024 {
-			return "", "", ErrTooManyUniqueFields
+		if len(s.appFieldMap) >= s.limits.MaxUniqueFields {
+			return adifield.New(strings.Clone(fieldStringUnsafe)), 0, 0, ErrTooManyUniqueFields
 		}
 		fieldStringSafe := strings.Clone(fieldStringUnsafe)
 		field = adifield.New(fieldStringSafe)
@@ -134,41 +378,63 @@ func (s *Scanner) parseOneField() (field adifield.Field, value string, err error
 
 	if !foundFirstColon {
 		// EOH, EOR, and LoTW's non-standard APP_LOTW_EOF all lack a colon.
-		return field, "", nil
+		return field, 0, 0, nil
 	}
 
-	// Step 3: Strip optional single-character data type indicator (e.g. ":S").
-	if idx := len(volatileLength) - 2; idx > 0 && volatileLength[idx] == ':' {
-		volatileLength = volatileLength[:idx]
+	// Step 3: Parse the field length and the optional data type indicator.
+	length, dataType, err = parseDataLengthAndType(volatileLength)
+	if err != nil {
+		return field, dataType, 0, s.malformedSpecifier(volatileSpecifier)
 	}
 
-	// Step 4: Parse the field length.
-	length, err := parseDataLength(volatileLength)
-	if err != nil {
-		return "", "", err
+	if s.repairLengths && length > 0 {
+		length = s.repairLength(length)
 	}
-	if length < 1 {
-		return field, "", nil
+
+	// Step 4: Enforce the limits before any of the value is read.
+	s.recordFields++
+	switch {
+	case s.limits.MaxFieldLength > 0 && length > s.limits.MaxFieldLength:
+		return field, dataType, 0, ErrFieldTooLong
+	case s.limits.MaxFieldsPerRecord > 0 && s.recordFields > s.limits.MaxFieldsPerRecord:
+		return field, dataType, 0, ErrTooManyFields
+	case s.limits.MaxRecordBytes > 0 && s.offset+int64(length)-s.recordStart > s.limits.MaxRecordBytes:
+		return field, dataType, 0, ErrRecordTooLarge
 	}
+	return field, dataType, length, nil
+}
 
-	// Step 5: Read exactly length bytes of field value into the arena.
-	// Values are referenced as strings pointing into the arena, avoiding a per-value allocation.
-	// A fresh chunk is allocated only when the value does not fit, so previously committed bytes never move.
-	if cap(s.arena)-len(s.arena) < length {
-		chunkSize := scannerArenaChunkSize
-		if length > chunkSize {
-			chunkSize = length
+// readValueGrowing reads length bytes of field value, appending them to buf.
+// buf grows only as data arrives, so the allocation is bounded by the input actually present.
+func (s *Scanner) readValueGrowing(buf []byte, length int) ([]byte, error) {
+	buf = buf[:0]
+	for len(buf) < length {
+		if len(buf) == cap(buf) {
+			buf = slices.Grow(buf, min(max(len(buf), scannerArenaChunkSize), length-len(buf)))
+		}
+		n, err := s.r.Read(buf[len(buf):min(cap(buf), length)])
+		s.advance(buf[len(buf) : len(buf)+n])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			return buf, ErrMalformedADI
 		}
-		s.arena = make([]byte, 0, chunkSize)
 	}
-	start := len(s.arena)
+	return buf, nil
+}
 
-	c, err := io.ReadFull(s.r, s.arena[start:start+length])
-	if c != length {
-		return "", "", ErrMalformedADI
+// startRecord resets the per-record limit counters at the start of a record.
+func (s *Scanner) startRecord() {
+	s.recordStart, s.recordFields = s.offset, 0
+}
+
+// countQSORecord counts a completed QSO record against MaxRecords.
+// It returns a *ParseError wrapping ErrTooManyRecords when the record exceeds the limit.
+func (s *Scanner) countQSORecord() error {
+	if s.limits.MaxRecords > 0 && s.qsoRecords >= s.limits.MaxRecords {
+		return s.newParseError(adifield.EOR, ErrTooManyRecords)
 	}
-	s.arena = s.arena[:start+length]
-	return field, unsafe.String(&s.arena[start], length), err
+	s.qsoRecords++
+	return nil
 }
 
 // readDataSpecifierVolatile reads up to and including the next '>' and returns
@@ -189,6 +455,7 @@ func (s *Scanner) readDataSpecifierVolatile() ([]byte, error) {
 	var accumulator []byte
 	for {
 		volatile, err := s.r.ReadSlice('>')
+		s.advance(volatile)
 		if err == nil {
 			if accumulator != nil {
 				volatile = append(accumulator, volatile...)
@@ -196,6 +463,9 @@ func (s *Scanner) readDataSpecifierVolatile() ([]byte, error) {
 			return volatile[:len(volatile)-1], nil // strip trailing '>'
 		}
 		if err == bufio.ErrBufferFull {
+			if s.limits.MaxRecordBytes > 0 && s.offset-s.recordStart > s.limits.MaxRecordBytes {
+				return nil, ErrRecordTooLarge
+			}
 			accumulator = append(accumulator, volatile...)
 			continue
 		}
@@ -208,13 +478,125 @@ func (s *Scanner) readDataSpecifierVolatile() ([]byte, error) {
 
 // discardUntilLessThan reads and discards bytes until '<' is found.
 func (s *Scanner) discardUntilLessThan() error {
-	_, err := s.r.ReadSlice('<')
+	volatile, err := s.r.ReadSlice('<')
+	s.advance(volatile)
 	for err == bufio.ErrBufferFull {
-		_, err = s.r.ReadSlice('<')
+		volatile, err = s.r.ReadSlice('<')
+		s.advance(volatile)
 	}
 	return err
 }
 
+// skipValue discards the next n bytes, counting the line breaks within them.
+func (s *Scanner) skipValue(n int) error {
+	for n > 0 {
+		volatile, err := s.r.Peek(min(n, s.r.Size()))
+		s.advance(volatile)
+		n -= len(volatile)
+		_, _ = s.r.Discard(len(volatile)) // cannot fail for bytes returned by Peek
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPreamble reads the text preceding the first '<' of the input into preamble.
+// The '<' itself is left unread so that the first field is parsed normally.
+func (s *Scanner) readPreamble() error {
+	s.skipBOM()
+	var accumulator []byte
+	for {
+		volatile, err := s.r.ReadSlice('<')
+		if err == bufio.ErrBufferFull {
+			s.advance(volatile)
+			if s.limits.MaxRecordBytes > 0 && s.offset > s.limits.MaxRecordBytes {
+				return s.newParseError("", ErrRecordTooLarge)
+			}
+			accumulator = append(accumulator, volatile...)
+			continue
+		}
+		if err == nil {
+			volatile = volatile[:len(volatile)-1]
+			_ = s.r.UnreadByte() // cannot fail directly after a successful ReadSlice
+		}
+		s.advance(volatile)
+		s.preamble = s.decodeString(string(append(accumulator, volatile...)))
+		return err
+	}
+}
+
+// malformedSpecifier returns ErrMalformedADI for a data specifier that cannot be parsed.
+// A specifier missing its '>', as in <BAND:3 20M<EOR>, is read up to the '>' of the following tag;
+// when that tag is <EOR> or <EOH>, the end of the record has already been consumed and specifierEndedRecord is set.
+func (s *Scanner) malformedSpecifier(volatileSpecifier []byte) error {
+	if n := len(volatileSpecifier); n >= 4 && volatileSpecifier[n-4] == '<' {
+		tag := volatileSpecifier[n-3:]
+		s.specifierEndedRecord = bytes.EqualFold(tag, []byte("EOR")) || bytes.EqualFold(tag, []byte("EOH"))
+	}
+	return ErrMalformedADI
+}
+
+// resync discards input up to and including the next <EOR> or <EOH> tag.
+// It is used by lenient scanning to resume at the record following a malformed one.
+func (s *Scanner) resync() error {
+	for {
+		if err := s.discardUntilLessThan(); err != nil {
+			return err
+		}
+		tag, err := s.r.Peek(4)
+		if len(tag) == 4 && tag[3] == '>' && (bytes.EqualFold(tag[:3], []byte("EOR")) || bytes.EqualFold(tag[:3], []byte("EOH"))) {
+			s.advance(tag)
+			_, err = s.r.Discard(4)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// advance records that b was consumed from the underlying reader, updating the position used by ParseError.
+func (s *Scanner) advance(b []byte) {
+	s.offset += int64(len(b))
+	// IndexByte is vectorized; most specifiers and values contain no line breaks at all.
+	if bytes.IndexByte(b, '\n') < 0 {
+		return
+	}
+	s.line += bytes.Count(b, []byte{'\n'})
+	s.lineStart = s.offset - int64(len(b)) + int64(bytes.LastIndexByte(b, '\n')) + 1
+}
+
+// newParseError wraps err in a *ParseError describing the field that was being read.
+// Errors other than ErrMalformedADI, ErrTooManyUniqueFields and the limit errors (e.g. I/O errors) are returned unchanged.
+func (s *Scanner) newParseError(field adifield.Field, err error) error {
+	switch err {
+	case ErrMalformedADI, ErrTooManyUniqueFields, ErrFieldTooLong, ErrTooManyFields, ErrRecordTooLarge, ErrTooManyRecords:
+	default:
+		return err
+	}
+	return &ParseError{
+		Offset: s.fieldOffset,
+		Line:   s.fieldLine,
+		Column: int(s.fieldOffset-s.fieldLineStart) + 1,
+		Record: s.records,
+		Field:  field,
+		Err:    err,
+	}
+}
+
+// parseDataLengthAndType parses the part of a data specifier that follows the field name and its colon,
+// e.g. "5" or "5:N", into the data length and the optional single-character data type indicator.
+func parseDataLengthAndType(volatileLength []byte) (int, aditype.DataTypeIndicator, error) {
+	dataType := aditype.DATATYPEINDICATOR_NONE
+	if idx := len(volatileLength) - 2; idx > 0 && volatileLength[idx] == ':' {
+		dataType = aditype.NewDataTypeIndicator(rune(volatileLength[idx+1]))
+		volatileLength = volatileLength[:idx]
+	}
+	length, err := parseDataLength(volatileLength)
+	return length, dataType, err
+}
+
 // parseDataLength converts an ASCII decimal byte slice to an int.
 // It is an optimized, allocation-free replacement for strconv.Atoi.
 func parseDataLength(data []byte) (int, error) {
@@ -2,12 +2,19 @@ package adif
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
 )
 
 //go:embed testdata/*.adi
@@ -232,7 +239,7 @@ func TestScannerParseNoRecords(t *testing.T) {
 			if s.Scan() {
 				t.Fatal("expected no records")
 			}
-			if s.Err() != tt.expectedErr {
+			if !errors.Is(s.Err(), tt.expectedErr) {
 				t.Errorf("Err(): got %v, want %v", s.Err(), tt.expectedErr)
 			}
 			if s.Record() != nil {
@@ -355,7 +362,7 @@ func TestScannerTooManyUniqueFields(t *testing.T) {
 	if s.Scan() {
 		t.Error("expected Scan to return false")
 	}
-	if s.Err() != ErrTooManyUniqueFields {
+	if !errors.Is(s.Err(), ErrTooManyUniqueFields) {
 		t.Errorf("Err(): got %v, want %v", s.Err(), ErrTooManyUniqueFields)
 	}
 	if s.Record() != nil {
@@ -363,6 +370,251 @@ func TestScannerTooManyUniqueFields(t *testing.T) {
 	}
 }
 
+func TestScannerTooManyUniqueFields_FieldNotVolatile(t *testing.T) {
+	// A 16 byte buffer is refilled by the reads that follow the error.
+	br := bufio.NewReaderSize(strings.NewReader("<A:1>x<B:1>y<ZZZZ:1>z<EOR>"+strings.Repeat("<CALL:4>W1AW<EOR>", 4)), 16)
+	s := NewScannerWithOptions(br, ScannerOptions{MaxUniqueFields: 2})
+	for s.Scan() {
+	}
+	var pe *ParseError
+	if !errors.As(s.Err(), &pe) || pe.Field != "ZZZZ" {
+		t.Fatalf("Err(): got %v, want a *ParseError for ZZZZ", s.Err())
+	}
+	for range 40 {
+		if _, err := br.ReadByte(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pe.Field != "ZZZZ" {
+		t.Errorf("Field changed to %q after further reads", pe.Field)
+	}
+}
+
+func TestScannerParseError_Position(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		offset int64
+		line   int
+		column int
+		record int
+		field  adifield.Field
+		err    error
+	}{
+		{"Bad length first record", "<CALL:X>W9PVA<EOR>", 0, 1, 1, 0, adifield.CALL, ErrMalformedADI},
+		{"Bad length after header", "<PROGRAMID:4>TEST<EOH>\n<CALL:5>W9PVA<EOR>\n  <BAND:x>20M<EOR>", 44, 3, 3, 2, adifield.BAND, ErrMalformedADI},
+		{"Truncated value", "preamble\r\n<EOH>\r\n<CALL:5>W9PVA<EOR><COMMENT:99>short", 35, 3, 19, 2, adifield.COMMENT, ErrMalformedADI},
+		{"Multi-line value", "<COMMENT:3>a\nb<NOTES:9><EOR>", 14, 2, 2, 0, adifield.NOTES, ErrMalformedADI},
+		{"Unterminated specifier", "\n\n<CALL:5>W9PVA<EOR><CALL", 20, 3, 19, 1, "", ErrMalformedADI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(tt.data))
+			for s.Scan() {
+			}
+			var pe *ParseError
+			if !errors.As(s.Err(), &pe) {
+				t.Fatalf("expected *ParseError, got %v", s.Err())
+			}
+			if !errors.Is(pe, tt.err) {
+				t.Errorf("Err: got %v, want %v", pe.Err, tt.err)
+			}
+			if pe.Offset != tt.offset || pe.Line != tt.line || pe.Column != tt.column {
+				t.Errorf("position: got offset=%d line=%d column=%d, want offset=%d line=%d column=%d",
+					pe.Offset, pe.Line, pe.Column, tt.offset, tt.line, tt.column)
+			}
+			if pe.Record != tt.record {
+				t.Errorf("Record: got %d, want %d", pe.Record, tt.record)
+			}
+			if pe.Field != tt.field {
+				t.Errorf("Field: got %q, want %q", pe.Field, tt.field)
+			}
+			if pe.Error() == "" {
+				t.Error("expected non-empty error message")
+			}
+		})
+	}
+}
+
+func TestScannerParseError_TooManyUniqueFieldsField(t *testing.T) {
+	var sb strings.Builder
+	for i := range 1026 {
+		fmt.Fprintf(&sb, "<app_test_%04d:1>X", i)
+	}
+	s := NewScanner(strings.NewReader(sb.String()))
+	for s.Scan() {
+	}
+	var pe *ParseError
+	if !errors.As(s.Err(), &pe) {
+		t.Fatalf("expected *ParseError, got %v", s.Err())
+	}
+	if pe.Field != "APP_TEST_1025" {
+		t.Errorf("Field: got %q, want %q", pe.Field, "APP_TEST_1025")
+	}
+}
+
+func TestScannerRecoveryHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		calls   []string
+		records int
+		errors  int
+	}{
+		{"No errors", "<CALL:5>W9PVA<EOR><CALL:5>K9CTS<EOR>", []string{"W9PVA", "K9CTS"}, 2, 0},
+		{"Bad length", "<CALL:5>W9PVA<EOR><CALL:X>BAD<EOR><CALL:5>K9CTS<EOR>", []string{"W9PVA", "K9CTS"}, 2, 1},
+		{"Bad length lowercase eor", "<CALL:X>BAD<eor>\n<CALL:5>K9CTS<EOR>", []string{"K9CTS"}, 1, 1},
+		{"Consecutive bad records", "<CALL:X><EOR><BAND:Y><EOR><CALL:5>K9CTS<EOR>", []string{"K9CTS"}, 1, 2},
+		{"Resync at EOH", "<PROGRAMID:X>BAD<EOH><CALL:5>K9CTS<EOR>", []string{"K9CTS"}, 1, 1},
+		{"Resync ignores other tags", "<CALL:X> < <EO <EOF> <EORX> <EOR><CALL:5>K9CTS<EOR>", []string{"K9CTS"}, 1, 1},
+		{"Truncated final record", "<CALL:5>W9PVA<EOR><CALL:50>W1AW", []string{"W9PVA"}, 1, 1},
+		{"Unterminated final specifier", "<CALL:5>W9PVA<EOR><CALL", []string{"W9PVA"}, 1, 1},
+		{"Resync reaches EOF", "<CALL:X>BAD <EO", nil, 0, 1},
+		{"Unterminated specifier before EOR", "<CALL:5>K9CTS<BAND:3 20M<EOR><CALL:4>W1AW<EOR><CALL:4>N0CA<EOR>", []string{"W1AW", "N0CA"}, 2, 1},
+		{"Unterminated specifier before EOH", "<PROGRAMID:4 TEST<eoh><CALL:4>W1AW<EOR>", []string{"W1AW"}, 1, 1},
+		{"Empty field name before EOR", "<:3 X<EOR><CALL:4>W1AW<EOR>", []string{"W1AW"}, 1, 1},
+		{"Unterminated specifier before other tag", "<CALL:5 K9CTS<BAND:3>20M<EOR><CALL:4>W1AW<EOR>", []string{"W1AW"}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []*ParseError
+			br := bufio.NewReaderSize(strings.NewReader(tt.data), 16)
+			s := NewScanner(br).SetRecoveryHandler(func(pe *ParseError) {
+				errs = append(errs, pe)
+			})
+			var calls []string
+			for s.Scan() {
+				if s.IsHeader() {
+					continue
+				}
+				calls = append(calls, s.Record()[adifield.CALL])
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(calls) != tt.records || strings.Join(calls, ",") != strings.Join(tt.calls, ",") {
+				t.Errorf("calls: got %v, want %v", calls, tt.calls)
+			}
+			if len(errs) != tt.errors {
+				t.Fatalf("errors: got %d, want %d", len(errs), tt.errors)
+			}
+			for _, pe := range errs {
+				if !errors.Is(pe, ErrMalformedADI) {
+					t.Errorf("got %v, want ErrMalformedADI", pe)
+				}
+			}
+		})
+	}
+}
+
+func TestScannerRecoveryHandler_RecordIndex(t *testing.T) {
+	adi := "<PROGRAMID:4>TEST<EOH><CALL:5>W9PVA<EOR><CALL:X>BAD<EOR><CALL:5>K9CTS<EOR><CALL:Y>BAD<EOR>"
+	var got []int
+	s := NewScanner(strings.NewReader(adi)).SetRecoveryHandler(func(pe *ParseError) {
+		got = append(got, pe.Record)
+	})
+	for s.Scan() {
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("record indexes: got %v, want [2 4]", got)
+	}
+}
+
+func TestScannerRecoveryHandler_TooManyUniqueFieldsIsFatal(t *testing.T) {
+	var sb strings.Builder
+	for i := range 1026 {
+		fmt.Fprintf(&sb, "<APP_TEST_%04d:1>X", i)
+	}
+	sb.WriteString("<EOR>")
+
+	called := false
+	s := NewScanner(strings.NewReader(sb.String())).SetRecoveryHandler(func(*ParseError) { called = true })
+	for s.Scan() {
+	}
+	if !errors.Is(s.Err(), ErrTooManyUniqueFields) {
+		t.Errorf("Err(): got %v, want %v", s.Err(), ErrTooManyUniqueFields)
+	}
+	if called {
+		t.Error("recovery handler must not be called for ErrTooManyUniqueFields")
+	}
+}
+
+func TestScannerRecoveryHandler_IOError(t *testing.T) {
+	mock := &mockFailReader{
+		maxBytes:    12,
+		backingData: []byte("<CALL:X>BAD" + strings.Repeat(" ", 100) + "<EOR>"),
+	}
+	s := NewScanner(mock).SetRecoveryHandler(func(*ParseError) {})
+	if s.Scan() {
+		t.Error("expected Scan to return false")
+	}
+	if s.Err() == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func TestScannerDataTypeIndicator(t *testing.T) {
+	adi := "<USERDEF1:3:n>EPC<EOH><EPC:5:n>32123<CALL:5>K9CTS<APP_X_Y:0:S><EOR><CALL:1:s>Y<EOR><CALL:1>Z<EOR>"
+	s := NewScanner(strings.NewReader(adi))
+
+	if !s.Scan() || !s.IsHeader() {
+		t.Fatalf("expected header; Err=%v", s.Err())
+	}
+	if got := s.DataTypeIndicator(adifield.USERDEF1); got != aditype.DATATYPEINDICATOR_NUMBER {
+		t.Errorf("USERDEF1: got %q, want %q", got, aditype.DATATYPEINDICATOR_NUMBER)
+	}
+
+	if !s.Scan() {
+		t.Fatalf("expected record; Err=%v", s.Err())
+	}
+	if got := s.DataTypeIndicator(adifield.New("EPC")); got != aditype.DATATYPEINDICATOR_NUMBER {
+		t.Errorf("EPC: got %q, want %q", got, aditype.DATATYPEINDICATOR_NUMBER)
+	}
+	if got := s.DataTypeIndicator(adifield.CALL); got != aditype.DATATYPEINDICATOR_NONE {
+		t.Errorf("CALL: got %q, want none", got)
+	}
+	// Empty values are not part of the record, so neither are their indicators.
+	if len(s.DataTypeIndicators()) != 1 {
+		t.Errorf("DataTypeIndicators: got %v, want only EPC", s.DataTypeIndicators())
+	}
+	if s.Record()[adifield.New("EPC")] != "32123" {
+		t.Errorf("EPC value: got %q, want %q", s.Record()[adifield.New("EPC")], "32123")
+	}
+
+	if !s.Scan() {
+		t.Fatalf("expected record; Err=%v", s.Err())
+	}
+	if got := s.DataTypeIndicator(adifield.CALL); got != aditype.DATATYPEINDICATOR_STRING {
+		t.Errorf("CALL: got %q, want %q", got, aditype.DATATYPEINDICATOR_STRING)
+	}
+
+	if !s.Scan() {
+		t.Fatalf("expected record; Err=%v", s.Err())
+	}
+	if s.DataTypeIndicators() != nil {
+		t.Errorf("DataTypeIndicators: got %v, want nil", s.DataTypeIndicators())
+	}
+}
+
+func TestScannerDataTypeIndicator_ClearedOnRecovery(t *testing.T) {
+	adi := "<CALL:5:S>K9CTS<BAND:X>20M<EOR><CALL:4>W1AW<EOR>"
+	s := NewScanner(strings.NewReader(adi)).SetRecoveryHandler(func(*ParseError) {})
+	if !s.Scan() {
+		t.Fatalf("expected record; Err=%v", s.Err())
+	}
+	if s.Record()[adifield.CALL] != "W1AW" {
+		t.Fatalf("CALL: got %q, want %q", s.Record()[adifield.CALL], "W1AW")
+	}
+	if s.DataTypeIndicators() != nil {
+		t.Errorf("DataTypeIndicators: got %v, want nil", s.DataTypeIndicators())
+	}
+}
+
 func TestScannerReadDataSpecifierVolatile_IOError(t *testing.T) {
 	mock := &mockFailReader{
 		maxBytes:    5,
@@ -376,3 +628,378 @@ func TestScannerReadDataSpecifierVolatile_IOError(t *testing.T) {
 		t.Error("expected an error, got nil")
 	}
 }
+
+func TestScannerPreamble(t *testing.T) {
+	long := strings.Repeat("x", 10_000) + "\n"
+	tests := []struct {
+		name string
+		adi  string
+		want string
+	}{
+		{"No preamble", "<PROGRAMID:4>Test<EOH><CALL:5>W9PVA<EOR>", ""},
+		{"Newline only", "\n<EOH><CALL:5>W9PVA<EOR>", "\n"},
+		{"Comment lines", "Generated by MonoLog\r\nRecord Count: 1\r\n\r\n<EOH><CALL:5>W9PVA<EOR>", "Generated by MonoLog\r\nRecord Count: 1\r\n\r\n"},
+		{"Longer than the read buffer", long + "<EOH><CALL:5>W9PVA<EOR>", long},
+		{"Text between fields is not retained", "preamble\n<PROGRAMID:4>Test\nfree text\n<EOH>", "preamble\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(tt.adi))
+			for s.Scan() {
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Preamble(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScannerPreamble_TestFiles(t *testing.T) {
+	tests := map[string]string{
+		"lotwreport.adi":  "QSL RX SINCE: 2018-06-02 00:00:00 (user supplied value)",
+		"qrz.adi":         "Bookid: 277131",
+		"skcc-logger.adi": "Record Count: 15",
+	}
+	for file, want := range tests {
+		t.Run(file, func(t *testing.T) {
+			f, err := testFileFS.Open("testdata/" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			s := NewScanner(f)
+			if !s.Scan() || !s.IsHeader() {
+				t.Fatalf("expected header; Err=%v", s.Err())
+			}
+			if !strings.Contains(s.Preamble(), want) {
+				t.Errorf("preamble %q does not contain %q", s.Preamble(), want)
+			}
+		})
+	}
+}
+
+func TestScannerPreamble_NoDataSpecifier(t *testing.T) {
+	s := NewScanner(strings.NewReader("only text"))
+	if s.Scan() {
+		t.Fatal("expected no records")
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Preamble(); got != "only text" {
+		t.Errorf("got %q, want %q", got, "only text")
+	}
+}
+
+func TestScannerDecode(t *testing.T) {
+	adi := "<PROGRAMID:7>MonoLog<EOH>\n<CALL:5>K9CTS<CQZ:1>4<EOR>\n<CALL:5>W9PVA<CQZ:2>99<EOR>\n<CALL:5>N9XYZ<EOR>"
+	s := NewScanner(strings.NewReader(adi))
+
+	var q struct {
+		Call string
+		CQZ  uint8
+	}
+	if err := s.Decode(&q); err != nil || q.Call != "K9CTS" || q.CQZ != 4 {
+		t.Fatalf("first: got %+v, %v", q, err)
+	}
+	if err := s.Decode(&q); err != nil || q.Call != "W9PVA" || q.CQZ != 99 {
+		t.Fatalf("second: got %+v, %v", q, err)
+	}
+	if err := s.Decode(&q); err != nil || q.Call != "N9XYZ" || q.CQZ != 99 {
+		t.Fatalf("third: got %+v, %v", q, err)
+	}
+	if err := s.Decode(&q); err != io.EOF {
+		t.Fatalf("end: got %v, want io.EOF", err)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = NewScanner(strings.NewReader("<CQZ:1>x<EOR><CALL:X>K9CTS<EOR>"))
+	if err := s.Decode(&q); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("invalid value: got %v, want ErrInvalidValue", err)
+	}
+	if err := s.Decode(&q); !errors.Is(err, ErrMalformedADI) {
+		t.Errorf("malformed: got %v, want ErrMalformedADI", err)
+	}
+}
+
+func TestScannerAll(t *testing.T) {
+	adi := "<PROGRAMID:7>MonoLog<EOH><CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>"
+	s := NewScanner(strings.NewReader(adi))
+	var calls []string
+	headers := 0
+	for r, err := range s.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.IsHeader() {
+			headers++
+			continue
+		}
+		calls = append(calls, r[adifield.CALL])
+	}
+	if headers != 1 || !slices.Equal(calls, []string{"K9CTS", "W9PVA"}) {
+		t.Errorf("got %d headers and calls %v", headers, calls)
+	}
+
+	// Breaking out of the loop leaves the remaining records to later calls.
+	s = NewScanner(strings.NewReader(adi))
+	for range s.All() {
+		break
+	}
+	if got := slices.Collect(s.QSOs()); len(got) != 2 {
+		t.Errorf("after break: got %d QSOs, want 2", len(got))
+	}
+}
+
+func TestScannerAll_Error(t *testing.T) {
+	s := NewScanner(strings.NewReader("<CALL:5>K9CTS<EOR><CALL:X>W9PVA<EOR>"))
+	var errs []error
+	n := 0
+	for r, err := range s.All() {
+		if err != nil {
+			if r != nil {
+				t.Errorf("got record %v with error", r)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	if n != 1 || len(errs) != 1 || !errors.Is(errs[0], ErrMalformedADI) {
+		t.Errorf("got %d records and errors %v", n, errs)
+	}
+}
+
+func TestScannerQSOs(t *testing.T) {
+	s := NewScanner(strings.NewReader("<PROGRAMID:7>MonoLog<EOH><CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR><CALL:X>"))
+	var calls []string
+	for r := range s.QSOs() {
+		calls = append(calls, r[adifield.CALL])
+	}
+	if !slices.Equal(calls, []string{"K9CTS", "W9PVA"}) {
+		t.Errorf("got %v", calls)
+	}
+	if !errors.Is(s.Err(), ErrMalformedADI) {
+		t.Errorf("Err: got %v, want ErrMalformedADI", s.Err())
+	}
+
+	s = NewScanner(strings.NewReader("<CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>"))
+	for r := range s.QSOs() {
+		if r[adifield.CALL] != "K9CTS" {
+			t.Errorf("got %v", r)
+		}
+		break
+	}
+}
+
+func TestScannerScanContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewScanner(strings.NewReader("<PROGRAMID:7>MonoLog<EOH><CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>"))
+	if !s.ScanContext(ctx) || !s.IsHeader() {
+		t.Fatalf("expected header; Err=%v", s.Err())
+	}
+	if !s.ScanContext(ctx) || s.Record()[adifield.CALL] != "K9CTS" {
+		t.Fatalf("expected K9CTS; Err=%v", s.Err())
+	}
+	cancel()
+	if s.ScanContext(ctx) {
+		t.Fatal("expected ScanContext to stop after cancel")
+	}
+	if !errors.Is(s.Err(), context.Canceled) || s.Record() != nil || s.IsHeader() {
+		t.Errorf("got Err %v, Record %v", s.Err(), s.Record())
+	}
+}
+
+func TestScannerSetProjection(t *testing.T) {
+	keep := []adifield.Field{adifield.CALL, adifield.QSO_DATE, adifield.BAND, adifield.MODE, "qsl_rcvd", adifield.PROGRAMID}
+	data, err := testFileFS.ReadFile("testdata/lotwreport.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := readSequential(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project := func(r Record) Record {
+		projected := Record{}
+		for _, field := range keep {
+			field = adifield.New(string(field))
+			if r[field] != "" {
+				projected[field] = r[field]
+			}
+		}
+		return projected
+	}
+
+	s := NewScanner(bytes.NewReader(data)).SetProjection(keep...)
+	n := 0
+	for s.Scan() {
+		if s.IsHeader() {
+			assertRecordEqual(t, s.Record(), project(want.Header))
+			continue
+		}
+		assertRecordEqual(t, s.Record(), project(want.Records[n]))
+		n++
+	}
+	if err := s.Err(); err != nil || n != len(want.Records) {
+		t.Fatalf("got %d records, %v; want %d", n, err, len(want.Records))
+	}
+
+	// An empty projection restores complete records.
+	s = NewScanner(bytes.NewReader(data)).SetProjection(keep...).SetProjection()
+	for s.Scan() && s.IsHeader() {
+	}
+	assertRecordEqual(t, s.Record(), want.Records[0])
+}
+
+func TestScannerSetProjection_DataTypesAndLineBreaks(t *testing.T) {
+	adi := "<NOTES:6:M>a\r\nb\r\n<CALL:5:S>K9CTS<EOR>\n<NOTES:3>x\r\n<CALL:5>W9PVA<BAND:X>20m<EOR>"
+	s := NewScanner(strings.NewReader(adi)).SetProjection(adifield.CALL)
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	assertRecordEqual(t, s.Record(), Record{adifield.CALL: "K9CTS"})
+	if s.DataTypeIndicator(adifield.NOTES) != aditype.DATATYPEINDICATOR_NONE || s.DataTypeIndicator(adifield.CALL) != 'S' {
+		t.Errorf("got indicators %v", s.DataTypeIndicators())
+	}
+	if s.Scan() {
+		t.Fatalf("expected an error, got %v", s.Record())
+	}
+	// Skipped values still count toward the position of a ParseError.
+	var pe *ParseError
+	if !errors.As(s.Err(), &pe) || pe.Line != 5 || pe.Column != 14 || pe.Field != adifield.BAND {
+		t.Errorf("got %v", s.Err())
+	}
+}
+
+func TestScannerSetProjection_TruncatedValue(t *testing.T) {
+	adi := "<CALL:5>K9CTS<EOR>\n<NOTES:100>truncated"
+	for _, visit := range []bool{false, true} {
+		s := NewScanner(strings.NewReader(adi)).SetProjection(adifield.CALL)
+		var err error
+		if visit {
+			err = s.Visit(&countVisitor{})
+		} else {
+			for s.Scan() {
+			}
+			err = s.Err()
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Field != adifield.NOTES || pe.Line != 2 {
+			t.Errorf("visit %v: got %v", visit, err)
+		}
+	}
+}
+
+func TestScannerSetProjection_Visit(t *testing.T) {
+	adi := "<PROGRAMID:4>Test<EOH><CALL:5>K9CTS<NOTES:4>skip<BAND:3>20m<EOR>"
+	var v recordVisitor
+	if err := NewScanner(strings.NewReader(adi)).SetProjection(adifield.CALL, adifield.BAND).Visit(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.header != nil || len(v.records) != 1 {
+		t.Fatalf("got header %v, %d records", v.header, len(v.records))
+	}
+	assertRecordEqual(t, v.records[0], Record{adifield.CALL: "K9CTS", adifield.BAND: "20m"})
+}
+
+func TestScannerOptions_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   ScannerOptions
+		data   string
+		offset int64
+		record int
+		field  adifield.Field
+		err    error
+	}{
+		{"Field length", ScannerOptions{MaxFieldLength: 5}, "<CALL:5>K9CTS<EOR><CALL:6>K9CTS1<EOR>", 18, 1, adifield.CALL, ErrFieldTooLong},
+		{"Fields per record", ScannerOptions{MaxFieldsPerRecord: 2}, "<CALL:5>K9CTS<BAND:3>20m<EOR><CALL:5>K9CTS<BAND:3>20m<MODE:3>SSB<EOR>", 53, 1, adifield.MODE, ErrTooManyFields},
+		{"Record bytes", ScannerOptions{MaxRecordBytes: 30}, "<CALL:5>K9CTS<BAND:3>20m<EOR>\n<CALL:5>K9CTS<NOTES:10>0123456789<EOR>", 43, 1, adifield.NOTES, ErrRecordTooLarge},
+		{"Record bytes in specifier", ScannerOptions{MaxRecordBytes: 100}, "<CALL:5>K9CTS<EOR><" + strings.Repeat("A", 5000) + ":1>X<EOR>", 18, 1, "", ErrRecordTooLarge},
+		{"Record bytes in preamble", ScannerOptions{MaxRecordBytes: 100}, strings.Repeat("x", 5000) + "<EOH>", 0, 0, "", ErrRecordTooLarge},
+		{"Records", ScannerOptions{MaxRecords: 1}, "<PROGRAMID:4>Test<EOH><CALL:5>K9CTS<EOR><CALL:5>W9PVA<EOR>", 53, 2, adifield.EOR, ErrTooManyRecords},
+		{"Unique fields", ScannerOptions{MaxUniqueFields: 3}, "<CALL:5>K9CTS<EOR><BAND:3>20m<MODE:3>SSB<EOR>", 29, 1, adifield.MODE, ErrTooManyUniqueFields},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScannerWithOptions(strings.NewReader(tt.data), tt.opts).SetRecoveryHandler(func(pe *ParseError) {
+				t.Errorf("recovery handler called for %v", pe)
+			})
+			for s.Scan() {
+			}
+			visitErr := NewScannerWithOptions(strings.NewReader(tt.data), tt.opts).Visit(&countVisitor{})
+			for _, err := range []error{s.Err(), visitErr} {
+				var pe *ParseError
+				if !errors.As(err, &pe) {
+					t.Fatalf("expected *ParseError, got %v", err)
+				}
+				if !errors.Is(err, tt.err) || pe.Offset != tt.offset || pe.Record != tt.record || pe.Field != tt.field {
+					t.Errorf("got %+v, want offset %d, record %d, field %q, %v", pe, tt.offset, tt.record, tt.field, tt.err)
+				}
+			}
+		})
+	}
+}
+
+func TestScannerOptions_WithinLimits(t *testing.T) {
+	opts := ScannerOptions{MaxFieldLength: 5, MaxFieldsPerRecord: 2, MaxRecordBytes: 29, MaxRecords: 2, MaxUniqueFields: 5}
+	data := "<PROGRAMID:4>Test<EOH><CALL:5>K9CTS<BAND:3>20m<EOR><CALL:5>W9PVA<BAND:3>40m<EOR>"
+	s := NewScannerWithOptions(strings.NewReader(data), opts)
+	var records int
+	for s.Scan() {
+		records++
+	}
+	if s.Err() != nil || records != 3 {
+		t.Errorf("got %d records, %v", records, s.Err())
+	}
+
+	var v countVisitor
+	if err := NewScannerWithOptions(strings.NewReader(data), opts).Visit(&v); err != nil || v.records != 2 {
+		t.Errorf("Visit: got %d records, %v", v.records, err)
+	}
+}
+
+func TestScannerOptions_Defaults(t *testing.T) {
+	s := NewScannerWithOptions(strings.NewReader(""), ScannerOptions{})
+	if want := (ScannerOptions{MaxUniqueFields: defaultMaxUniqueFields}); s.limits != want {
+		t.Errorf("got %+v, want %+v", s.limits, want)
+	}
+	if NewScanner(strings.NewReader("")).limits != s.limits {
+		t.Error("NewScanner limits differ from the zero ScannerOptions")
+	}
+}
+
+func TestScannerHugeDeclaredLength(t *testing.T) {
+	adi := "<CALL:5>K9CTS<EOR><NOTES:999999999>" + strings.Repeat("x", 100000)
+	for _, visit := range []bool{false, true} {
+		s := NewScanner(strings.NewReader(adi))
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		var err error
+		if visit {
+			err = s.Visit(&countVisitor{})
+		} else {
+			for s.Scan() {
+			}
+			err = s.Err()
+		}
+		runtime.ReadMemStats(&after)
+		if !errors.Is(err, ErrMalformedADI) {
+			t.Errorf("visit %v: got %v, want ErrMalformedADI", visit, err)
+		}
+		// Allocation follows the input actually read, not the declared length.
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("visit %v: allocated %d bytes", visit, allocated)
+		}
+	}
+}
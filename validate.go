@@ -0,0 +1,415 @@
+package adif
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/farmergreg/adif/v5/internal/digits"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
+	"github.com/farmergreg/spec/v6/enum/continent"
+)
+
+// Severity classifies how serious a validation Issue is.
+type Severity int
+
+const (
+	// SeverityError marks a value that does not conform to the ADIF specification.
+	SeverityError Severity = iota
+
+	// SeverityWarning marks a value that is allowed but discouraged, such as an import-only field.
+	SeverityWarning
+)
+
+// String returns "error" or "warning".
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Rule identifies the validation check that reported an Issue.
+type Rule string
+
+const (
+	// RuleDataType reports a value that does not match the ADIF data type of its field (e.g. a DATE of 20250230).
+	RuleDataType Rule = "DATATYPE"
+
+	// RuleRange reports a numeric value outside the minimum and maximum the ADIF specification gives for its field.
+	RuleRange Rule = "RANGE"
+
+	// RuleImportOnly reports a field, or an enumeration value, the ADIF specification marks as import-only.
+	RuleImportOnly Rule = "IMPORT_ONLY"
+
+	// RuleEnumeration reports a value that is not a member of the enumeration of its field (e.g. a MODE of USB).
+	// Disabling it also disables the import-only check of enumeration values.
+	RuleEnumeration Rule = "ENUMERATION"
+)
+
+// Issue describes a single problem found by a Validator.
+type Issue struct {
+	Field    adifield.Field
+	Value    string
+	Rule     Rule
+	Severity Severity
+	Message  string
+}
+
+// String returns the issue formatted as "severity: FIELD: message".
+// Implements fmt.Stringer.
+func (i Issue) String() string {
+	return i.Severity.String() + ": " + string(i.Field) + ": " + i.Message
+}
+
+// Validator checks records against the ADIF field specifications published in adifield.
+// Every field known to adifield.Lookup is checked against its aditype.Type and its minimum and maximum values,
+// and fields such as BAND, MODE and QSL_RCVD are checked against their enumerations, ignoring case.
+// Related fields are checked for consistency with each other, such as FREQ with BAND and SUBMODE with MODE.
+// Fields that are not part of the specification, such as APP_ and user-defined fields, are not checked.
+//
+// A Validator is safe for concurrent use once configured.
+type Validator struct {
+	disabled map[Rule]bool
+}
+
+// NewValidator returns a Validator with all rules enabled.
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// SetRuleEnabled enables or disables a rule and returns the Validator for chaining.
+// All rules are enabled by default.
+func (v *Validator) SetRuleEnabled(rule Rule, enabled bool) *Validator {
+	if v.disabled == nil {
+		v.disabled = make(map[Rule]bool)
+	}
+	v.disabled[rule] = !enabled
+	return v
+}
+
+// enabled reports whether rule is enabled.
+func (v *Validator) enabled(rule Rule) bool {
+	return !v.disabled[rule]
+}
+
+// Validate checks every field of r and returns all problems found, or nil when r is valid.
+// Issues are ordered by field name.
+func (v *Validator) Validate(r Record) []Issue {
+	var issues []Issue
+	for field, value := range r {
+		if value == "" {
+			continue
+		}
+		spec, ok := adifield.Lookup(field)
+		if !ok {
+			continue
+		}
+		if bool(spec.IsImportOnly) && v.enabled(RuleImportOnly) {
+			issues = append(issues, Issue{field, value, RuleImportOnly, SeverityWarning, "field is import-only"})
+		}
+		if check, ok := dataTypeChecks[spec.DataType]; ok && !check(value) {
+			if v.enabled(RuleDataType) {
+				issues = append(issues, Issue{field, value, RuleDataType, SeverityError, fmt.Sprintf("%q is not a valid %s", value, spec.DataType)})
+			}
+			continue
+		}
+		if msg, ok := checkRange(spec, value); !ok && v.enabled(RuleRange) {
+			issues = append(issues, Issue{field, value, RuleRange, SeverityError, msg})
+		}
+		if check, ok := fieldEnumerations[field]; ok && v.enabled(RuleEnumeration) {
+			switch member, importOnly := check(value); {
+			case !member:
+				// STRING fields such as CONTEST_ID and SUBMODE only recommend enumeration values for interoperability.
+				severity := SeverityError
+				if spec.DataType == aditype.STRING {
+					severity = SeverityWarning
+				}
+				issues = append(issues, Issue{field, value, RuleEnumeration, severity, fmt.Sprintf("%q is not a valid %s", value, field)})
+			case importOnly && v.enabled(RuleImportOnly):
+				issues = append(issues, Issue{field, value, RuleImportOnly, SeverityWarning, fmt.Sprintf("%q is import-only", value)})
+			}
+		}
+	}
+	for _, c := range crossFieldChecks {
+		if !v.enabled(c.rule) {
+			continue
+		}
+		if issue, found := c.check(r); found {
+			issues = append(issues, issue)
+		}
+	}
+	slices.SortStableFunc(issues, func(a, b Issue) int { return strings.Compare(string(a.Field), string(b.Field)) })
+	return issues
+}
+
+// Validate checks r with a default Validator and returns all problems found, or nil when r is valid.
+func (r Record) Validate() []Issue {
+	return NewValidator().Validate(r)
+}
+
+// dataTypeChecks maps ADIF data types to functions that report whether a value conforms to them.
+// Only the character set of enumerations is checked here; membership is checked by fieldEnumerations.
+var dataTypeChecks = map[aditype.Type]func(string) bool{
+	aditype.BOOLEAN:             isADIFBoolean,
+	aditype.CHARACTER:           func(s string) bool { return len(s) == 1 && isADIFString(s) },
+	aditype.DATE:                isADIFDate,
+	aditype.DIGIT:               func(s string) bool { return len(s) == 1 && digits.Only(s) },
+	aditype.ENUMERATION:         isADIFString,
+	aditype.GRIDSQUARE:          isGridSquare,
+	aditype.GRIDSQUAREEXT:       isGridSquareExt,
+	aditype.GRIDSQUARELIST:      listOf(isGridSquare),
+	aditype.INTEGER:             isADIFInteger,
+	aditype.INTLCHARACTER:       func(s string) bool { return utf8.RuneCountInString(s) == 1 && isADIFIntlString(s) },
+	aditype.INTLMULTILINESTRING: utf8.ValidString,
+	aditype.INTLSTRING:          isADIFIntlString,
+	aditype.IOTAREFNO:           isIOTARefNo,
+	aditype.LOCATION:            isADIFLocation,
+	aditype.MULTILINESTRING:     isADIFMultilineString,
+	aditype.NUMBER:              isADIFNumber,
+	aditype.POSITIVEINTEGER:     isADIFPositiveInteger,
+	aditype.POTAREF:             isPOTARef,
+	aditype.POTAREFLIST:         listOf(isPOTARef),
+	aditype.SECONDARYADMINISTRATIVESUBDIVISIONLISTALT: isADIFString,
+	aditype.SECONDARYSUBDIVISIONLIST:                  isADIFString,
+	aditype.SOTAREF:                                   isSOTARef,
+	aditype.SPONSOREDAWARDLIST:                        isADIFString,
+	aditype.STRING:                                    isADIFString,
+	aditype.TIME:                                      isADIFTime,
+	aditype.WWFFREF:                                   isWWFFRef,
+}
+
+// checkRange reports whether a numeric value lies within the minimum and maximum given by spec.
+// The specification leaves both at zero when a field has no range, and leaves the maximum at zero when it has only a minimum.
+func checkRange(spec adifield.Spec, value string) (string, bool) {
+	min, max := float64(spec.MinimumValue), float64(spec.MaximumValue)
+	if min == 0 && max == 0 {
+		return "", true
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		// Only numeric types carry a range, and their values have already passed the data type check.
+		return "", true
+	}
+	if n < min || (max != 0 && n > max) {
+		if max == 0 {
+			return fmt.Sprintf("%s is less than the minimum of %d", value, spec.MinimumValue), false
+		}
+		return fmt.Sprintf("%s is outside the range %d to %d", value, spec.MinimumValue, spec.MaximumValue), false
+	}
+	return "", true
+}
+
+// listOf returns a check that accepts a comma-delimited list whose items all pass check.
+func listOf(check func(string) bool) func(string) bool {
+	return func(s string) bool {
+		for item := range strings.SplitSeq(s, ",") {
+			if !check(item) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// isADIFBoolean reports whether s is Y, y, N or n.
+func isADIFBoolean(s string) bool {
+	return len(s) == 1 && strings.ContainsRune("YyNn", rune(s[0]))
+}
+
+// isADIFString reports whether s consists only of ASCII characters 32 through 126.
+func isADIFString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 32 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// isADIFMultilineString reports whether s consists only of ASCII characters 32 through 126 and CR LF line breaks.
+func isADIFMultilineString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n':
+			i++
+		case s[i] < 32 || s[i] > 126:
+			return false
+		}
+	}
+	return true
+}
+
+// isADIFIntlString reports whether s is valid UTF-8 without CR or LF characters.
+func isADIFIntlString(s string) bool {
+	return utf8.ValidString(s) && !strings.ContainsAny(s, "\r\n")
+}
+
+// isADIFInteger reports whether s is a sequence of digits optionally preceded by a minus sign.
+func isADIFInteger(s string) bool {
+	return digits.Only(strings.TrimPrefix(s, "-"))
+}
+
+// isADIFPositiveInteger reports whether s is an unsigned sequence of digits with a value greater than zero.
+func isADIFPositiveInteger(s string) bool {
+	return digits.Only(s) && strings.Trim(s, "0") != ""
+}
+
+// isADIFNumber reports whether s is a sequence of digits optionally preceded by a minus sign
+// and optionally including a single decimal point.
+func isADIFNumber(s string) bool {
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	return (whole != "" || fraction != "") &&
+		(whole == "" || digits.Only(whole)) &&
+		(fraction == "" || digits.Only(fraction))
+}
+
+// isADIFDate reports whether s is a YYYYMMDD date on or after 1930-01-01.
+func isADIFDate(s string) bool {
+	if len(s) != 8 || !digits.Only(s) {
+		return false
+	}
+	year, month, day := atoi(s[:4]), atoi(s[4:6]), atoi(s[6:])
+	if year < 1930 || month < 1 || month > 12 || day < 1 {
+		return false
+	}
+	daysInMonth := [...]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}[month-1]
+	if month == 2 && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		daysInMonth = 29
+	}
+	return day <= daysInMonth
+}
+
+// isADIFTime reports whether s is a time in HHMMSS or HHMM format.
+func isADIFTime(s string) bool {
+	if (len(s) != 4 && len(s) != 6) || !digits.Only(s) {
+		return false
+	}
+	if atoi(s[:2]) > 23 || atoi(s[2:4]) > 59 {
+		return false
+	}
+	return len(s) == 4 || atoi(s[4:]) <= 59
+}
+
+// isGridSquare reports whether s is a 2, 4, 6 or 8 character Maidenhead locator.
+func isGridSquare(s string) bool {
+	if len(s) == 0 || len(s) > 8 || len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20 // lower case letters; digits are unaffected
+		switch i / 2 {
+		case 0:
+			if c < 'a' || c > 'r' {
+				return false
+			}
+		case 1, 3:
+			if s[i] < '0' || s[i] > '9' {
+				return false
+			}
+		case 2:
+			if c < 'a' || c > 'x' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isGridSquareExt reports whether s holds characters 9 and 10, or 9 through 12, of a Maidenhead locator.
+func isGridSquareExt(s string) bool {
+	if len(s) != 2 && len(s) != 4 {
+		return false
+	}
+	for i := 0; i < 2; i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'x' {
+			return false
+		}
+	}
+	return len(s) == 2 || digits.Only(s[2:])
+}
+
+// isADIFLocation reports whether s is a latitude or longitude in XDDD MM.MMM format.
+func isADIFLocation(s string) bool {
+	if len(s) != 11 || !strings.ContainsRune("NSEWnsew", rune(s[0])) || s[4] != ' ' || s[7] != '.' {
+		return false
+	}
+	if !digits.Only(s[1:4]) || !digits.Only(s[5:7]) || !digits.Only(s[8:]) {
+		return false
+	}
+	return atoi(s[1:4]) <= 180 && atoi(s[5:7]) <= 59
+}
+
+// isIOTARefNo reports whether s is an IOTA designator in CC-XXX format.
+func isIOTARefNo(s string) bool {
+	cc, number, ok := strings.Cut(s, "-")
+	if !ok || len(number) != 3 || !digits.Only(number) || number == "000" {
+		return false
+	}
+	_, ok = continent.Lookup(continent.New(cc))
+	return ok
+}
+
+// isPOTARef reports whether s is a Parks on the Air reference in xxxx-nnnnn[@yyyyyy] format.
+func isPOTARef(s string) bool {
+	if len(s) < 6 || len(s) > 17 {
+		return false
+	}
+	ref, location, hasLocation := strings.Cut(s, "@")
+	program, number, ok := strings.Cut(ref, "-")
+	if !ok || len(program) < 1 || len(program) > 4 || !isAlphanumeric(program) {
+		return false
+	}
+	if (len(number) != 4 && len(number) != 5) || !digits.Only(number) {
+		return false
+	}
+	return !hasLocation || (len(location) >= 4 && len(location) <= 6 && isAlphanumeric(strings.Replace(location, "-", "", 1)))
+}
+
+// isSOTARef reports whether s is a SOTA reference such as W2/WE-003 or G/LD-003.
+func isSOTARef(s string) bool {
+	association, summit, ok := strings.Cut(s, "/")
+	if !ok || !isAlphanumeric(association) {
+		return false
+	}
+	region, number, ok := strings.Cut(summit, "-")
+	return ok && isAlphanumeric(region) && len(number) == 3 && digits.Only(number)
+}
+
+// isWWFFRef reports whether s is a WWFF reference in xxFF-nnnn format.
+func isWWFFRef(s string) bool {
+	if len(s) < 8 || len(s) > 11 {
+		return false
+	}
+	program, number, ok := strings.Cut(s, "-")
+	if !ok || len(program) < 3 || !strings.EqualFold(program[len(program)-2:], "FF") || !isAlphanumeric(program) {
+		return false
+	}
+	return len(number) == 4 && digits.Only(number)
+}
+
+// isAlphanumeric reports whether s is a non-empty sequence of ASCII letters and digits.
+func isAlphanumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		if (c < 'a' || c > 'z') && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// atoi converts a short sequence of ASCII digits that has already been validated to an int.
+func atoi(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n
+}
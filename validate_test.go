@@ -0,0 +1,304 @@
+package adif
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestValidator_DataTypes(t *testing.T) {
+	tests := []struct {
+		field adifield.Field
+		value string
+		valid bool
+	}{
+		// BOOLEAN
+		{adifield.FORCE_INIT, "Y", true},
+		{adifield.FORCE_INIT, "n", true},
+		{adifield.FORCE_INIT, "YES", false},
+
+		// DATE
+		{adifield.QSO_DATE, "20240229", true},
+		{adifield.QSO_DATE, "20000229", true},
+		{adifield.QSO_DATE, "19300101", true},
+		{adifield.QSO_DATE, "19291231", false},
+		{adifield.QSO_DATE, "20230229", false},
+		{adifield.QSO_DATE, "19000229", false},
+		{adifield.QSO_DATE, "20241301", false},
+		{adifield.QSO_DATE, "20240100", false},
+		{adifield.QSO_DATE, "2024-01-01", false},
+		{adifield.QSO_DATE, "2024010A", false},
+
+		// TIME
+		{adifield.TIME_ON, "2359", true},
+		{adifield.TIME_ON, "235959", true},
+		{adifield.TIME_ON, "2400", false},
+		{adifield.TIME_ON, "2360", false},
+		{adifield.TIME_ON, "235960", false},
+		{adifield.TIME_ON, "12345", false},
+		{adifield.TIME_ON, "12:34", false},
+
+		// NUMBER
+		{adifield.FREQ, "14.074", true},
+		{adifield.FREQ, "-1", true},
+		{adifield.FREQ, ".5", true},
+		{adifield.FREQ, "5.", true},
+		{adifield.FREQ, ".", false},
+		{adifield.FREQ, "-", false},
+		{adifield.FREQ, "1.2.3", false},
+		{adifield.FREQ, "1e6", false},
+		{adifield.FREQ, "+1", false},
+
+		// INTEGER
+		{adifield.K_INDEX, "7", true},
+		{adifield.SFI, "-0", true},
+		{adifield.SFI, "1.0", false},
+		{adifield.SFI, "--1", false},
+		{adifield.SFI, "-", false},
+
+		// POSITIVEINTEGER
+		{adifield.FISTS, "0042", true},
+		{adifield.FISTS, "000", false},
+		{adifield.FISTS, "-1", false},
+
+		// STRING and MULTILINESTRING
+		{adifield.CALL, "K9CTS", true},
+		{adifield.NAME, "Jürgen", false},
+		{adifield.NAME, "tab\t", false},
+		{adifield.NOTES, "line 1\r\nline 2", true},
+		{adifield.NOTES, "line 1\nline 2", false},
+		{adifield.NOTES, "line 1\r", false},
+		{adifield.NOTES, "Jürgen", false},
+
+		// INTLSTRING and INTLMULTILINESTRING
+		{adifield.NAME_INTL, "Jürgen", true},
+		{adifield.NAME_INTL, "line 1\r\nline 2", false},
+		{adifield.NAME_INTL, "\xff", false},
+		{adifield.NOTES_INTL, "Jürgen\r\nline 2", true},
+		{adifield.NOTES_INTL, "\xff", false},
+
+		// GRIDSQUARE, GRIDSQUAREEXT and GRIDSQUARELIST
+		{adifield.GRIDSQUARE, "EN", true},
+		{adifield.GRIDSQUARE, "en34qu", true},
+		{adifield.GRIDSQUARE, "EN34QU12", true},
+		{adifield.GRIDSQUARE, "E", false},
+		{adifield.GRIDSQUARE, "ES", false},
+		{adifield.GRIDSQUARE, "EN3", false},
+		{adifield.GRIDSQUARE, "ENA4", false},
+		{adifield.GRIDSQUARE, "EN34QZ", false},
+		{adifield.GRIDSQUARE, "EN34QU1A", false},
+		{adifield.GRIDSQUARE, "EN34QU12AB", false},
+		{adifield.GRIDSQUARE_EXT, "AB", true},
+		{adifield.GRIDSQUARE_EXT, "ab12", true},
+		{adifield.GRIDSQUARE_EXT, "A", false},
+		{adifield.GRIDSQUARE_EXT, "AZ", false},
+		{adifield.GRIDSQUARE_EXT, "ABCD", false},
+		{adifield.VUCC_GRIDS, "EN34,EN35,EM44,EM45", true},
+		{adifield.VUCC_GRIDS, "EN34,", false},
+
+		// LOCATION
+		{adifield.LAT, "N045 30.123", true},
+		{adifield.LON, "w180 00.000", true},
+		{adifield.LAT, "X045 30.123", false},
+		{adifield.LAT, "N045 60.000", false},
+		{adifield.LON, "E181 00.000", false},
+		{adifield.LAT, "N045.30.123", false},
+		{adifield.LAT, "N045 30,123", false},
+		{adifield.LAT, "N04A 30.123", false},
+		{adifield.LAT, "N045 3A.123", false},
+		{adifield.LAT, "N045 30.12A", false},
+		{adifield.LAT, "N45 30.123", false},
+
+		// IOTAREFNO
+		{adifield.IOTA, "NA-001", true},
+		{adifield.IOTA, "oc-999", true},
+		{adifield.IOTA, "XX-001", false},
+		{adifield.IOTA, "NA-000", false},
+		{adifield.IOTA, "NA-01", false},
+		{adifield.IOTA, "NA-0A1", false},
+		{adifield.IOTA, "NA001", false},
+
+		// POTAREF and POTAREFLIST
+		{adifield.POTA_REF, "K-5033", true},
+		{adifield.POTA_REF, "VE-5082@CA-AB", true},
+		{adifield.POTA_REF, "K-4562@US-CA,8P-0012", true},
+		{adifield.POTA_REF, "K-503", false},
+		{adifield.POTA_REF, "K-503A", false},
+		{adifield.POTA_REF, "K-123456", false},
+		{adifield.POTA_REF, "KKKKK-5033", false},
+		{adifield.POTA_REF, "K_5033", false},
+		{adifield.POTA_REF, "K-5033@US", false},
+		{adifield.POTA_REF, "K-5033@US-C*", false},
+		{adifield.POTA_REF, "K-5033@US-CAXXXX", false},
+
+		// SOTAREF
+		{adifield.SOTA_REF, "W2/WE-003", true},
+		{adifield.SOTA_REF, "G/LD-003", true},
+		{adifield.SOTA_REF, "W2WE-003", false},
+		{adifield.SOTA_REF, "W2/WE003", false},
+		{adifield.SOTA_REF, "W2/WE-03", false},
+		{adifield.SOTA_REF, "W*/WE-003", false},
+		{adifield.SOTA_REF, "/WE-003", false},
+
+		// WWFFREF
+		{adifield.WWFF_REF, "KFF-4655", true},
+		{adifield.WWFF_REF, "3DAFF-0002", true},
+		{adifield.WWFF_REF, "KFF-465", false},
+		{adifield.WWFF_REF, "KXX-4655", false},
+		{adifield.WWFF_REF, "KFF4655", false},
+		{adifield.WWFF_REF, "FF-46555", false},
+		{adifield.WWFF_REF, "K*FF-4655", false},
+		{adifield.WWFF_REF, "KFF-465A", false},
+		{adifield.WWFF_REF, "KFF-46556789", false},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		issues := v.Validate(Record{tt.field: tt.value})
+		if got := len(issues) == 0; got != tt.valid {
+			t.Errorf("%s=%q: got valid=%v, want %v (issues: %v)", tt.field, tt.value, got, tt.valid, issues)
+			continue
+		}
+		if !tt.valid && issues[0].Rule != RuleDataType {
+			t.Errorf("%s=%q: got rule %s, want %s", tt.field, tt.value, issues[0].Rule, RuleDataType)
+		}
+	}
+}
+
+func TestValidator_CharacterTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(string) bool
+		value string
+		valid bool
+	}{
+		{"CHARACTER", dataTypeChecks["CHARACTER"], "A", true},
+		{"CHARACTER", dataTypeChecks["CHARACTER"], "AB", false},
+		{"DIGIT", dataTypeChecks["DIGIT"], "7", true},
+		{"DIGIT", dataTypeChecks["DIGIT"], "A", false},
+		{"INTLCHARACTER", dataTypeChecks["INTLCHARACTER"], "ü", true},
+		{"INTLCHARACTER", dataTypeChecks["INTLCHARACTER"], "üü", false},
+	}
+	for _, tt := range tests {
+		if got := tt.check(tt.value); got != tt.valid {
+			t.Errorf("%s %q: got %v, want %v", tt.name, tt.value, got, tt.valid)
+		}
+	}
+}
+
+func TestValidator_Range(t *testing.T) {
+	tests := []struct {
+		field adifield.Field
+		value string
+		valid bool
+	}{
+		{adifield.CQZ, "1", true},
+		{adifield.CQZ, "40", true},
+		{adifield.CQZ, "41", false},
+		{adifield.ANT_EL, "-90", true},
+		{adifield.ANT_EL, "-90.5", false},
+		{adifield.AGE, "0", true},
+		{adifield.AGE, "121", false},
+		{adifield.IOTA_ISLAND_ID, "99999999", true},
+		{adifield.IOTA_ISLAND_ID, "100000000", false},
+		{adifield.FISTS, "123456789", true},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		issues := v.Validate(Record{tt.field: tt.value})
+		if got := len(issues) == 0; got != tt.valid {
+			t.Errorf("%s=%q: got valid=%v, want %v (issues: %v)", tt.field, tt.value, got, tt.valid, issues)
+			continue
+		}
+		if !tt.valid && issues[0].Rule != RuleRange {
+			t.Errorf("%s=%q: got rule %s, want %s", tt.field, tt.value, issues[0].Rule, RuleRange)
+		}
+	}
+}
+
+func TestValidator_RangeMessages(t *testing.T) {
+	spec, _ := adifield.Lookup(adifield.FISTS)
+	spec.MinimumValue = 5
+	if msg, ok := checkRange(spec, "4"); ok || msg != "4 is less than the minimum of 5" {
+		t.Errorf("got %q, %v", msg, ok)
+	}
+
+	spec, _ = adifield.Lookup(adifield.CQZ)
+	if msg, ok := checkRange(spec, "41"); ok || msg != "41 is outside the range 1 to 40" {
+		t.Errorf("got %q, %v", msg, ok)
+	}
+	if _, ok := checkRange(spec, "not a number"); !ok {
+		t.Error("expected non-numeric values to be left to the data type check")
+	}
+}
+
+func TestRecord_Validate(t *testing.T) {
+	r := Record{
+		adifield.CALL:              "K9CTS",
+		adifield.QSO_DATE:          "20230229",
+		adifield.TIME_ON:           "1523",
+		adifield.CQZ:               "41",
+		adifield.GUEST_OP:          "W9PVA",
+		adifield.COMMENT:           "",
+		adifield.New("APP_X_Y"):    "\xff",
+		adifield.New("NOT_SPEC"):   "\xff",
+		adifield.ANT_AZ:            "abc",
+		adifield.MY_GRIDSQUARE:     "EN34QU",
+		adifield.MY_IOTA_ISLAND_ID: "1",
+	}
+
+	want := []Issue{
+		{adifield.ANT_AZ, "abc", RuleDataType, SeverityError, `"abc" is not a valid NUMBER`},
+		{adifield.CQZ, "41", RuleRange, SeverityError, "41 is outside the range 1 to 40"},
+		{adifield.GUEST_OP, "W9PVA", RuleImportOnly, SeverityWarning, "field is import-only"},
+		{adifield.QSO_DATE, "20230229", RuleDataType, SeverityError, `"20230229" is not a valid DATE`},
+	}
+	if got := r.Validate(); !slices.Equal(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+
+	if got := (Record{adifield.CALL: "K9CTS"}).Validate(); got != nil {
+		t.Errorf("expected no issues, got %v", got)
+	}
+}
+
+func TestIssue_String(t *testing.T) {
+	tests := []struct {
+		issue Issue
+		want  string
+	}{
+		{Issue{adifield.CQZ, "41", RuleRange, SeverityError, "41 is outside the range 1 to 40"}, "error: CQZ: 41 is outside the range 1 to 40"},
+		{Issue{adifield.GUEST_OP, "W9PVA", RuleImportOnly, SeverityWarning, "field is import-only"}, "warning: GUEST_OP: field is import-only"},
+	}
+	for _, tt := range tests {
+		if got := tt.issue.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestValidator_TestFiles(t *testing.T) {
+	// These files contain only values that conform to the specification.
+	for _, file := range []string{"N3FJP-AClogAdif.adi", "lotwreport.adi", "skcc-logger.adi"} {
+		t.Run(file, func(t *testing.T) {
+			f, err := testFileFS.Open("testdata/" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			v := NewValidator()
+			s := NewScanner(f)
+			for s.Scan() {
+				if issues := v.Validate(s.Record()); issues != nil {
+					t.Fatalf("unexpected issues: %v", issues)
+				}
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
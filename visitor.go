@@ -0,0 +1,113 @@
+package adif
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// Visitor receives the fields of ADI records as Scanner.Visit reads them, without a Record being built.
+// Returning a non-nil error from any method stops Visit, which then returns that error.
+type Visitor interface {
+	// OnField is called for each field with a non-empty value, in input order.
+	// value is only valid until OnField returns; copy it to retain it.
+	OnField(field adifield.Field, value []byte) error
+
+	// OnEOR is called at the end of each QSO record.
+	OnEOR() error
+
+	// OnEOH is called at the end of the header record.
+	OnEOH() error
+}
+
+// Visit reads the remaining input, passing each field and record boundary to v.
+// Fields excluded by SetProjection are skipped.
+// Field names are interned as in Scan, and values are handed to v straight from the read buffer,
+// so Visit does not allocate per record or per field.
+//
+// Visit returns nil when the input is exhausted. Fields that follow the last <EOR> are passed to v without a closing OnEOR.
+// Parse failures are reported as a *ParseError as Err does for Scan. The recovery handler is not used.
+// Visit may not be mixed with Scan on the same Scanner.
+func (s *Scanner) Visit(v Visitor) error {
+	err := s.visit(v)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// visit implements Visit, returning io.EOF when the input is exhausted.
+func (s *Scanner) visit(v Visitor) error {
+	if s.offset == 0 {
+		if err := s.readPreamble(); err != nil {
+			return err
+		}
+	}
+	for {
+		if err := s.discardUntilLessThan(); err != nil {
+			return err
+		}
+		s.fieldOffset, s.fieldLine, s.fieldLineStart = s.offset-1, s.line, s.lineStart
+
+		field, _, length, err := s.readFieldSpecifier()
+		var value []byte
+		if err == nil && length > 0 {
+			if s.keep != nil && !s.keep[field] {
+				if s.skipValue(length) != nil {
+					err = ErrMalformedADI
+				}
+			} else {
+				value, err = s.readValueVolatile(length)
+			}
+		}
+		if err != nil {
+			return s.newParseError(field, err)
+		}
+
+		switch field {
+		case adifield.EOR:
+			if err := s.countQSORecord(); err != nil {
+				return err
+			}
+			s.records++
+			s.startRecord()
+			err = v.OnEOR()
+		case adifield.EOH:
+			s.records++
+			s.startRecord()
+			err = v.OnEOH()
+		default:
+			if len(value) > 0 {
+				err = v.OnField(field, s.decodeVolatile(value))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readValueVolatile reads the length bytes of a field value.
+//
+// IMPORTANT: The returned slice is VOLATILE and will be invalidated by the next
+// read from the underlying bufio.Reader. Callers must not retain it.
+func (s *Scanner) readValueVolatile(length int) ([]byte, error) {
+	if length > s.r.Size() {
+		// Values larger than the buffer are rare; read them into the reusable scratch buffer.
+		var err error
+		s.scratch, err = s.readValueGrowing(s.scratch, length)
+		return s.scratch, err
+	}
+	// Peek returns a view of the buffer; Discard only moves the read position past it.
+	volatile, err := s.r.Peek(length)
+	_, _ = s.r.Discard(len(volatile))
+	s.advance(volatile)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if len(volatile) != length {
+		return nil, ErrMalformedADI
+	}
+	return volatile, nil
+}
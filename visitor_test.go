@@ -0,0 +1,187 @@
+package adif
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+// recordVisitor rebuilds records from Visitor callbacks so that they can be compared with Scan.
+type recordVisitor struct {
+	header  Record
+	records []Record
+	current Record
+}
+
+func (v *recordVisitor) OnField(field adifield.Field, value []byte) error {
+	if v.current == nil {
+		v.current = NewRecord()
+	}
+	v.current[field] = string(value)
+	return nil
+}
+
+func (v *recordVisitor) OnEOR() error {
+	v.records = append(v.records, v.current)
+	v.current = nil
+	return nil
+}
+
+func (v *recordVisitor) OnEOH() error {
+	v.header, v.current = v.current, nil
+	return nil
+}
+
+// countVisitor counts QSO records with a CALL field without retaining anything.
+type countVisitor struct {
+	calls, records int
+}
+
+func (v *countVisitor) OnField(field adifield.Field, value []byte) error {
+	if field == adifield.CALL {
+		v.calls++
+	}
+	return nil
+}
+
+func (v *countVisitor) OnEOR() error { v.records++; return nil }
+func (v *countVisitor) OnEOH() error { return nil }
+
+// failVisitor returns err from the callback named by failOn.
+type failVisitor struct {
+	failOn string
+	err    error
+}
+
+func (v *failVisitor) OnField(adifield.Field, []byte) error { return v.fail("field") }
+func (v *failVisitor) OnEOR() error                         { return v.fail("eor") }
+func (v *failVisitor) OnEOH() error                         { return v.fail("eoh") }
+
+func (v *failVisitor) fail(event string) error {
+	if event == v.failOn {
+		return v.err
+	}
+	return nil
+}
+
+func TestScannerVisit_TestFiles(t *testing.T) {
+	fs, err := testFileFS.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		t.Run(f.Name(), func(t *testing.T) {
+			data, err := testFileFS.ReadFile("testdata/" + f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := readSequential(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var v recordVisitor
+			s := NewScanner(bytes.NewReader(data))
+			if err := s.Visit(&v); err != nil {
+				t.Fatal(err)
+			}
+			if s.Preamble() != want.Preamble {
+				t.Errorf("Preamble: got %q, want %q", s.Preamble(), want.Preamble)
+			}
+			assertRecordEqual(t, v.header, want.Header)
+			if len(v.records) != len(want.Records) {
+				t.Fatalf("Records: got %d, want %d", len(v.records), len(want.Records))
+			}
+			for i := range want.Records {
+				assertRecordEqual(t, v.records[i], want.Records[i])
+			}
+		})
+	}
+}
+
+func TestScannerVisit_Allocations(t *testing.T) {
+	const record = "<CALL:5>K9CTS<BAND:3>20m<MODE:3>SSB<APP_TEST_ID:2>42<EOR>\n"
+	allocs := func(records int) float64 {
+		data := "<PROGRAMID:4>Test<EOH>\n" + strings.Repeat(record, records)
+		return testing.AllocsPerRun(10, func() {
+			var v countVisitor
+			if err := NewScanner(strings.NewReader(data)).Visit(&v); err != nil || v.records != records {
+				t.Fatalf("got %d records, %v", v.records, err)
+			}
+		})
+	}
+	// Allocations are those of NewScanner and field interning, independent of the number of records.
+	if one, many := allocs(1), allocs(1000); many != one {
+		t.Errorf("allocations grew from %v for 1 record to %v for 1000", one, many)
+	}
+}
+
+func TestScannerVisit_LargeValue(t *testing.T) {
+	big := strings.Repeat("x", 10000) // larger than the default bufio.Reader buffer
+	adi := "<NOTES:10000>" + big + "<CALL:5>K9CTS<EOR><NOTES:10000>" + big + "<EOR>"
+	var v recordVisitor
+	if err := NewScanner(strings.NewReader(adi)).Visit(&v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.records) != 2 || v.records[0][adifield.NOTES] != big || v.records[1][adifield.NOTES] != big {
+		t.Errorf("got %d records", len(v.records))
+	}
+}
+
+func TestScannerVisit_ParseError(t *testing.T) {
+	tests := []string{
+		"<CALL:5>K9CTS<EOR>\n<CALL:X>W9PVA<EOR>",
+		"<CALL:5>K9CTS<EOR>\n<CALL:10>W9PVA",
+		"<CALL:5>K9CTS<EOR>\n<NOTES:10000>" + strings.Repeat("x", 9999),
+	}
+	for _, adi := range tests {
+		s := NewScanner(strings.NewReader(adi))
+		for s.Scan() {
+		}
+		var wantPE *ParseError
+		if !errors.As(s.Err(), &wantPE) {
+			t.Fatalf("Scan: got %v, want a *ParseError", s.Err())
+		}
+
+		var v recordVisitor
+		err := NewScanner(strings.NewReader(adi)).Visit(&v)
+		var pe *ParseError
+		if !errors.As(err, &pe) || *pe != *wantPE {
+			t.Errorf("got %v, want %v", err, wantPE)
+		}
+		if len(v.records) != 1 {
+			t.Errorf("got %d records before the error, want 1", len(v.records))
+		}
+	}
+}
+
+func TestScannerVisit_VisitorError(t *testing.T) {
+	errStop := errors.New("stop")
+	adi := "<PROGRAMID:4>Test<EOH><CALL:5>K9CTS<EOR>"
+	for _, event := range []string{"field", "eor", "eoh"} {
+		err := NewScanner(strings.NewReader(adi)).Visit(&failVisitor{failOn: event, err: errStop})
+		if err != errStop {
+			t.Errorf("%s: got %v, want errStop", event, err)
+		}
+	}
+}
+
+func TestScannerVisit_ReadError(t *testing.T) {
+	data := []byte("preamble<CALL:5>K9CTS<EOR>")
+	for _, maxBytes := range []int{3, 12} {
+		r := &mockFailReader{backingData: data, maxBytes: maxBytes}
+		if err := NewScanner(r).Visit(&countVisitor{}); err == nil {
+			t.Errorf("maxBytes %d: expected an error", maxBytes)
+		}
+	}
+
+	// A read failure within a value is reported as such, not as malformed input.
+	r := &mockFailReader{backingData: data, maxBytes: len("preamble<CALL:5>K9C")}
+	err := NewScanner(r).Visit(&countVisitor{})
+	if errors.Is(err, ErrMalformedADI) || err == nil || !strings.Contains(err.Error(), "read failed") {
+		t.Errorf("value: got %v, want the read error", err)
+	}
+}
@@ -1,12 +1,15 @@
 package adif
 
 import (
+	"context"
 	"io"
 	"slices"
 	"strconv"
 	"sync"
 
+	"github.com/farmergreg/adif/v5/internal/flush"
 	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
 )
 
 // WriteMode controls the output format of the ADI Writer.
@@ -84,6 +87,8 @@ type Writer struct {
 	w              io.Writer
 	headerPreamble string
 	mode           WriteMode
+	ascii          ASCIIPolicy
+	types          dataTypeIndicators
 	wroteData      bool
 }
 
@@ -108,6 +113,10 @@ func (w *Writer) WriteHeader(r Record) error {
 	if w.wroteData {
 		return ErrHeaderAlreadyWritten
 	}
+	r, err := applyASCIIPolicy(r, w.ascii)
+	if err != nil {
+		return err
+	}
 	preamble := w.headerPreamble
 	if preamble == "" {
 		preamble = "\n" // minimal preamble required by the ADIF spec
@@ -116,11 +125,16 @@ func (w *Writer) WriteHeader(r Record) error {
 		return err
 	}
 	w.wroteData = true
+	w.types.learnHeader(r)
 	return w.writeRecord(r, 'H')
 }
 
 // Write appends a QSO record to the output.
 func (w *Writer) Write(r Record) error {
+	r, err := applyASCIIPolicy(r, w.ascii)
+	if err != nil {
+		return err
+	}
 	w.wroteData = true
 	return w.writeRecord(r, 'R')
 }
@@ -131,25 +145,57 @@ func (w *Writer) SetWriteMode(mode WriteMode) *Writer {
 	return w
 }
 
-// Flush flushes the underlying io.Writer if it implements Flush() error (e.g. bufio.Writer).
-// It is a no-op for writers that do not buffer.
-func (w *Writer) Flush() error {
-	return flushWriter(w.w)
+// SetASCIIPolicy sets how non-ASCII characters in ASCII-only fields are written and returns the Writer for chaining.
+// The ADIF specification permits only ASCII in ADI files, apart from the values of Intl fields such as NAME_INTL.
+// With ASCIIPolicyReject, Write and WriteHeader return an error wrapping ErrNonASCII and write nothing.
+func (w *Writer) SetASCIIPolicy(policy ASCIIPolicy) *Writer {
+	w.ascii = policy
+	return w
 }
 
-// flushWriter calls w.Flush() if w implements the Flush() error interface, otherwise it is a no-op.
-func flushWriter(w io.Writer) error {
-	if f, ok := w.(interface{ Flush() error }); ok {
-		return f.Flush()
+// SetDataTypeIndicators sets the data type indicators to write for the given fields and returns the Writer for chaining.
+// A field listed in types is written with its indicator (e.g. <EPC:5:N>) in every record, including the header.
+// Explicit indicators take precedence over those chosen by SetAutoDataTypeIndicators.
+// Indicators other than an uppercase ASCII letter are ignored.
+// The map must not be modified while the Writer is in use.
+func (w *Writer) SetDataTypeIndicators(types map[adifield.Field]aditype.DataTypeIndicator) *Writer {
+	w.types.explicit = types
+	return w
+}
+
+// SetAutoDataTypeIndicators enables or disables automatic data type indicators and returns the Writer for chaining.
+// When enabled, indicators are written for fields whose type the ADIF specification does not define:
+//   - header USERDEFn fields: N for a range such as {5:20}, E for an enumeration such as {S,M,L}, otherwise S.
+//   - user-defined fields declared by the header: the indicator of the declaring USERDEFn field.
+//   - APP_ fields: M for values containing a CR LF line break, otherwise S.
+func (w *Writer) SetAutoDataTypeIndicators(auto bool) *Writer {
+	w.types.auto = auto
+	return w
+}
+
+// WriteContext is like Write, but returns ctx.Err() without writing once ctx is done.
+func (w *Writer) WriteContext(ctx context.Context, r Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	return nil
+	return w.Write(r)
+}
+
+// Flush flushes the underlying io.Writer if it implements Flush() error (e.g. bufio.Writer).
+// It is a no-op for writers that do not buffer.
+func (w *Writer) Flush() error {
+	return flush.Writer(w.w)
 }
 
 func (w *Writer) writeRecord(r Record, endTag byte) error {
 	bufPtr := writerBufPool.Get().(*[]byte)
 	buf := (*bufPtr)[:0]
 
-	buf = appendFieldsADI(r, buf, w.mode)
+	var types *dataTypeIndicators
+	if w.types.enabled() {
+		types = &w.types
+	}
+	buf = appendFieldsADI(r, buf, w.mode, types, endTag == 'H')
 	if len(buf) == 0 {
 		writerBufPool.Put(bufPtr)
 		return nil
@@ -164,20 +210,21 @@ func (w *Writer) writeRecord(r Record, endTag byte) error {
 }
 
 // appendFieldsADI writes all fields of r to buf in ADI format using the given WriteMode.
+// Data type indicators are chosen by types, which may be nil.
 // It will not write the end tag.
-func appendFieldsADI(r Record, buf []byte, mode WriteMode) []byte {
+func appendFieldsADI(r Record, buf []byte, mode WriteMode, types *dataTypeIndicators, isHeader bool) []byte {
 	if mode == WriteModeFast {
-		return appendFieldsADIFast(r, buf)
+		return appendFieldsADIFast(r, buf, types, isHeader)
 	}
-	return appendFieldsADIPretty(r, buf)
+	return appendFieldsADIPretty(r, buf, types, isHeader)
 }
 
 // appendFieldsADIFast writes all fields of r to buf in ADI format as quickly and efficiently as possible.
 // It does not guarantee any particular field order.
 // It will not write the end tag.
-func appendFieldsADIFast(r Record, buf []byte) []byte {
+func appendFieldsADIFast(r Record, buf []byte, types *dataTypeIndicators, isHeader bool) []byte {
 	for field, value := range r {
-		buf = appendField(buf, field, value)
+		buf = appendField(buf, field, value, types.lookup(field, value, isHeader))
 	}
 	return buf
 }
@@ -186,21 +233,12 @@ func appendFieldsADIFast(r Record, buf []byte) []byte {
 // First, it writes priority fields in a fixed order.
 // Next, it writes remaining fields in alphabetical order.
 // It will not write the end tag.
-func appendFieldsADIPretty(r Record, buf []byte) []byte {
-	for _, field := range adiWriterPriorityFieldOrder {
-		buf = appendField(buf, field, r[field])
-	}
-
+func appendFieldsADIPretty(r Record, buf []byte, types *dataTypeIndicators, isHeader bool) []byte {
 	scratchPtr := writerFieldScratchPool.Get().(*[]adifield.Field)
-	scratch := (*scratchPtr)[:0]
-	for field := range r {
-		if _, isPriority := adiWriterPriorityFieldMap[field]; !isPriority {
-			scratch = append(scratch, field)
-		}
-	}
-	slices.Sort(scratch)
+	scratch := appendFieldOrderPretty((*scratchPtr)[:0], r)
 	for _, field := range scratch {
-		buf = appendField(buf, field, r[field])
+		value := r[field]
+		buf = appendField(buf, field, value, types.lookup(field, value, isHeader))
 	}
 	*scratchPtr = scratch
 	writerFieldScratchPool.Put(scratchPtr)
@@ -208,9 +246,28 @@ func appendFieldsADIPretty(r Record, buf []byte) []byte {
 	return buf
 }
 
+// appendFieldOrderPretty appends the fields of r to fields in pretty order and returns the extended slice.
+// Priority fields that are present in r come first in a fixed order; remaining fields follow in alphabetical order.
+func appendFieldOrderPretty(fields []adifield.Field, r Record) []adifield.Field {
+	for _, field := range adiWriterPriorityFieldOrder {
+		if _, ok := r[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+	start := len(fields)
+	for field := range r {
+		if _, isPriority := adiWriterPriorityFieldMap[field]; !isPriority {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields[start:])
+	return fields
+}
+
 // appendField appends a single ADIF field in ADI format to buf.
+// The data type indicator is omitted when dataType is aditype.DATATYPEINDICATOR_NONE.
 // Returns buf unchanged when value is empty.
-func appendField(buf []byte, field adifield.Field, value string) []byte {
+func appendField(buf []byte, field adifield.Field, value string, dataType aditype.DataTypeIndicator) []byte {
 	if value == "" {
 		return buf
 	}
@@ -218,6 +275,9 @@ func appendField(buf []byte, field adifield.Field, value string) []byte {
 	buf = append(buf, field...)
 	buf = append(buf, ':')
 	buf = strconv.AppendInt(buf, int64(len(value)), 10)
+	if dataType != aditype.DATATYPEINDICATOR_NONE {
+		buf = append(buf, ':', byte(dataType))
+	}
 	buf = append(buf, '>')
 	buf = append(buf, value...)
 	return buf
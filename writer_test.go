@@ -2,11 +2,14 @@ package adif
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"math/rand"
 	"strings"
 	"testing"
 
 	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/aditype"
 )
 
 func TestWriter_Write(t *testing.T) {
@@ -190,3 +193,162 @@ func TestWriter_Flush_Buffered(t *testing.T) {
 		t.Error("expected K9CTS in output after Flush")
 	}
 }
+
+func TestWriter_SetDataTypeIndicators(t *testing.T) {
+	for _, mode := range []WriteMode{WriteModePretty, WriteModeFast} {
+		var sb strings.Builder
+		w := NewWriterWithPreamble(&sb, "").SetWriteMode(mode).SetDataTypeIndicators(map[adifield.Field]aditype.DataTypeIndicator{
+			adifield.PROGRAMID: aditype.DATATYPEINDICATOR_STRING,
+			adifield.FREQ:      aditype.DATATYPEINDICATOR_NUMBER,
+			adifield.CALL:      'ö',
+			adifield.BAND:      'n',
+			adifield.USERDEF1:  'ö',
+		})
+		if err := w.WriteHeader(Record{adifield.PROGRAMID: "Test", adifield.USERDEF1: "EPC"}); err != nil {
+			t.Fatal(err)
+		}
+		// Indicators other than an uppercase ASCII letter are left out rather than written as a corrupt specifier.
+		for _, r := range []Record{{adifield.FREQ: "14.074"}, {adifield.CALL: "K9CTS"}, {adifield.BAND: "20M"}} {
+			if err := w.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := "\n<PROGRAMID:4:S>Test<USERDEF1:3>EPC<EOH>\n<FREQ:6:N>14.074<EOR>\n<CALL:5>K9CTS<EOR>\n<BAND:3>20M<EOR>\n"
+		if got := sb.String(); got != want {
+			t.Errorf("mode %d: got %q, want %q", mode, got, want)
+		}
+	}
+}
+
+func TestWriter_SetAutoDataTypeIndicators(t *testing.T) {
+	hdr := Record{
+		adifield.USERDEF1:  "EPC",
+		adifield.USERDEF2:  "SWEATERSIZE,{S,M,L}",
+		adifield.USERDEF3:  "SHOESIZE,{5:20}",
+		adifield.USERDEF4:  "WPM",
+		adifield.PROGRAMID: "Test",
+	}
+	qso := Record{
+		adifield.CALL:                   "K9CTS",
+		adifield.New("EPC"):             "1",
+		adifield.New("SHOESIZE"):        "11",
+		adifield.New("SWEATERSIZE"):     "M",
+		adifield.New("WPM"):             "25",
+		adifield.New("APP_K9CTS_NOTE"):  "a",
+		adifield.New("APP_K9CTS_LINES"): "a\r\nb",
+	}
+
+	var sb strings.Builder
+	w := NewWriterWithPreamble(&sb, "").
+		SetAutoDataTypeIndicators(true).
+		SetDataTypeIndicators(map[adifield.Field]aditype.DataTypeIndicator{
+			adifield.USERDEF4: aditype.DATATYPEINDICATOR_NUMBER,
+		})
+	if err := w.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(qso); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\n<PROGRAMID:4>Test<USERDEF1:3:S>EPC<USERDEF2:19:E>SWEATERSIZE,{S,M,L}<USERDEF3:15:N>SHOESIZE,{5:20}<USERDEF4:3:N>WPM<EOH>\n" +
+		"<CALL:5>K9CTS<APP_K9CTS_LINES:4:M>a\r\nb<APP_K9CTS_NOTE:1:S>a<EPC:1:S>1<SHOESIZE:2:N>11<SWEATERSIZE:1:E>M<WPM:2:N>25<EOR>\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestWriter_DataTypeIndicators_RoundTrip(t *testing.T) {
+	adi := "<USERDEF1:3:N>EPC<EOH><EPC:5:N>32123<APP_K9CTS_WPM:2:N>25<CALL:5>K9CTS<EOR>"
+	d := NewDocument()
+	if _, err := d.ReadFrom(strings.NewReader(adi)); err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	w := NewWriterWithPreamble(&sb, "").SetDataTypeIndicators(d.DataTypes)
+	if err := w.WriteHeader(d.Header); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range d.Records {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "\n<USERDEF1:3:N>EPC<EOH>\n<CALL:5>K9CTS<APP_K9CTS_WPM:2:N>25<EPC:5:N>32123<EOR>\n"
+	if got := sb.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestWriter_WriteContext(t *testing.T) {
+	var sb strings.Builder
+	w := NewWriter(&sb)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := w.WriteContext(ctx, Record{adifield.CALL: "K9CTS"}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := w.WriteContext(ctx, Record{adifield.CALL: "W9PVA"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if want := "<CALL:5>K9CTS<EOR>\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestWriter_SetASCIIPolicy(t *testing.T) {
+	hdr := Record{adifield.PROGRAMID: "Lögger"}
+	qso := Record{adifield.CALL: "K9CTS", adifield.NAME: "José Müller", adifield.NAME_INTL: "José Müller"}
+
+	tests := []struct {
+		policy ASCIIPolicy
+		want   string
+	}{
+		{ASCIIPolicyAllow, "\n<PROGRAMID:7>Lögger<EOH>\n<CALL:5>K9CTS<NAME:13>José Müller<NAME_INTL:13>José Müller<EOR>\n"},
+		{ASCIIPolicyTransliterate, "\n<PROGRAMID:6>Logger<EOH>\n<CALL:5>K9CTS<NAME:11>Jose Muller<NAME_INTL:13>José Müller<EOR>\n"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		w := NewWriterWithPreamble(&sb, "").SetASCIIPolicy(tt.policy)
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(qso); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("policy %d: got %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+	if qso[adifield.NAME] != "José Müller" {
+		t.Errorf("record was modified: %q", qso[adifield.NAME])
+	}
+}
+
+func TestWriter_SetASCIIPolicy_Reject(t *testing.T) {
+	var sb strings.Builder
+	w := NewWriterWithPreamble(&sb, "").SetASCIIPolicy(ASCIIPolicyReject)
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Lögger"}); !errors.Is(err, ErrNonASCII) {
+		t.Errorf("WriteHeader: got %v, want ErrNonASCII", err)
+	}
+	err := w.Write(Record{adifield.CALL: "K9CTS", adifield.QTH: "Zürich"})
+	if want := `non-ASCII value in ASCII-only field: QTH "Zürich"`; err == nil || err.Error() != want {
+		t.Errorf("Write: got %v, want %q", err, want)
+	}
+	if sb.Len() != 0 {
+		t.Errorf("got output %q after rejected records", sb.String())
+	}
+
+	// Nothing was written, so the header may still be written.
+	if err := w.WriteHeader(Record{adifield.PROGRAMID: "Logger"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(Record{adifield.CALL: "K9CTS", adifield.QTH_INTL: "Zürich"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\n<PROGRAMID:6>Logger<EOH>\n<CALL:5>K9CTS<QTH_INTL:7>Zürich<EOR>\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
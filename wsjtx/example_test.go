@@ -0,0 +1,40 @@
+package wsjtx_test
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	adif "github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/adif/v5/wsjtx"
+)
+
+// ExampleListener demonstrates appending the QSOs logged by WSJT-X to an ADI file until interrupted.
+func ExampleListener() {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:2237")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	f, err := os.OpenFile("wsjtx.adi", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	w := adif.NewWriter(bufio.NewWriter(f))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = wsjtx.NewListener(conn, w).SetHandler(func(addr net.Addr, m wsjtx.Message) {
+		if s, ok := m.(*wsjtx.Status); ok {
+			log.Printf("%s: %d Hz %s, working %s", s.ID, s.DialFrequency, s.Mode, s.DXCall)
+		}
+	}).Serve(ctx)
+	if err != nil && err != context.Canceled {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,102 @@
+package wsjtx
+
+import (
+	"context"
+	"net"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/adif/v5/internal/udp"
+)
+
+// RecordWriter receives the QSOs logged by WSJT-X. It is implemented by adif.Writer, adif.ADXWriter and adif.NDJSONWriter.
+type RecordWriter interface {
+	Write(r adif.Record) error
+}
+
+// Listener receives WSJT-X messages from a UDP socket and writes the QSOs they log to a RecordWriter.
+// Obtain one with NewListener, then call Serve.
+//
+// WSJT-X sends each logged QSO twice, as a QSO Logged and a Logged ADIF message.
+// By default, the records of Logged ADIF messages are written, as they hold everything WSJT-X logs;
+// SetUseQSOLogged writes the records of QSO Logged messages instead.
+type Listener struct {
+	conn         net.PacketConn
+	w            RecordWriter
+	handler      func(addr net.Addr, m Message)
+	useQSOLogged bool
+}
+
+// NewListener returns a Listener that reads messages from conn and writes logged QSOs to w.
+// w may be nil when only the handler set with SetHandler is wanted.
+//
+//	conn, err := net.ListenPacket("udp", "127.0.0.1:2237")
+func NewListener(conn net.PacketConn, w RecordWriter) *Listener {
+	return &Listener{conn: conn, w: w}
+}
+
+// SetHandler sets a function that is called with each decoded message and the address it came from,
+// before any record is written, and returns the Listener for chaining.
+// Use it to follow Heartbeat and Status messages. It is called from the goroutine running Serve.
+func (l *Listener) SetHandler(handler func(addr net.Addr, m Message)) *Listener {
+	l.handler = handler
+	return l
+}
+
+// SetUseQSOLogged selects whether the records of QSO Logged messages, converted by QSOLogged.Record,
+// are written instead of those of Logged ADIF messages, and returns the Listener for chaining.
+func (l *Listener) SetUseQSOLogged(use bool) *Listener {
+	l.useQSOLogged = use
+	return l
+}
+
+// Serve reads and handles messages until ctx is done, or reading from the socket or writing a record fails.
+// After each logged QSO is written, the RecordWriter is flushed if it implements Flush() error.
+// Packets that are not WSJT-X messages, messages of other types, and Logged ADIF messages holding malformed ADI are skipped.
+//
+// Serve returns ctx.Err() when ctx is done, or the error of the socket or RecordWriter. It does not close the socket.
+func (l *Listener) Serve(ctx context.Context) error {
+	return udp.Serve(ctx, l.conn, func(addr net.Addr, packet []byte) error {
+		m, err := Decode(packet)
+		if err != nil {
+			return nil
+		}
+		if l.handler != nil {
+			l.handler(addr, m)
+		}
+		return l.write(m)
+	})
+}
+
+// write writes the records logged by m, if any, and flushes the RecordWriter.
+func (l *Listener) write(m Message) error {
+	if l.w == nil {
+		return nil
+	}
+	var records []adif.Record
+	switch m := m.(type) {
+	case *QSOLogged:
+		if !l.useQSOLogged {
+			return nil
+		}
+		records = []adif.Record{m.Record()}
+	case *LoggedADIF:
+		if l.useQSOLogged {
+			return nil
+		}
+		var err error
+		if records, err = m.Records(); err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+	for _, r := range records {
+		if err := l.w.Write(r); err != nil {
+			return err
+		}
+	}
+	if f, ok := l.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package wsjtx
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farmergreg/adif/v5"
+)
+
+// errWrite is returned by failWriter.
+var errWrite = errors.New("write failed")
+
+type failWriter struct{}
+
+func (failWriter) Write(adif.Record) error { return errWrite }
+
+// sliceWriter collects records and, unlike adif.Writer, has no Flush method.
+type sliceWriter struct{ records []adif.Record }
+
+func (w *sliceWriter) Write(r adif.Record) error {
+	w.records = append(w.records, r)
+	return nil
+}
+
+const testLoggedADIF = "\n<adif_ver:5>3.1.0\n<programid:6>WSJT-X\n<EOH>\n<call:6>DL1ABC <mode:3>FT8 <eor>\n"
+
+// serve starts l on a loopback socket and returns the address to send to and a function that
+// stops l and returns the error of Serve.
+func serve(t *testing.T, newListener func(conn net.PacketConn) *Listener) (*net.UDPConn, func() error) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback UDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	sender, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sender.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	l := newListener(conn)
+	go func() { done <- l.Serve(ctx) }()
+	return sender, func() error {
+		cancel()
+		return <-done
+	}
+}
+
+// send sends the messages, followed by a heartbeat that is reported on seen once all of them have been handled.
+func send(t *testing.T, sender *net.UDPConn, seen <-chan Message, messages ...[]byte) {
+	t.Helper()
+	for _, m := range append(messages, newMessage(TypeHeartbeat, "sync").uint32(3).utf8("").utf8("").buf) {
+		if _, err := sender.Write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case m := <-seen:
+			if m.MessageHeader().ID == "sync" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for messages")
+		}
+	}
+}
+
+func TestListener(t *testing.T) {
+	for _, useQSOLogged := range []bool{false, true} {
+		var sb strings.Builder
+		bw := bufio.NewWriter(&sb)
+		w := adif.NewWriter(bw).SetWriteMode(adif.WriteModeFast)
+		seen := make(chan Message, 16)
+		var types []MessageType
+		sender, stop := serve(t, func(conn net.PacketConn) *Listener {
+			return NewListener(conn, w).SetUseQSOLogged(useQSOLogged).SetHandler(func(addr net.Addr, m Message) {
+				if m.MessageHeader().ID != "sync" {
+					types = append(types, m.MessageHeader().Type)
+				}
+				seen <- m
+			})
+		})
+		send(t, sender, seen,
+			[]byte("not a WSJT-X message"),
+			newMessage(TypeStatus, "WSJT-X").uint64(14074000).buf[:20],
+			newMessage(2, "WSJT-X").buf,
+			qsoLogged(false).buf,
+			newMessage(TypeLoggedADIF, "WSJT-X").utf8(testLoggedADIF).buf,
+			newMessage(TypeLoggedADIF, "WSJT-X").utf8("<call:X>").buf,
+		)
+		if err := stop(); err != context.Canceled {
+			t.Errorf("Serve: got %v, want context.Canceled", err)
+		}
+
+		if len(types) != 3 || types[0] != TypeQSOLogged || types[1] != TypeLoggedADIF {
+			t.Errorf("handler: got %v", types)
+		}
+		// Fast mode writes fields in map order, so only look for the field that tells the two messages apart.
+		want := "<MODE:3>FT8"
+		if useQSOLogged {
+			want = "<SUBMODE:3>FT4"
+		}
+		if got := sb.String(); !strings.Contains(got, want) || strings.Count(got, "<EOR>") != 1 {
+			t.Errorf("useQSOLogged %v: got %q, want one record with %q", useQSOLogged, got, want)
+		}
+	}
+}
+
+func TestListener_Errors(t *testing.T) {
+	seen := make(chan Message, 16)
+	sender, stop := serve(t, func(conn net.PacketConn) *Listener {
+		return NewListener(conn, failWriter{}).SetHandler(func(addr net.Addr, m Message) { seen <- m })
+	})
+	if _, err := sender.Write(newMessage(TypeLoggedADIF, "WSJT-X").utf8(testLoggedADIF).buf); err != nil {
+		t.Fatal(err)
+	}
+	<-seen
+	if err := stop(); err != errWrite {
+		t.Errorf("Serve: got %v, want errWrite", err)
+	}
+
+	sw := &sliceWriter{}
+	sender, stop = serve(t, func(conn net.PacketConn) *Listener {
+		return NewListener(conn, sw).SetHandler(func(addr net.Addr, m Message) { seen <- m })
+	})
+	send(t, sender, seen, newMessage(TypeLoggedADIF, "WSJT-X").utf8(testLoggedADIF).buf)
+	if err := stop(); err != context.Canceled || len(sw.records) != 1 {
+		t.Errorf("Serve: got %d records, %v", len(sw.records), err)
+	}
+
+	// Without a RecordWriter, messages are only handed to the handler.
+	sender, stop = serve(t, func(conn net.PacketConn) *Listener {
+		return NewListener(conn, nil).SetHandler(func(addr net.Addr, m Message) { seen <- m })
+	})
+	send(t, sender, seen, newMessage(TypeLoggedADIF, "WSJT-X").utf8(testLoggedADIF).buf)
+	if err := stop(); err != context.Canceled {
+		t.Errorf("Serve: got %v, want context.Canceled", err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback UDP: %v", err)
+	}
+	conn.Close()
+	if err := NewListener(conn, nil).Serve(context.Background()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("closed socket: got %v, want net.ErrClosed", err)
+	}
+}
@@ -0,0 +1,74 @@
+package wsjtx
+
+import (
+	"strings"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+	"github.com/farmergreg/spec/v6/enum/band"
+	"github.com/farmergreg/spec/v6/enum/mode"
+	"github.com/farmergreg/spec/v6/enum/submode"
+)
+
+// Record returns the QSO as an ADIF record.
+// TxFrequency is stored as FREQ in MHz together with its BAND, and a mode that ADIF defines as a submode,
+// such as FT4, as MODE MFSK with SUBMODE FT4. MyCall is stored as STATION_CALLSIGN.
+// Without a TimeOn, QSO_DATE and TIME_ON are taken from TimeOff. Empty fields are omitted.
+func (q *QSOLogged) Record() adif.Record {
+	r := adif.NewRecord()
+	set := func(field adifield.Field, value string) {
+		if value != "" {
+			r[field] = value
+		}
+	}
+	set(adifield.CALL, q.DXCall)
+	set(adifield.GRIDSQUARE, q.DXGrid)
+	if q.TxFrequency > 0 {
+		mhz := float64(q.TxFrequency) / 1e6
+		r.SetFloat(adifield.FREQ, mhz)
+		if spec, ok := band.FindBandByMHz(mhz); ok {
+			r.SetBand(spec.Key)
+		}
+	}
+	if spec, ok := submode.Lookup(submode.New(q.Mode)); ok {
+		if _, isMode := mode.Lookup(mode.New(q.Mode)); !isMode {
+			r[adifield.MODE], r[adifield.SUBMODE] = strings.ToUpper(spec.Mode), string(spec.Key)
+		}
+	}
+	if r[adifield.MODE] == "" {
+		set(adifield.MODE, q.Mode)
+	}
+	on := q.TimeOn
+	if on.IsZero() {
+		on = q.TimeOff
+	}
+	if !on.IsZero() {
+		r.SetTime(adifield.QSO_DATE, adifield.TIME_ON, on)
+	}
+	if !q.TimeOff.IsZero() {
+		r.SetTime(adifield.QSO_DATE_OFF, adifield.TIME_OFF, q.TimeOff)
+	}
+	set(adifield.RST_SENT, q.ReportSent)
+	set(adifield.RST_RCVD, q.ReportReceived)
+	set(adifield.TX_PWR, q.TxPower)
+	set(adifield.COMMENT, q.Comments)
+	set(adifield.NAME, q.Name)
+	set(adifield.OPERATOR, q.OperatorCall)
+	set(adifield.STATION_CALLSIGN, q.MyCall)
+	set(adifield.MY_GRIDSQUARE, q.MyGrid)
+	set(adifield.STX_STRING, q.ExchangeSent)
+	set(adifield.SRX_STRING, q.ExchangeReceived)
+	set(adifield.PROP_MODE, q.PropagationMode)
+	return r
+}
+
+// Records parses the ADI document held by the message and returns its QSO records; the header is skipped.
+// Malformed ADI is reported as an *adif.ParseError.
+func (m *LoggedADIF) Records() ([]adif.Record, error) {
+	s := adif.NewScanner(strings.NewReader(m.ADIF))
+	var records []adif.Record
+	for r := range s.QSOs() {
+		records = append(records, r)
+	}
+	return records, s.Err()
+}
@@ -0,0 +1,77 @@
+package wsjtx
+
+import (
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/farmergreg/adif/v5"
+	"github.com/farmergreg/spec/v6/adifield"
+)
+
+func TestQSOLogged_Record(t *testing.T) {
+	want := adif.Record{
+		adifield.CALL:             "DL1ABC",
+		adifield.GRIDSQUARE:       "JO62",
+		adifield.FREQ:             "14.0765",
+		adifield.BAND:             "20M",
+		adifield.MODE:             "MFSK",
+		adifield.SUBMODE:          "FT4",
+		adifield.QSO_DATE:         "20240704",
+		adifield.TIME_ON:          "235945",
+		adifield.QSO_DATE_OFF:     "20240705",
+		adifield.TIME_OFF:         "000015",
+		adifield.RST_SENT:         "-05",
+		adifield.RST_RCVD:         "+02",
+		adifield.TX_PWR:           "100",
+		adifield.COMMENT:          "tnx",
+		adifield.NAME:             "Hans",
+		adifield.OPERATOR:         "W9PVA",
+		adifield.STATION_CALLSIGN: "K9CTS",
+		adifield.MY_GRIDSQUARE:    "EN52",
+		adifield.STX_STRING:       "599 IL",
+		adifield.SRX_STRING:       "599 DL",
+	}
+	if got := testQSO.Record(); !maps.Equal(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		q    QSOLogged
+		want adif.Record
+	}{
+		{"mode", QSOLogged{Mode: "FT8", TxFrequency: 1}, adif.Record{adifield.MODE: "FT8", adifield.FREQ: "0.000001"}},
+		{"mode and submode", QSOLogged{Mode: "PKT"}, adif.Record{adifield.MODE: "PKT"}},
+		{"unknown mode", QSOLogged{Mode: "Q65X"}, adif.Record{adifield.MODE: "Q65X"}},
+		{"time off only", QSOLogged{TimeOff: testTimeOff}, adif.Record{adifield.QSO_DATE: "20240705", adifield.TIME_ON: "000015",
+			adifield.QSO_DATE_OFF: "20240705", adifield.TIME_OFF: "000015"}},
+		{"local time", QSOLogged{TimeOn: time.Date(2024, 7, 4, 20, 0, 0, 0, time.FixedZone("CDT", -5*3600))},
+			adif.Record{adifield.QSO_DATE: "20240705", adifield.TIME_ON: "010000"}},
+		{"empty", QSOLogged{}, adif.Record{}},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Record(); !maps.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoggedADIF_Records(t *testing.T) {
+	m := &LoggedADIF{ADIF: "\n<adif_ver:5>3.1.0\n<programid:6>WSJT-X\n<EOH>\n" +
+		"<call:6>DL1ABC <gridsquare:4>JO62 <mode:4>MFSK <submode:3>FT4 <qso_date:8>20240704 <time_on:6>235945 <band:3>20m <EOR>"}
+	records, err := m.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0][adifield.CALL] != "DL1ABC" || records[0][adifield.SUBMODE] != "FT4" {
+		t.Errorf("got %v", records)
+	}
+
+	m.ADIF = "<call:X>DL1ABC<EOR>"
+	var pe *adif.ParseError
+	if _, err := m.Records(); !errors.As(err, &pe) {
+		t.Errorf("got %v, want a *adif.ParseError", err)
+	}
+}
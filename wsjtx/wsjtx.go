@@ -0,0 +1,270 @@
+// Package wsjtx decodes the UDP messages that WSJT-X and compatible programs such as JTDX send to logging applications,
+// and turns the QSOs they log into ADIF records.
+//
+// Messages are Qt QDataStream encoded, as described in NetworkMessage.hpp of the WSJT-X source.
+// Decode handles the Heartbeat, Status, QSO Logged and Logged ADIF messages;
+// Listener receives them from a UDP socket and writes each logged QSO to an ADIF writer.
+package wsjtx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Magic is the number that begins every WSJT-X message.
+const Magic uint32 = 0xadbccbda
+
+// DefaultPort is the UDP port that WSJT-X sends messages to unless configured otherwise.
+const DefaultPort = 2237
+
+// MessageType identifies the kind of a WSJT-X message.
+type MessageType uint32
+
+// Message types decoded by Decode. WSJT-X defines others, such as Decode and Clear, that are not decoded.
+const (
+	TypeHeartbeat  MessageType = 0
+	TypeStatus     MessageType = 1
+	TypeQSOLogged  MessageType = 5
+	TypeLoggedADIF MessageType = 12
+)
+
+var (
+	// ErrMalformedMessage is returned by Decode when its input is not a complete WSJT-X message.
+	ErrMalformedMessage = errors.New("malformed WSJT-X message")
+
+	// ErrUnsupportedMessage is returned by Decode for a well-formed message of a type that it does not decode.
+	ErrUnsupportedMessage = errors.New("unsupported WSJT-X message type")
+)
+
+// Header holds the fields common to every message.
+type Header struct {
+	// Schema is the schema number of the message encoding, 2 or 3 for current versions of WSJT-X.
+	Schema uint32
+
+	// Type is the type of the message.
+	Type MessageType
+
+	// ID identifies the sending program instance, e.g. "WSJT-X", or the --rig-name it was started with.
+	ID string
+}
+
+// MessageHeader returns h. It makes every message type a Message.
+func (h Header) MessageHeader() Header { return h }
+
+// Message is a decoded WSJT-X message: a *Heartbeat, *Status, *QSOLogged or *LoggedADIF.
+type Message interface {
+	MessageHeader() Header
+}
+
+// Heartbeat is sent periodically by each WSJT-X instance.
+type Heartbeat struct {
+	Header
+	MaxSchema uint32
+	Version   string
+	Revision  string
+}
+
+// Status is sent when the state of a WSJT-X instance changes, such as its dial frequency, mode or DX call.
+// Fields that older versions of WSJT-X do not send are left at their zero values.
+type Status struct {
+	Header
+	DialFrequency        uint64 // Hz
+	Mode                 string
+	DXCall               string
+	Report               string
+	TxMode               string
+	TxEnabled            bool
+	Transmitting         bool
+	Decoding             bool
+	RxDF                 uint32 // Hz
+	TxDF                 uint32 // Hz
+	DECall               string
+	DEGrid               string
+	DXGrid               string
+	TxWatchdog           bool
+	SubMode              string
+	FastMode             bool
+	SpecialOperationMode uint8
+	FrequencyTolerance   uint32
+	TRPeriod             uint32 // seconds
+	ConfigurationName    string
+	TxMessage            string
+}
+
+// QSOLogged is sent when the operator logs a QSO. Record converts it to an ADIF record.
+// Fields that older versions of WSJT-X do not send are left at their zero values.
+type QSOLogged struct {
+	Header
+	TimeOff          time.Time
+	DXCall           string
+	DXGrid           string
+	TxFrequency      uint64 // Hz
+	Mode             string
+	ReportSent       string
+	ReportReceived   string
+	TxPower          string
+	Comments         string
+	Name             string
+	TimeOn           time.Time
+	OperatorCall     string
+	MyCall           string
+	MyGrid           string
+	ExchangeSent     string
+	ExchangeReceived string
+	PropagationMode  string
+}
+
+// LoggedADIF is sent together with QSOLogged when the operator logs a QSO, and holds the QSO as an ADI document.
+// Records parses it.
+type LoggedADIF struct {
+	Header
+	ADIF string
+}
+
+// Decode decodes the WSJT-X message in data.
+// The error wraps ErrMalformedMessage if data is not a WSJT-X message or is truncated,
+// or is ErrUnsupportedMessage if it is a message of another type.
+func Decode(data []byte) (Message, error) {
+	d := decoder{data: data}
+	if d.uint32() != Magic {
+		return nil, fmt.Errorf("%w: bad magic number", ErrMalformedMessage)
+	}
+	h := Header{Schema: d.uint32(), Type: MessageType(d.uint32()), ID: d.utf8()}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var m Message
+	switch h.Type {
+	case TypeHeartbeat:
+		m = &Heartbeat{Header: h, MaxSchema: d.uint32(), Version: d.utf8(), Revision: d.utf8()}
+	case TypeStatus:
+		s := &Status{Header: h}
+		s.DialFrequency, s.Mode, s.DXCall, s.Report, s.TxMode = d.uint64(), d.utf8(), d.utf8(), d.utf8(), d.utf8()
+		s.TxEnabled, s.Transmitting, s.Decoding = d.bool(), d.bool(), d.bool()
+		s.RxDF, s.TxDF, s.DECall, s.DEGrid, s.DXGrid = d.uint32(), d.uint32(), d.utf8(), d.utf8(), d.utf8()
+		d.optional = true
+		s.TxWatchdog, s.SubMode, s.FastMode, s.SpecialOperationMode = d.bool(), d.utf8(), d.bool(), d.uint8()
+		s.FrequencyTolerance, s.TRPeriod, s.ConfigurationName, s.TxMessage = d.uint32(), d.uint32(), d.utf8(), d.utf8()
+		m = s
+	case TypeQSOLogged:
+		q := &QSOLogged{Header: h}
+		q.TimeOff, q.DXCall, q.DXGrid, q.TxFrequency = d.dateTime(), d.utf8(), d.utf8(), d.uint64()
+		q.Mode, q.ReportSent, q.ReportReceived, q.TxPower = d.utf8(), d.utf8(), d.utf8(), d.utf8()
+		q.Comments, q.Name = d.utf8(), d.utf8()
+		d.optional = true
+		q.TimeOn, q.OperatorCall, q.MyCall, q.MyGrid = d.dateTime(), d.utf8(), d.utf8(), d.utf8()
+		q.ExchangeSent, q.ExchangeReceived, q.PropagationMode = d.utf8(), d.utf8(), d.utf8()
+		m = q
+	case TypeLoggedADIF:
+		m = &LoggedADIF{Header: h, ADIF: d.utf8()}
+	default:
+		return nil, ErrUnsupportedMessage
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return m, nil
+}
+
+// decoder reads big-endian QDataStream values from data.
+// After a read fails, err is set and all further reads return zero values.
+type decoder struct {
+	data []byte
+	err  error
+
+	// optional is set once the fields that every version of a message has are read.
+	// The input may then end between fields, leaving the remaining fields at their zero values.
+	optional bool
+}
+
+// next returns the next n bytes, or nil at the end of the input.
+func (d *decoder) next(n int) []byte {
+	if d.err != nil || d.optional && len(d.data) == 0 {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = fmt.Errorf("%w: truncated", ErrMalformedMessage)
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) bool() bool {
+	return d.uint8() != 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// utf8 reads a QByteArray holding UTF-8 text. A null QByteArray, with length 0xffffffff, reads as "".
+func (d *decoder) utf8() string {
+	n := d.uint32()
+	if n == 0xffffffff {
+		return ""
+	}
+	if int64(n) > int64(len(d.data)) {
+		d.next(len(d.data) + 1) // reports the truncation
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+// julianDayUnixEpoch is the Julian day number of 1970-01-01, the day from which QDate counts as a Julian day number.
+const julianDayUnixEpoch = 2440588
+
+// QDateTime time specifications.
+const (
+	qtLocalTime     = 0
+	qtUTC           = 1
+	qtOffsetFromUTC = 2
+)
+
+// dateTime reads a QDateTime: a QDate as a Julian day number, a QTime as milliseconds since midnight,
+// and a time specification, followed by the offset in seconds for an offset from UTC.
+// A null date reads as the zero time.Time. Times with a named time zone are not supported.
+func (d *decoder) dateTime() time.Time {
+	day, ms, spec := int64(d.uint64()), d.uint32(), d.uint8()
+	loc := time.UTC
+	switch spec {
+	case qtLocalTime:
+		loc = time.Local
+	case qtUTC:
+	case qtOffsetFromUTC:
+		offset := int32(d.uint32())
+		loc = time.FixedZone("", int(offset))
+	default:
+		d.err = fmt.Errorf("%w: unsupported time specification %d", ErrMalformedMessage, spec)
+		return time.Time{}
+	}
+	if day <= 0 || day > 5373484 { // null, or after the year 9999
+		return time.Time{}
+	}
+	if ms == 0xffffffff { // null time
+		ms = 0
+	}
+	t := time.Date(1970, 1, 1+int(day-julianDayUnixEpoch), 0, 0, 0, 0, loc)
+	return t.Add(time.Duration(ms) * time.Millisecond)
+}
@@ -0,0 +1,189 @@
+package wsjtx
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// encoder builds QDataStream encoded test messages.
+type encoder struct{ buf []byte }
+
+func newMessage(t MessageType, id string) *encoder {
+	e := &encoder{}
+	return e.uint32(Magic).uint32(3).uint32(uint32(t)).utf8(id)
+}
+
+func (e *encoder) uint8(v uint8) *encoder { e.buf = append(e.buf, v); return e }
+
+func (e *encoder) bool(v bool) *encoder {
+	if v {
+		return e.uint8(1)
+	}
+	return e.uint8(0)
+}
+
+func (e *encoder) uint32(v uint32) *encoder {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+	return e
+}
+func (e *encoder) uint64(v uint64) *encoder {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+	return e
+}
+
+func (e *encoder) utf8(s string) *encoder {
+	return e.uint32(uint32(len(s))).bytes(s)
+}
+
+func (e *encoder) bytes(s string) *encoder { e.buf = append(e.buf, s...); return e }
+
+// dateTime encodes t as a QDateTime in UTC.
+func (e *encoder) dateTime(t time.Time) *encoder {
+	t = t.UTC()
+	day := t.Unix()/86400 + julianDayUnixEpoch
+	ms := t.Sub(t.Truncate(24 * time.Hour)).Milliseconds()
+	return e.uint64(uint64(day)).uint32(uint32(ms)).uint8(qtUTC)
+}
+
+var (
+	testTimeOn  = time.Date(2024, 7, 4, 23, 59, 45, 0, time.UTC)
+	testTimeOff = time.Date(2024, 7, 5, 0, 0, 15, 0, time.UTC)
+)
+
+// qsoLogged encodes the QSO Logged message of testQSO, up to but excluding the optional fields when short is set.
+func qsoLogged(short bool) *encoder {
+	e := newMessage(TypeQSOLogged, "WSJT-X").dateTime(testTimeOff).utf8("DL1ABC").utf8("JO62").uint64(14076500).
+		utf8("FT4").utf8("-05").utf8("+02").utf8("100").utf8("tnx").utf8("Hans")
+	if short {
+		return e
+	}
+	return e.dateTime(testTimeOn).utf8("W9PVA").utf8("K9CTS").utf8("EN52").utf8("599 IL").utf8("599 DL").utf8("")
+}
+
+var testQSO = QSOLogged{
+	Header:           Header{Schema: 3, Type: TypeQSOLogged, ID: "WSJT-X"},
+	TimeOff:          testTimeOff,
+	DXCall:           "DL1ABC",
+	DXGrid:           "JO62",
+	TxFrequency:      14076500,
+	Mode:             "FT4",
+	ReportSent:       "-05",
+	ReportReceived:   "+02",
+	TxPower:          "100",
+	Comments:         "tnx",
+	Name:             "Hans",
+	TimeOn:           testTimeOn,
+	OperatorCall:     "W9PVA",
+	MyCall:           "K9CTS",
+	MyGrid:           "EN52",
+	ExchangeSent:     "599 IL",
+	ExchangeReceived: "599 DL",
+}
+
+func TestDecode(t *testing.T) {
+	shortQSO := testQSO
+	shortQSO.TimeOn, shortQSO.OperatorCall, shortQSO.MyCall, shortQSO.MyGrid = time.Time{}, "", "", ""
+	shortQSO.ExchangeSent, shortQSO.ExchangeReceived = "", ""
+
+	status := newMessage(TypeStatus, "JTDX").uint64(7074000).utf8("FT8").utf8("DL1ABC").utf8("-10").utf8("FT8").
+		bool(true).bool(false).bool(true).uint32(1200).uint32(1500).utf8("K9CTS").utf8("EN52").utf8("JO62")
+	shortStatus := &Status{Header: Header{Schema: 3, Type: TypeStatus, ID: "JTDX"}, DialFrequency: 7074000, Mode: "FT8",
+		DXCall: "DL1ABC", Report: "-10", TxMode: "FT8", TxEnabled: true, Decoding: true, RxDF: 1200, TxDF: 1500,
+		DECall: "K9CTS", DEGrid: "EN52", DXGrid: "JO62"}
+	shortStatusBytes := append([]byte(nil), status.buf...)
+	fullStatus := *shortStatus
+	fullStatus.TxWatchdog, fullStatus.SubMode, fullStatus.FastMode, fullStatus.SpecialOperationMode = true, "", true, 5
+	fullStatus.FrequencyTolerance, fullStatus.TRPeriod, fullStatus.ConfigurationName, fullStatus.TxMessage = 20, 15, "Default", "DL1ABC K9CTS EN52"
+	status.bool(true).uint32(0xffffffff).bool(true).uint8(5).uint32(20).uint32(15).utf8("Default").utf8("DL1ABC K9CTS EN52")
+
+	tests := []struct {
+		name string
+		data []byte
+		want Message
+	}{
+		{"heartbeat", newMessage(TypeHeartbeat, "WSJT-X").uint32(3).utf8("2.7.0").utf8("abc123").buf,
+			&Heartbeat{Header: Header{Schema: 3, Type: TypeHeartbeat, ID: "WSJT-X"}, MaxSchema: 3, Version: "2.7.0", Revision: "abc123"}},
+		{"status", status.buf, &fullStatus},
+		{"status of an old version", shortStatusBytes, shortStatus},
+		{"QSO logged", qsoLogged(false).buf, &testQSO},
+		{"QSO logged by an old version", qsoLogged(true).buf, &shortQSO},
+		{"logged ADIF", newMessage(TypeLoggedADIF, "WSJT-X").utf8("<call:5>K9CTS<eor>").buf,
+			&LoggedADIF{Header: Header{Schema: 3, Type: TypeLoggedADIF, ID: "WSJT-X"}, ADIF: "<call:5>K9CTS<eor>"}},
+	}
+	for _, tt := range tests {
+		got, err := Decode(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+		if got.MessageHeader() != reflect.ValueOf(tt.want).Elem().Field(0).Interface().(Header) {
+			t.Errorf("%s: MessageHeader got %+v", tt.name, got.MessageHeader())
+		}
+	}
+}
+
+func TestDecode_DateTime(t *testing.T) {
+	date := func(day uint64, ms uint32, spec uint8, offset ...uint32) *encoder {
+		e := newMessage(TypeQSOLogged, "").uint64(day).uint32(ms).uint8(spec)
+		for _, o := range offset {
+			e.uint32(o)
+		}
+		return e.utf8("").utf8("").uint64(0).utf8("").utf8("").utf8("").utf8("").utf8("").utf8("")
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want time.Time
+	}{
+		{"UTC", date(2460496, 86399999, qtUTC).buf, time.Date(2024, 7, 4, 23, 59, 59, 999e6, time.UTC)},
+		{"local", date(2440588, 0, qtLocalTime).buf, time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"offset", date(2440588, 3600000, qtOffsetFromUTC, uint32(0xffffffff-3599)).buf, time.Date(1970, 1, 1, 2, 0, 0, 0, time.UTC)},
+		{"null date", date(1<<63, 0xffffffff, qtLocalTime).buf, time.Time{}},
+		{"null time", date(2440588, 0xffffffff, qtUTC).buf, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"year 10000", date(5373485, 0, qtUTC).buf, time.Time{}},
+	}
+	for _, tt := range tests {
+		m, err := Decode(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := m.(*QSOLogged).TimeOff; !got.Equal(tt.want) || got.IsZero() != tt.want.IsZero() {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	full := qsoLogged(false).buf
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrMalformedMessage},
+		{"bad magic", append([]byte{0, 0, 0, 0}, full[4:]...), ErrMalformedMessage},
+		{"truncated header", full[:14], ErrMalformedMessage},
+		{"truncated string", full[:20], ErrMalformedMessage},
+		{"truncated required field", qsoLogged(true).buf[:60], ErrMalformedMessage},
+		{"truncated optional field", full[:len(full)-2], ErrMalformedMessage},
+		{"time zone", newMessage(TypeQSOLogged, "").uint64(2440588).uint32(0).uint8(3).utf8("UTC").buf, ErrMalformedMessage},
+		{"unsupported type", newMessage(2, "WSJT-X").buf, ErrUnsupportedMessage},
+	}
+	for _, tt := range tests {
+		if m, err := Decode(tt.data); m != nil || !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, m, err, tt.err)
+		}
+	}
+
+	null := newMessage(TypeLoggedADIF, "WSJT-X").uint32(0xffffffff).buf
+	if m, err := Decode(null); err != nil || m.(*LoggedADIF).ADIF != "" {
+		t.Errorf("null string: got %+v, %v", m, err)
+	}
+}